@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+// annotationEscaper escapes the characters GitHub Actions reads as the end
+// of a workflow command message
+var annotationEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// writeAnnotations prints a GitHub Actions workflow command for each conflict
+// and high risk dependency, so they show up inline in the Actions UI.
+// Findings at or above the failOn level are errors, the others warnings.
+func writeAnnotations(w io.Writer, conflicts []string, highRisk []Dependency, failOn string) {
+	level := annotationLevel("high", failOn)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(w, "::%s title=License conflict::%s\n", level, annotationEscaper.Replace(conflict))
+	}
+	for _, dep := range highRisk {
+		message := fmt.Sprintf("%s@%s is licensed %s", dep.Name, dep.Version, dep.License)
+		fmt.Fprintf(w, "::%s title=High risk license::%s\n", level, annotationEscaper.Replace(message))
+	}
+}
+
+// annotationLevel returns error for a risk level failing the -fail-on
+// threshold and warning otherwise, including without a threshold
+func annotationLevel(risk, failOn string) string {
+	if failOn != "" && analyzer.RiskAtLeast(risk, failOn) {
+		return "error"
+	}
+	return "warning"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteAnnotations(t *testing.T) {
+	conflicts := []string{"GPL-2.0 and Apache-2.0 are incompatible"}
+	highRisk := []Dependency{{Name: "gpl-lib", Version: "1.0.0", License: "GPL-3.0"}}
+
+	var out bytes.Buffer
+	writeAnnotations(&out, conflicts, highRisk, "")
+	expected := "::warning title=License conflict::GPL-2.0 and Apache-2.0 are incompatible\n" +
+		"::warning title=High risk license::gpl-lib@1.0.0 is licensed GPL-3.0\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	// Findings meeting the -fail-on threshold are errors
+	out.Reset()
+	writeAnnotations(&out, nil, highRisk, "medium")
+	if out.String() != "::error title=High risk license::gpl-lib@1.0.0 is licensed GPL-3.0\n" {
+		t.Errorf("expected an error annotation, got %q", out.String())
+	}
+
+	// Line breaks would end the workflow command early
+	out.Reset()
+	writeAnnotations(&out, []string{"100% conflict\nsecond line"}, nil, "")
+	if out.String() != "::warning title=License conflict::100%25 conflict%0Asecond line\n" {
+		t.Errorf("expected the message escaped, got %q", out.String())
+	}
+}
+
+func TestRun_GitHubAnnotations(t *testing.T) {
+	fixture := filepath.Join("testdata", "fixtures", "npm")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-github-annotations", fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "::warning title=High risk license::gpl-lib@1.0.0 is licensed GPL-3.0") {
+		t.Errorf("expected a warning for gpl-lib, got %q", stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "{") {
+		t.Errorf("expected the JSON report on stdout as usual, got %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"-github-annotations", "-fail-on", "high", fixture}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "::error title=High risk license::gpl-lib@1.0.0") {
+		t.Errorf("expected an error for gpl-lib, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ScanDiff is what changed between a baseline report and a fresh scan,
+// comparing dependencies by name
+type ScanDiff struct {
+	BaselineRiskLevel string             `json:"baselineRiskLevel"`
+	RiskLevel         string             `json:"riskLevel"`
+	Added             []Dependency       `json:"added"`
+	Removed           []Dependency       `json:"removed"`
+	Changed           []DependencyChange `json:"changed"`
+}
+
+// DependencyChange is a dependency whose versions or licenses changed.
+// Several installed versions of a package are joined with ", ".
+type DependencyChange struct {
+	Name            string `json:"name"`
+	BaselineVersion string `json:"baselineVersion"`
+	Version         string `json:"version"`
+	BaselineLicense string `json:"baselineLicense"`
+	License         string `json:"license"`
+	BaselineRisk    string `json:"baselineRisk,omitempty"`
+	Risk            string `json:"risk,omitempty"`
+}
+
+// LicenseChanged reports whether the change is more than a version bump
+func (c DependencyChange) LicenseChanged() bool {
+	return c.BaselineLicense != c.License
+}
+
+// Empty reports whether nothing changed
+func (d *ScanDiff) Empty() bool {
+	return d.BaselineRiskLevel == d.RiskLevel && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// loadBaseline reads a JSON report saved by an earlier run
+func loadBaseline(path string) (*ScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var baseline ScanResult
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	if len(baseline.Dependencies) == 0 && len(baseline.Groups) > 0 {
+		return nil, fmt.Errorf("baseline %s was saved with -group-by and lists no dependencies", path)
+	}
+	return &baseline, nil
+}
+
+// compareScans diffs the dependencies of two reports; risk returns the risk
+// level of a license, or an empty string if it has none
+func compareScans(baseline, current *ScanResult, risk func(license string) string) *ScanDiff {
+	diff := &ScanDiff{
+		BaselineRiskLevel: baseline.Summary.RiskLevel,
+		RiskLevel:         current.Summary.RiskLevel,
+	}
+	before := dependenciesByName(baseline.Dependencies)
+	after := dependenciesByName(current.Dependencies)
+
+	for _, name := range sortedKeys(after) {
+		deps, ok := before[name]
+		if !ok {
+			diff.Added = append(diff.Added, after[name]...)
+			continue
+		}
+		baselineVersion, baselineLicense := joinVersions(deps), joinLicenses(deps)
+		version, license := joinVersions(after[name]), joinLicenses(after[name])
+		if baselineVersion == version && baselineLicense == license {
+			continue
+		}
+		change := DependencyChange{
+			Name:            name,
+			BaselineVersion: baselineVersion,
+			Version:         version,
+			BaselineLicense: baselineLicense,
+			License:         license,
+		}
+		if change.LicenseChanged() {
+			change.BaselineRisk, change.Risk = highestRisk(deps, risk), highestRisk(after[name], risk)
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+	for _, name := range sortedKeys(before) {
+		if _, ok := after[name]; !ok {
+			diff.Removed = append(diff.Removed, before[name]...)
+		}
+	}
+	return diff
+}
+
+func dependenciesByName(deps []Dependency) map[string][]Dependency {
+	byName := make(map[string][]Dependency)
+	for _, dep := range deps {
+		byName[dep.Name] = append(byName[dep.Name], dep)
+	}
+	return byName
+}
+
+func sortedKeys(byName map[string][]Dependency) []string {
+	keys := make([]string, 0, len(byName))
+	for key := range byName {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinVersions(deps []Dependency) string {
+	return joinDistinct(deps, func(dep Dependency) string { return dep.Version })
+}
+
+func joinLicenses(deps []Dependency) string {
+	return joinDistinct(deps, func(dep Dependency) string { return dep.License })
+}
+
+func joinDistinct(deps []Dependency, field func(Dependency) string) string {
+	var values []string
+	for _, dep := range deps {
+		if value := field(dep); !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ", ")
+}
+
+// highestRisk returns the highest risk level among the licenses of deps
+func highestRisk(deps []Dependency, risk func(license string) string) string {
+	rank := map[string]int{"low": 1, "medium": 2, "high": 3}
+	highest := ""
+	for _, dep := range deps {
+		if level := risk(dep.License); rank[level] > rank[highest] {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// writeDiff prints the diff for reviewing a dependency update
+func writeDiff(w io.Writer, diff *ScanDiff) {
+	if diff.BaselineRiskLevel != diff.RiskLevel {
+		fmt.Fprintf(w, "Risk level: %s -> %s\n", diff.BaselineRiskLevel, diff.RiskLevel)
+	}
+	for _, dep := range diff.Added {
+		fmt.Fprintf(w, "+ %s@%s (%s)\n", dep.Name, dep.Version, dep.License)
+	}
+	for _, dep := range diff.Removed {
+		fmt.Fprintf(w, "- %s@%s (%s)\n", dep.Name, dep.Version, dep.License)
+	}
+	for _, change := range diff.Changed {
+		line := fmt.Sprintf("~ %s %s -> %s", change.Name, change.BaselineVersion, change.Version)
+		if change.LicenseChanged() {
+			line += fmt.Sprintf(", ⚠️  license %s -> %s", change.BaselineLicense, change.License)
+			if change.BaselineRisk != change.Risk {
+				line += fmt.Sprintf(" (risk %s -> %s)", change.BaselineRisk, change.Risk)
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	if diff.Empty() {
+		fmt.Fprintln(w, "No dependency changes")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareScans(t *testing.T) {
+	var baseline, current ScanResult
+	baseline.Summary.RiskLevel = "low"
+	baseline.Dependencies = []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "lodash", Version: "4.17.20", License: "MIT"},
+		{Name: "left-pad", Version: "1.3.0", License: "WTFPL"},
+		{Name: "readline", Version: "1.0.0", License: "MIT"},
+	}
+	current.Summary.RiskLevel = "high"
+	current.Dependencies = []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT"},
+		{Name: "readline", Version: "2.0.0", License: "GPL-3.0"},
+		{Name: "ms", Version: "2.1.3", License: "MIT"},
+	}
+	risk := map[string]string{"MIT": "low", "WTFPL": "low", "GPL-3.0": "high"}
+
+	diff := compareScans(&baseline, &current, func(license string) string { return risk[license] })
+
+	if diff.BaselineRiskLevel != "low" || diff.RiskLevel != "high" {
+		t.Errorf("expected the risk level change low -> high, got %s -> %s", diff.BaselineRiskLevel, diff.RiskLevel)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "ms" {
+		t.Errorf("expected ms added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "left-pad" {
+		t.Errorf("expected left-pad removed, got %+v", diff.Removed)
+	}
+	expected := []DependencyChange{
+		{Name: "lodash", BaselineVersion: "4.17.20", Version: "4.17.21", BaselineLicense: "MIT", License: "MIT"},
+		{Name: "readline", BaselineVersion: "1.0.0", Version: "2.0.0", BaselineLicense: "MIT", License: "GPL-3.0", BaselineRisk: "low", Risk: "high"},
+	}
+	if !reflect.DeepEqual(diff.Changed, expected) {
+		t.Errorf("expected changes %+v, got %+v", expected, diff.Changed)
+	}
+
+	var out bytes.Buffer
+	writeDiff(&out, diff)
+	for _, line := range []string{
+		"Risk level: low -> high",
+		"+ ms@2.1.3 (MIT)",
+		"- left-pad@1.3.0 (WTFPL)",
+		"~ lodash 4.17.20 -> 4.17.21\n",
+		"~ readline 1.0.0 -> 2.0.0, ⚠️  license MIT -> GPL-3.0 (risk low -> high)",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected %q in the text diff, got:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestCompareScans_SeveralVersions(t *testing.T) {
+	var baseline, current ScanResult
+	baseline.Dependencies = []Dependency{
+		{Name: "ms", Version: "2.0.0", License: "MIT"},
+		{Name: "ms", Version: "2.1.3", License: "MIT"},
+	}
+	current.Dependencies = []Dependency{
+		{Name: "ms", Version: "2.1.3", License: "MIT"},
+		{Name: "ms", Version: "2.0.0", License: "MIT"},
+	}
+
+	diff := compareScans(&baseline, &current, func(string) string { return "low" })
+	if !diff.Empty() {
+		t.Errorf("expected reordered versions to be no change, got %+v", diff)
+	}
+}
+
+func TestRun_Diff(t *testing.T) {
+	fixture := filepath.Join("testdata", "fixtures", "yarn")
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-output", baseline, fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	// Rescanning the same project changes nothing
+	if code := run([]string{"-diff", baseline, "-format", "diff", fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "No dependency changes\n" {
+		t.Errorf("expected no changes, got %q", stdout.String())
+	}
+
+	// The JSON report carries the diff
+	stdout.Reset()
+	if code := run([]string{"-diff", baseline, fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var report struct {
+		Diff *ScanDiff `json:"diff"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if report.Diff == nil || !report.Diff.Empty() {
+		t.Errorf("expected an empty diff in the report, got %+v", report.Diff)
+	}
+
+	// A missing baseline fails the run
+	stderr.Reset()
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if code := run([]string{"-diff", missing, fixture}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Error loading -diff baseline") {
+		t.Errorf("expected a baseline error, got %q", stderr.String())
+	}
+}
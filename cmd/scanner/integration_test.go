@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites the golden files from the current output:
+// go test ./cmd/scanner -run TestRun_Integration -update
+var update = flag.Bool("update", false, "Rewrite the integration test golden files")
+
+// TestRun_Integration runs the whole tool against the on-disk projects in
+// testdata/fixtures, one per package manager, and compares the JSON report
+// with testdata/golden. Unlike the unit tests nothing is mocked, so path
+// resolution is exercised against real directory layouts.
+func TestRun_Integration(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		args     []string
+		wantCode int
+	}{
+		// A nested copy of ms differs from the hoisted one; gpl-lib fails -fail-on
+		{fixture: "npm", args: []string{"-fail-on", "high"}, wantCode: 1},
+		// supports-color only ships a lower-case license file
+		{fixture: "yarn"},
+		// Packages only exist in the .pnpm store, scoped ones as @scope+name
+		{fixture: "pnpm"},
+		// Components are installed in the .bowerrc directory
+		{fixture: "bower"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, filepath.Join("testdata", "fixtures", tt.fixture))
+			if code := run(args, &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d: %s", tt.wantCode, code, stderr.String())
+			}
+
+			golden := filepath.Join("testdata", "golden", tt.fixture+".json")
+			if *update {
+				if err := os.WriteFile(golden, stdout.Bytes(), 0o644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(stdout.Bytes(), want) {
+				t.Errorf("report differs from %s (rerun with -update if intended):\n%s", golden, stdout.String())
+			}
+		})
+	}
+}
@@ -1,29 +1,94 @@
 package main
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/StefanoA1/license-scanner/internal/analyzer"
+	"github.com/StefanoA1/license-scanner/internal/approval"
 	"github.com/StefanoA1/license-scanner/internal/constants"
+	"github.com/StefanoA1/license-scanner/internal/detector"
+	"github.com/StefanoA1/license-scanner/internal/history"
+	"github.com/StefanoA1/license-scanner/internal/parser"
+	"github.com/StefanoA1/license-scanner/internal/registry"
+	"github.com/StefanoA1/license-scanner/internal/report"
+	"github.com/StefanoA1/license-scanner/internal/rules"
+	"github.com/StefanoA1/license-scanner/internal/sbom"
 	"github.com/StefanoA1/license-scanner/internal/scanner"
+	"github.com/StefanoA1/license-scanner/internal/schema"
 	"github.com/StefanoA1/license-scanner/internal/templates"
+	"github.com/StefanoA1/license-scanner/internal/watch"
 )
 
 type ScanResult struct {
 	Summary struct {
-		TotalDependencies int      `json:"totalDependencies"`
-		UniqueLicenses    []string `json:"uniqueLicenses"`
-		RiskLevel         string   `json:"riskLevel"`
-		Conflicts         []string `json:"conflicts"`
-		Recommendations   []string `json:"recommendations"`
-	} `json:"summary"`
-	Dependencies []Dependency `json:"dependencies"`
-	Timestamp    string       `json:"timestamp,omitempty"`
+		TotalDependencies   int                   `json:"totalDependencies"`
+		ResultHash          string                `json:"resultHash"`
+		InstalledCoverage   float64               `json:"installedCoverage"`
+		ProjectLicense      string                `json:"projectLicense"`
+		ProjectPrivate      bool                  `json:"projectPrivate"`
+		UniqueLicenses      []string              `json:"uniqueLicenses"`
+		UnknownPercentage   float64               `json:"unknownPercentage"`
+		HighRiskCount       int                   `json:"highRiskCount"`
+		RiskScore           float64               `json:"riskScore"`
+		RiskPoints          int                   `json:"riskPoints"`
+		RiskLevel           string                `json:"riskLevel"`
+		PredominantLicense  string                `json:"predominantLicense"`
+		Conflicts           []string              `json:"conflicts"`
+		Denied              []string              `json:"denied"`
+		Recommendations     []string              `json:"recommendations,omitempty"`
+		Obligations         []analyzer.Obligation `json:"obligations"`
+		CopyleftTainted     []string              `json:"copyleftTainted"`
+		ConfidenceHistogram map[string]int        `json:"confidenceHistogram"`
+		DepthCounts         map[int]int           `json:"depthCounts"`
+		// Dependencies that cannot be distributed under -project-license
+		IncompatibleWithProject []string `json:"incompatibleWithProject,omitempty"`
+		// Recommendations with their category, severity and affected packages
+		StructuredRecommendations []analyzer.Recommendation `json:"structuredRecommendations,omitempty"`
+		// Conflicts with the direct and transitive dependencies involved
+		StructuredConflicts []analyzer.Conflict `json:"structuredConflicts,omitempty"`
+		// Scanner warnings such as tampered packages or an incomplete
+		// install, reported even with -no-recommendations
+		Warnings []string `json:"warnings,omitempty"`
+	} `json:"summary,omitzero"`
+	Dependencies []Dependency   `json:"dependencies,omitempty"`
+	Groups       []report.Group `json:"groups,omitempty"`
+	// Dependencies by remediation action (-format actions)
+	Actions []report.Group `json:"-"`
+	// Dependencies by risk section (-format console-grouped)
+	Sections []report.Group `json:"-"`
+	// Dependencies with their package manager (-format cyclonedx, spdx-json, spdx-tag)
+	Components []sbom.Component `json:"-"`
+	Timestamp  string           `json:"timestamp,omitempty"`
+	// Per detection source counts and average confidence (-metrics)
+	Metrics map[string]scanner.SourceMetric `json:"metrics,omitempty"`
+	// Changes not covered by the approved snapshot (-approved)
+	ApprovalViolations []approval.Violation `json:"approvalViolations,omitempty"`
+	// License texts edited since they were reviewed (-reviewed-hashes)
+	TextReviewViolations []approval.Violation `json:"textReviewViolations,omitempty"`
+	// Licenses of a package and its transitive dependencies (-footprint)
+	Footprint *analyzer.Footprint `json:"footprint,omitempty"`
+	// Changes since a baseline report (-diff)
+	Diff *ScanDiff `json:"diff,omitempty"`
+	// Differences from an externally produced SBOM (-compare-sbom)
+	SBOMDrift *sbom.Drift `json:"sbomDrift,omitempty"`
+	// Projects that could not be scanned, by path
+	ProjectErrors map[string]string `json:"projectErrors,omitempty"`
 }
 
 type Dependency struct {
@@ -32,28 +97,261 @@ type Dependency struct {
 	License    string  `json:"license"`
 	Confidence float64 `json:"confidence"`
 	Source     string  `json:"source"`
+	// SHA-256 of the license file the detection was based on (-include-license-text-hash)
+	LicenseTextHash string `json:"licenseTextHash,omitempty"`
+	// Only the head of an oversized license file was matched (-max-license-size)
+	LicenseTruncated bool `json:"licenseTruncated,omitempty"`
+	// Pre-approved by exact version and excluded from gating (-allow-file)
+	Approved bool `json:"approved,omitempty"`
+	// License reported by the registry when it differs from the local detection
+	RegistryLicense string `json:"registryLicense,omitempty"`
+	// Version pinned by package.json overrides or resolutions
+	Overridden bool `json:"overridden,omitempty"`
+	// Shipped inside the package via bundleDependencies
+	Bundled bool `json:"bundled,omitempty"`
+	// Referenced through a file: or link: specifier and excluded from risk
+	Local bool `json:"local,omitempty"`
+	// Version is a package.json range as the project has no lock file
+	Unresolved bool `json:"unresolved,omitempty"`
+	// Listed in the root package.json rather than pulled in transitively
+	Direct bool `json:"direct,omitempty"`
+	// Workspace members depending on the package (-workspaces)
+	Workspaces []string `json:"workspaces,omitempty"`
+	// Commit that added the dependency to the lock file (-git-introduced)
+	Introduced *history.Introduction `json:"introduced,omitempty"`
+	// Category of the license as the configured analyzer classifies it
+	Category analyzer.LicenseCategory `json:"-"`
 }
 
 func main() {
-	// Parse command line flags
-	verbose := flag.Bool("verbose", false, "Enable verbose logging")
-	format := flag.String("format", "json", "Output format (json, html)")
-	_ = flag.Bool("prod-only", false, "Scan production dependencies only")
-	_ = flag.Bool("no-summary", false, "Skip license summary")
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run is the whole command line tool: it parses args, writes the report to
+// stdout and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	// The schema subcommand documents the JSON output for integrations
+	if len(args) > 0 && args[0] == "schema" {
+		if err := writeSchema(stdout); err != nil {
+			fmt.Fprintf(stderr, "Error writing schema: %v\n", err)
+			return 1
+		}
+		return 0
+	}
 
-	// Get project path from remaining arguments
-	projectPath := "."
-	if flag.NArg() > 0 {
-		projectPath = flag.Arg(0)
+	// Parse command line flags
+	flags := flag.NewFlagSet("license-scanner", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	verbose := flags.Bool("verbose", false, "Enable verbose logging")
+	format := flags.String("format", "json", "Output format (json, html, actions, console-grouped, cyclonedx, spdx-json, spdx-tag, metrics-line, diff, csv)")
+	prodOnly := flags.Bool("prod-only", false, "Scan production dependencies only")
+	directOnly := flags.Bool("direct-only", false, "Scan the dependencies listed in the root package.json only, leaving out transitive ones")
+	noSummary := flags.Bool("no-summary", false, "Skip license summary")
+	noRecommendations := flags.Bool("no-recommendations", false, "Skip the recommendations, keeping the rest of the summary")
+	projectLicense := flags.String("project-license", "", "SPDX id of the license the project is distributed under, to flag dependencies incompatible with it")
+	aliasFile := flags.String("license-aliases", "", "Path to a JSON/YAML file mapping license aliases to canonical ids")
+	logo := flags.String("logo", "", "Image file embedded in the HTML report header")
+	title := flags.String("title", "", "Custom title for the HTML report, also used as the SPDX document name")
+	approvedFile := flags.String("approved", "", "Fail if licenses changed relative to this approved snapshot file")
+	approve := flags.Bool("approve", false, "Write the current dependency licenses to the -approved snapshot file")
+	reviewedHashes := flags.String("reviewed-hashes", "", "Fail if a license text changed relative to this reviewed hashes file, even at the same version")
+	reviewHashes := flags.Bool("review-hashes", false, "Write the current license text hashes to the -reviewed-hashes file")
+	allowFile := flags.String("allow-file", "", "File listing pre-approved name@version packages excluded from risk and failure gating")
+	useRegistry := flags.Bool("registry", false, "Look up undetected licenses in the npm registry (pkg.go.dev for Go modules) and flag local licenses it disagrees with")
+	registryURL := flags.String("registry-url", registry.DefaultURL, "Registry used for online license lookups")
+	repoLicense := flags.Bool("repo-license", false, "Fetch the LICENSE of packages without a local license from their GitHub repository at the pinned commit")
+	registryCache := flags.String("registry-cache", "", "Directory persisting registry responses across runs")
+	registryCacheTTL := flags.Duration("registry-cache-ttl", registry.DefaultCacheTTL, "How long cached registry responses are reused")
+	denyCategory := flags.String("deny-category", "", "Comma-separated license categories to block (permissive, weakCopyleft, strongCopyleft, proprietary, unknown)")
+	excludeTypes := flags.Bool("exclude-types", false, "Exclude @types/* stub packages from risk while still listing them")
+	excludeRisk := flags.String("exclude-risk", "", "Comma-separated package name patterns (e.g. @types/*,@babel/*) excluded from risk while still listed")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license catalog merged over the built-in license classifications")
+	diffBaseline := flags.String("diff", "", "Path to a JSON report of an earlier scan to list added, removed and changed dependencies against")
+	compareSBOM := flags.String("compare-sbom", "", "Path to a CycloneDX or SPDX JSON SBOM to diff components and licenses against")
+	trustSBOM := flags.String("trust-sbom", "", "Path to a CycloneDX or SPDX JSON SBOM whose declared licenses are trusted for the packages it covers")
+	policyFile := flags.String("policy", "", "Path to a JSON/YAML policy file of custom when/then license rules")
+	severityMap := flags.String("severity-map", "", "Path to a JSON/YAML file mapping license categories to risk levels (low, medium, high)")
+	packages := flags.String("packages", "", "Comma-separated packages (name or name@version) to restrict the scan to")
+	footprint := flags.String("footprint", "", "Report the licenses of this package and its whole transitive subtree")
+	packagesSubtree := flags.Bool("packages-subtree", false, "Also include the dependencies of the -packages filter")
+	metrics := flags.Bool("metrics", false, "Include per detection source counts and average confidence")
+	maxLicenseSize := flags.Int64("max-license-size", detector.DefaultMaxLicenseSize, "Maximum bytes of a LICENSE file read for license matching")
+	includeTextHash := flags.Bool("include-license-text-hash", false, "Include a SHA-256 of each detected license file for audit evidence")
+	output := flags.String("output", "", "Write the report to this file instead of stdout; a .json, .html or .csv extension sets the format unless -format is given")
+	detailsFile := flags.String("details-file", "", "Write the full report to this file and print only a text summary to stdout")
+	concurrency := flags.Int("concurrency", scanner.DefaultConcurrency, "How many projects are scanned in parallel when several paths are given")
+	workspaces := flags.Bool("workspaces", false, "Merge npm workspace members with their own package-lock.json and annotate each dependency with the members using it")
+	workers := flags.Int("workers", 0, "How many dependencies of a project have their license detected in parallel (0 uses one per CPU)")
+	failUnknownAbove := flags.Float64("fail-unknown-above", -1, "Fail if more than this percentage of dependencies have unknown licenses (disabled when negative)")
+	failOn := flags.String("fail-on", "", "Fail if the overall risk level is at or above this level (low, medium, high)")
+	githubAnnotations := flags.Bool("github-annotations", false, "Also print GitHub Actions annotations for conflicts and high risk dependencies, as errors when they meet -fail-on")
+	riskBudget := flags.Int("risk-budget", -1, "Fail if the risk points (unknown 1, weak copyleft 3, strong copyleft 10, conflict 20) exceed this budget (disabled when negative)")
+	exitZero := flags.Bool("exit-zero", false, "Always exit 0 once the report is written, even with failing findings")
+	groupBy := flags.String("group-by", "", "Group dependencies in the output (license, category, manager, project)")
+	gitIntroduced := flags.Bool("git-introduced", false, "Attribute each dependency to the commit that added it to the lock file (git blame)")
+	watchMode := flags.Bool("watch", false, "Re-scan and print a fresh summary whenever a project's lock file changes")
+	rootFS := flags.Bool("rootfs", false, "Treat paths as unpacked container root filesystems and report the OS, node_modules and site-packages packages installed in them")
+	cpuProfile := flags.String("cpuprofile", "", "Write a CPU profile to this file")
+	memProfile := flags.String("memprofile", "", "Write a heap profile to this file when the scan completes")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *output != "" {
+		if *detailsFile != "" {
+			fmt.Fprintln(stderr, "Error: -output cannot be combined with -details-file")
+			return 2
+		}
+		formatSet := false
+		flags.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+		if !formatSet {
+			*format = outputFormat(*output, *format)
+		}
 	}
 
-	// Create and run scanner
-	s := scanner.NewWithVerbose(projectPath, *verbose)
-	scanResult, err := s.Scan()
+	// Profiles go to files only, so they never affect the report. They are
+	// written on every return, so failed runs can be profiled too.
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error scanning project: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Error starting profiling: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := stopProfiling(); err != nil {
+			fmt.Fprintf(stderr, "Error writing profile: %v\n", err)
+		}
+	}()
+
+	if *failOn != "" && !analyzer.ValidRiskLevel(*failOn) {
+		fmt.Fprintf(stderr, "Error: invalid -fail-on level %q (expected low, medium or high)\n", *failOn)
+		return 1
+	}
+
+	if *rootFS && *gitIntroduced {
+		fmt.Fprintln(stderr, "Error: -git-introduced needs a lock file and cannot be combined with -rootfs")
+		return 1
+	}
+
+	// Get project paths from remaining arguments
+	projectPaths := flags.Args()
+	if len(projectPaths) == 0 {
+		projectPaths = []string{"."}
+	}
+
+	if *watchMode {
+		if *rootFS {
+			fmt.Fprintln(stderr, "Error: -watch needs a lock file and cannot be combined with -rootfs")
+			return 1
+		}
+		// -output already sends the report to a file
+		reportFile := *detailsFile
+		if *output != "" {
+			reportFile = *output
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := runWatch(ctx, args, projectPaths, *format, reportFile, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "Error watching lock files: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// The license catalog also caps text match confidence during the scan
+	var catalog *analyzer.Catalog
+	if *licenseDB != "" {
+		catalog, err = analyzer.LoadCatalog(*licenseDB)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading license catalog: %v\n", err)
+			return 1
+		}
+	}
+
+	// Packages covered by a trusted SBOM skip detection and registry lookups
+	var sbomLicenses map[string]string
+	if *trustSBOM != "" {
+		components, err := sbom.Load(*trustSBOM)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading trusted SBOM: %v\n", err)
+			return 1
+		}
+		sbomLicenses = sbom.Licenses(components)
+	}
+
+	// Create and run a scanner per project; a failing project does not stop the others
+	var client *registry.Client
+	if *useRegistry || *registryCache != "" {
+		client = registry.NewWithURL(*registryURL)
+		if *registryCache != "" {
+			client = registry.NewWithCache(*registryURL, registry.NewCache(*registryCache, *registryCacheTTL))
+		}
+	}
+	projects := scanner.ScanProjects(projectPaths, *concurrency, func(path string) *scanner.Scanner {
+		s := scanner.NewWithVerbose(path, *verbose)
+		s.SetRootFS(*rootFS)
+		s.SetMaxLicenseSize(*maxLicenseSize)
+		s.SetProdOnly(*prodOnly)
+		s.SetDirectOnly(*directOnly)
+		s.SetWorkers(*workers)
+		s.SetWorkspaces(*workspaces)
+		s.SetSBOMLicenses(sbomLicenses)
+		if catalog != nil {
+			s.SetConfidenceCaps(catalog.TextConfidence)
+		}
+		if *repoLicense {
+			s.SetRepositoryClient(registry.NewRawClient())
+		}
+		if client != nil {
+			s.SetRegistry(client)
+			// Go modules are not published to the npm registry; pkg.go.dev
+			// stands in for modules missing from the module cache
+			goModules := detector.NewGoModuleDetector(detector.DefaultGoModCache())
+			goModules.SetOnlineLookup(registry.NewGoClient())
+			s.SetGoModuleDetector(goModules)
+		}
+		if *packages != "" {
+			s.SetPackageFilter(strings.Split(*packages, ","), *packagesSubtree)
+		}
+		return s
+	})
+
+	var result ScanResult
+	for _, project := range projects {
+		if project.Err != nil {
+			fmt.Fprintf(stderr, "Error scanning project %s: %v\n", project.Path, project.Err)
+			if result.ProjectErrors == nil {
+				result.ProjectErrors = make(map[string]string)
+			}
+			result.ProjectErrors[project.Path] = project.Err.Error()
+		}
+	}
+	if len(result.ProjectErrors) == len(projects) {
+		return 1
+	}
+
+	// Attribute dependencies to the commits that introduced them
+	if *gitIntroduced {
+		for _, project := range projects {
+			if project.Err != nil {
+				continue
+			}
+			if err := project.Result.AnnotateIntroductions(); err != nil {
+				fmt.Fprintf(stderr, "Error reading git history of %s: %v\n", project.Path, err)
+				return 1
+			}
+		}
+	}
+	scanResult := scanner.Merge(projects)
+
+	allowed := map[string]bool{}
+	if *allowFile != "" {
+		allowed, err = approval.LoadAllowList(*allowFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading allow file: %v\n", err)
+			return 1
+		}
 	}
 
 	// Convert scanner result to CLI output format
@@ -72,46 +370,550 @@ func main() {
 			License:    license,
 			Confidence: dep.Confidence,
 			Source:     dep.Source,
+			Approved:   allowed[dep.Name+"@"+dep.Version],
+
+			LicenseTruncated: dep.LicenseTruncated,
+			RegistryLicense:  dep.RegistryLicense,
+			Overridden:       dep.Overridden,
+			Bundled:          dep.Bundled,
+			Local:            dep.Local,
+			Unresolved:       dep.Unresolved,
+			Direct:           dep.Direct,
+			Workspaces:       dep.Workspaces,
+			Introduced:       dep.Introduced,
+		}
+		if *includeTextHash {
+			dependencies[i].LicenseTextHash = dep.TextHash
 		}
 
 		analyzerDeps[i] = analyzer.Dependency{
-			Name:       dep.Name,
-			Version:    dep.Version,
-			License:    license,
-			Confidence: dep.Confidence,
+			Name:         dep.Name,
+			Version:      dep.Version,
+			License:      license,
+			Confidence:   dep.Confidence,
+			Dependencies: dep.Dependencies,
+			Depth:        dep.Depth,
+			Bundled:      dep.Bundled,
+			Local:        dep.Local,
+			Direct:       dep.Direct,
+		}
+	}
+
+	// Record or enforce the reviewed license texts
+	if *reviewedHashes != "" {
+		reviewDeps := make([]approval.Dependency, 0, len(dependencies))
+		for i, dep := range dependencies {
+			if dep.Approved && !*reviewHashes {
+				continue
+			}
+			reviewDeps = append(reviewDeps, approval.Dependency{
+				Name:     dep.Name,
+				Version:  dep.Version,
+				License:  dep.License,
+				TextHash: scanResult.Dependencies[i].TextHash,
+			})
+		}
+
+		if *reviewHashes {
+			reviewed := approval.NewReviewedHashes(reviewDeps)
+			if err := reviewed.Save(*reviewedHashes); err != nil {
+				fmt.Fprintf(stderr, "Error recording reviewed license texts: %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(stderr, "Recorded %d reviewed license texts in %s\n", len(reviewed.Packages), *reviewedHashes)
+			if !*approve {
+				return 0
+			}
+		} else {
+			reviewed, err := approval.LoadReviewedHashes(*reviewedHashes)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error loading reviewed hashes: %v\n", err)
+				return 1
+			}
+			result.TextReviewViolations = reviewed.Check(reviewDeps)
+		}
+	} else if *reviewHashes {
+		fmt.Fprintln(stderr, "Error: -review-hashes requires -reviewed-hashes <file>")
+		return 1
+	}
+
+	// Record or enforce the approved license snapshot
+	if *approvedFile != "" {
+		approvalDeps := make([]approval.Dependency, 0, len(dependencies))
+		for _, dep := range dependencies {
+			if dep.Approved && !*approve {
+				continue
+			}
+			approvalDeps = append(approvalDeps, approval.Dependency{Name: dep.Name, Version: dep.Version, License: dep.License})
+		}
+
+		if *approve {
+			if err := approval.NewSnapshot(approvalDeps).Save(*approvedFile); err != nil {
+				fmt.Fprintf(stderr, "Error approving licenses: %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(stderr, "Approved %d dependencies in %s\n", len(approvalDeps), *approvedFile)
+			return 0
+		}
+
+		snapshot, err := approval.Load(*approvedFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading approved snapshot: %v\n", err)
+			return 1
+		}
+		result.ApprovalViolations = snapshot.Check(approvalDeps)
+	} else if *approve {
+		fmt.Fprintln(stderr, "Error: -approve requires -approved <file>")
+		return 1
+	}
+
+	// Validate an externally produced SBOM against what was found on disk
+	if *compareSBOM != "" {
+		components, err := sbom.Load(*compareSBOM)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading SBOM: %v\n", err)
+			return 1
 		}
+		scanned := make([]sbom.Component, len(dependencies))
+		for i, dep := range dependencies {
+			scanned[i] = sbom.Component{Name: dep.Name, Version: dep.Version, License: dep.License}
+		}
+		result.SBOMDrift = sbom.Compare(scanned, components)
 	}
 
 	// Perform license analysis
 	licenseAnalyzer := analyzer.New()
+	if *aliasFile != "" {
+		aliases, err := analyzer.LoadAliases(*aliasFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading license aliases: %v\n", err)
+			return 1
+		}
+		licenseAnalyzer = analyzer.NewWithAliases(aliases)
+	}
+	licenseAnalyzer.SetPrivate(scanResult.ProjectPrivate)
+	for pkg := range allowed {
+		licenseAnalyzer.Approve(pkg)
+	}
+	if *excludeTypes {
+		licenseAnalyzer.ExcludeFromRisk(analyzer.TypesPattern)
+	}
+	if *excludeRisk != "" {
+		licenseAnalyzer.ExcludeFromRisk(strings.Split(*excludeRisk, ",")...)
+	}
+	if catalog != nil {
+		licenseAnalyzer.UseCatalog(catalog)
+	}
+	if *severityMap != "" {
+		severities, err := analyzer.LoadSeverities(*severityMap)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading severity map: %v\n", err)
+			return 1
+		}
+		licenseAnalyzer.SetSeverities(severities)
+	}
+	licenseAnalyzer.SetSkipRecommendations(*noRecommendations)
+	if err := licenseAnalyzer.SetProjectLicense(*projectLicense); err != nil {
+		fmt.Fprintf(stderr, "Error parsing -project-license: %v\n", err)
+		return 1
+	}
+	if *denyCategory != "" {
+		for _, name := range strings.Split(*denyCategory, ",") {
+			category, err := analyzer.ParseCategory(name)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error parsing -deny-category: %v\n", err)
+				return 1
+			}
+			licenseAnalyzer.DenyCategories(category)
+		}
+	}
 	analysis := licenseAnalyzer.Analyze(analyzerDeps)
+	for i := range dependencies {
+		dependencies[i].Category = licenseAnalyzer.Category(dependencies[i].License)
+	}
+	if *footprint != "" {
+		result.Footprint, err = licenseAnalyzer.LicenseFootprint(analyzerDeps, *footprint)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error resolving -footprint: %v\n", err)
+			return 1
+		}
+	}
 
-	// Build unique licenses list from analysis
+	// Custom policy rules add findings on top of the built-in analysis
+	var findings []rules.Finding
+	if *policyFile != "" {
+		policy, err := rules.Load(*policyFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading policy: %v\n", err)
+			return 1
+		}
+		var ruleDeps []rules.Dependency
+		for i, dep := range analyzerDeps {
+			// Pre-approved packages are exempt from policies as from risk
+			if dependencies[i].Approved {
+				continue
+			}
+			ruleDeps = append(ruleDeps, rules.Dependency{
+				Name:     dep.Name,
+				Version:  dep.Version,
+				License:  licenseAnalyzer.Normalize(dep.License),
+				Category: licenseAnalyzer.Category(dep.License),
+			})
+		}
+		findings = policy.Evaluate(ruleDeps, scanResult.ProjectPrivate)
+	}
+
+	// Build unique licenses list from analysis, sorted as the counts are a
+	// map and reports must not change between identical runs
 	var uniqueLicensesList []string
 	for license := range analysis.LicenseCounts {
 		if license != constants.UnknownLicense {
 			uniqueLicensesList = append(uniqueLicensesList, license)
 		}
 	}
+	sort.Strings(uniqueLicensesList)
 
-	result := ScanResult{
-		Dependencies: dependencies,
-	}
-
+	result.Dependencies = dependencies
 	result.Summary.TotalDependencies = len(dependencies)
+	result.Summary.ResultHash = scanResult.Hash()
+	if *metrics {
+		result.Metrics = scanResult.SourceMetrics
+	}
+	// The license dependencies were checked against, else package.json's
+	result.Summary.ProjectLicense = scanResult.ProjectLicense
+	if *projectLicense != "" {
+		result.Summary.ProjectLicense = *projectLicense
+	}
+	result.Summary.ProjectPrivate = scanResult.ProjectPrivate
+	result.Summary.InstalledCoverage = scanResult.InstalledCoverage
 	result.Summary.UniqueLicenses = uniqueLicensesList
-	result.Summary.RiskLevel = analysis.RiskLevel
+	result.Summary.UnknownPercentage = math.Round(analysis.UnknownPercentage()*10) / 10
+	result.Summary.HighRiskCount = analysis.SeverityCounts["high"]
+	result.Summary.RiskScore = math.Round(analysis.RiskScore()*10) / 10
+	result.Summary.RiskPoints = analysis.RiskPoints()
+	result.Summary.RiskLevel = rules.RaiseRiskLevel(analysis.RiskLevel, findings)
+	result.Summary.PredominantLicense = analysis.PredominantLicense
 	result.Summary.Conflicts = analysis.Conflicts
-	result.Summary.Recommendations = analysis.Recommendations
+	result.Summary.StructuredConflicts = analysis.StructuredConflicts
+	result.Summary.Warnings = scanResult.Warnings
+	if !*noRecommendations {
+		result.Summary.Recommendations = append(result.Summary.Recommendations, analysis.Recommendations...)
+		result.Summary.StructuredRecommendations = analysis.StructuredRecommendations
+		for _, finding := range findings {
+			result.Summary.Recommendations = append(result.Summary.Recommendations, finding.Recommendation())
+		}
+		if result.SBOMDrift != nil && !result.SBOMDrift.Empty() {
+			result.Summary.Recommendations = append(result.Summary.Recommendations, fmt.Sprintf(
+				"⚠️  SBOM drift: %d packages missing from the SBOM, %d not found by the scan, %d license mismatches",
+				len(result.SBOMDrift.Missing), len(result.SBOMDrift.Extra), len(result.SBOMDrift.LicenseMismatches)))
+		}
+	}
+	result.Summary.Obligations = analysis.Obligations
+	result.Summary.Denied = analysis.Denied
+	result.Summary.IncompatibleWithProject = analysis.IncompatibleWithProject
+	result.Summary.CopyleftTainted = analysis.CopyleftTainted
+	result.Summary.ConfidenceHistogram = analysis.ConfidenceHistogram
+	result.Summary.DepthCounts = analysis.DepthCounts
+
+	// Compare with the baseline report before grouping drops the dependency list
+	if *diffBaseline != "" {
+		baseline, err := loadBaseline(*diffBaseline)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -diff baseline: %v\n", err)
+			return 1
+		}
+		result.Diff = compareScans(baseline, &result, func(license string) string {
+			return licenseAnalyzer.Severity(licenseAnalyzer.Category(license))
+		})
+	}
+
+	// Nest dependencies under the grouping key if requested
+	if *groupBy != "" {
+		reportDeps := make([]report.Dependency, len(dependencies))
+		for i, dep := range dependencies {
+			reportDeps[i] = report.Dependency{
+				Name:       dep.Name,
+				Version:    dep.Version,
+				License:    dep.License,
+				Confidence: dep.Confidence,
+				Source:     dep.Source,
+				Category:   dep.Category,
+				Manager:    scanResult.Dependencies[i].Manager,
+				Project:    scanResult.Dependencies[i].Project,
+			}
+		}
+
+		groups, err := report.GroupBy(reportDeps, *groupBy)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error grouping dependencies: %v\n", err)
+			return 1
+		}
+		result.Groups = groups
+	}
+
+	// Sort dependencies by what to do about them for the actions format
+	if strings.EqualFold(*format, "actions") {
+		reportDeps := make([]report.Dependency, len(dependencies))
+		actions := make([]string, len(dependencies))
+		for i, dep := range dependencies {
+			reportDeps[i] = report.Dependency{
+				Name:       dep.Name,
+				Version:    dep.Version,
+				License:    dep.License,
+				Confidence: dep.Confidence,
+				Source:     dep.Source,
+			}
+			category := licenseAnalyzer.Category(dep.License)
+			actions[i] = report.ActionFor(category, dep.Confidence, licenseAnalyzer.Denies(category), dep.Approved)
+		}
+		result.Actions = report.GroupByAction(reportDeps, actions)
+	}
+
+	// Sort dependencies into risk sections for the console-grouped format
+	if strings.EqualFold(*format, "console-grouped") {
+		reportDeps := make([]report.Dependency, len(dependencies))
+		sections := make([]string, len(dependencies))
+		for i, dep := range dependencies {
+			reportDeps[i] = report.Dependency{
+				Name:       dep.Name,
+				Version:    dep.Version,
+				License:    dep.License,
+				Confidence: dep.Confidence,
+				Source:     dep.Source,
+			}
+			category := licenseAnalyzer.Category(dep.License)
+			sections[i] = report.SectionFor(category, licenseAnalyzer.Severity(category), licenseAnalyzer.Denies(category), dep.Approved)
+		}
+		result.Sections = report.GroupBySection(reportDeps, sections)
+	}
+
+	// Attribute dependencies to their package manager for the SBOM purls
+	if sbomFormats[strings.ToLower(*format)] {
+		result.Components = make([]sbom.Component, len(dependencies))
+		for i, dep := range dependencies {
+			manager := scanResult.Dependencies[i].Manager
+			if manager == "" {
+				manager = scanResult.PackageManager
+			}
+			result.Components[i] = sbom.Component{Name: dep.Name, Version: dep.Version, License: dep.License, Manager: manager}
+		}
+	}
+
+	// Output based on format, optionally keeping only the summary on stdout
+	switch {
+	case *output != "":
+		err = writeReportFile(*output, &result, *format, *title, *logo, !*noSummary)
+	case *detailsFile != "":
+		err = writeDetails(stdout, *detailsFile, &result, *format, *title, *logo, !*noSummary)
+	default:
+		err = writeReport(stdout, &result, *format, *title, *logo, !*noSummary)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error writing report: %v\n", err)
+		return 1
+	}
+
+	// Surface the findings inline in the Actions UI, next to the report
+	if *githubAnnotations {
+		var highRisk []Dependency
+		for _, dep := range dependencies {
+			category := licenseAnalyzer.Category(dep.License)
+			if !dep.Approved && (licenseAnalyzer.Denies(category) || licenseAnalyzer.Severity(category) == "high") {
+				highRisk = append(highRisk, dep)
+			}
+		}
+		writeAnnotations(stderr, analysis.Conflicts, highRisk, *failOn)
+	}
+
+	// Findings fail the run after the report is written
+	return exitCode(stderr, &result, *failOn, *failUnknownAbove, *riskBudget, *exitZero)
+}
+
+// startProfiling starts a CPU profile and returns a function that stops it
+// and writes the heap profile. Empty paths disable the respective profile.
+func startProfiling(cpuPath, memPath string) (func() error, error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			_ = file.Close() // The profiling error is more relevant
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuFile = file
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %w", err)
+			}
+		}
+
+		if memPath != "" {
+			file, err := os.Create(memPath)
+			if err != nil {
+				return fmt.Errorf("failed to create heap profile: %w", err)
+			}
+			runtime.GC() // Up-to-date allocation statistics
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				_ = file.Close() // The profiling error is more relevant
+				return fmt.Errorf("failed to write heap profile: %w", err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("failed to write heap profile: %w", err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// exitCode reports the findings that fail the run and returns the exit code.
+// An empty failOn disables the risk level gate, a negative failUnknownAbove
+// the unknown license gate and a negative riskBudget the risk budget. With
+// exitZero the findings are still reported but the run succeeds.
+func exitCode(w io.Writer, result *ScanResult, failOn string, failUnknownAbove float64, riskBudget int, exitZero bool) int {
+	code := 0
+
+	if failOn != "" && analyzer.RiskAtLeast(result.Summary.RiskLevel, failOn) {
+		fmt.Fprintf(w, "Risk level %s meets the -fail-on %s threshold\n", result.Summary.RiskLevel, failOn)
+		code = 1
+	}
+
+	// Too many unknown licenses point at a systemically broken scan
+	if failUnknownAbove >= 0 && result.Summary.UnknownPercentage > failUnknownAbove {
+		fmt.Fprintf(w, "Unknown licenses: %.1f%% of dependencies exceeds the %g%% limit\n",
+			result.Summary.UnknownPercentage, failUnknownAbove)
+		code = 1
+	}
+
+	// Some risk is acceptable, as long as it stays within the budget
+	if riskBudget >= 0 && result.Summary.RiskPoints > riskBudget {
+		fmt.Fprintf(w, "Risk points: %d exceed the budget of %d\n", result.Summary.RiskPoints, riskBudget)
+		code = 1
+	}
+
+	// Unapproved license changes
+	if len(result.ApprovalViolations) > 0 {
+		for _, violation := range result.ApprovalViolations {
+			fmt.Fprintf(w, "Unapproved license change: %s (%s): %s\n", violation.Package, violation.License, violation.Reason)
+		}
+		fmt.Fprintln(w, "Re-approve with -approve once the changes have been reviewed")
+		code = 1
+	}
+
+	// License texts edited without a version bump
+	if len(result.TextReviewViolations) > 0 {
+		for _, violation := range result.TextReviewViolations {
+			fmt.Fprintf(w, "License text review required: %s (%s): %s\n", violation.Package, violation.License, violation.Reason)
+		}
+		fmt.Fprintln(w, "Record the new texts with -review-hashes once they have been reviewed")
+		code = 1
+	}
+
+	// Projects that failed to scan, after the others were reported
+	if len(result.ProjectErrors) > 0 {
+		code = 1
+	}
+
+	if exitZero {
+		return 0
+	}
+	return code
+}
+
+// sbomFormats are the output formats built from ScanResult.Components
+var sbomFormats = map[string]bool{"cyclonedx": true, "spdx-json": true, "spdx-tag": true}
+
+// runWatch scans once and again after every change to the projects' lock
+// files until ctx is done. Each scan runs in-process without -watch and
+// writes the full report to the report file, so only a fresh summary is
+// printed. Without a report file, a temporary one is used.
+func runWatch(ctx context.Context, args, projectPaths []string, format, reportFile string, stdout, stderr io.Writer) error {
+	var lockFiles []string
+	for _, path := range projectPaths {
+		lockFile, _, err := parser.DetectLockFileDefault(path)
+		if err != nil {
+			return fmt.Errorf("no lock file found in %s", path)
+		}
+		lockFiles = append(lockFiles, lockFile)
+	}
+
+	var detailsFile string
+	if reportFile == "" {
+		file, err := os.CreateTemp("", "license-scanner-report-*."+watchExtension(format))
+		if err != nil {
+			return fmt.Errorf("failed to create the report file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to create the report file: %w", err)
+		}
+		detailsFile = file.Name()
+		fmt.Fprintf(stderr, "Writing the full report to %s\n", detailsFile)
+	}
+	scanArgs := watchArgs(args, detailsFile)
+	// A failing exit code reports findings, which the summary already shows
+	rescan := func() { _ = run(scanArgs, stdout, stderr) }
+
+	rescan()
+	fmt.Fprintf(stderr, "Watching %s for changes (Ctrl+C to stop)\n", strings.Join(lockFiles, ", "))
+
+	err := watch.New(lockFiles).Run(ctx, func() {
+		fmt.Fprintf(stderr, "\nLock file changed at %s, re-scanning\n", time.Now().Format("15:04:05"))
+		rescan()
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// watchArgs returns the arguments of a watch mode scan: the original ones
+// without -watch, with -details-file added when detailsFile is set
+func watchArgs(args []string, detailsFile string) []string {
+	var scanArgs []string
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "watch" {
+			continue
+		}
+		scanArgs = append(scanArgs, arg)
+	}
+	if detailsFile != "" {
+		scanArgs = append([]string{"-details-file", detailsFile}, scanArgs...)
+	}
+	return scanArgs
+}
+
+// watchExtension returns the file extension of a report in the given format
+func watchExtension(format string) string {
+	switch strings.ToLower(format) {
+	case "html":
+		return "html"
+	case "actions", "console-grouped", "metrics-line":
+		return "txt"
+	case "spdx-tag":
+		return "spdx"
+	case "csv":
+		return "csv"
+	}
+	return "json"
+}
 
-	// Output based on format
-	switch strings.ToLower(*format) {
+// writeReport writes the full report in the given format. Without
+// showSummary, the JSON report has no summary key and the HTML report no
+// summary panel.
+func writeReport(w io.Writer, result *ScanResult, format, title, logo string, showSummary bool) error {
+	scannedAt := time.Now()
+	switch strings.ToLower(format) {
 	case "html":
-		result.Timestamp = time.Now().Format("January 2, 2006 at 15:04:05")
+		result.Timestamp = scannedAt.Format("January 2, 2006 at 15:04:05")
 		tmpl, err := templates.GetReportTemplate()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating HTML template: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to create HTML template: %w", err)
 		}
 
 		// Create template data with embedded assets
@@ -119,6 +921,16 @@ func main() {
 		templateData.Summary = result.Summary
 		templateData.Dependencies = make([]templates.Dependency, len(result.Dependencies))
 		templateData.Timestamp = result.Timestamp
+		templateData.ShowSummary = showSummary
+		if title != "" {
+			templateData.Title = title
+		}
+		if logo != "" {
+			templateData.Logo, err = templates.LoadLogo(logo)
+			if err != nil {
+				return fmt.Errorf("failed to load logo: %w", err)
+			}
+		}
 
 		// Convert dependencies
 		for i, dep := range result.Dependencies {
@@ -131,19 +943,255 @@ func main() {
 			}
 		}
 
-		err = tmpl.Execute(os.Stdout, templateData)
+		if err := tmpl.Execute(w, templateData); err != nil {
+			return fmt.Errorf("failed to execute HTML template: %w", err)
+		}
+	case "actions":
+		writeActions(w, result)
+	case "console-grouped":
+		writeConsoleGrouped(w, result)
+	case "metrics-line":
+		writeMetricsLine(w, result, scannedAt)
+	case "csv":
+		return writeCSV(w, result)
+	case "diff":
+		if result.Diff == nil {
+			return fmt.Errorf("the diff format needs a -diff baseline")
+		}
+		writeDiff(w, result.Diff)
+	case "cyclonedx", "spdx-json", "spdx-tag":
+		name := title
+		if name == "" {
+			name = "license-scanner-report"
+		}
+		var output []byte
+		var err error
+		switch strings.ToLower(format) {
+		case "cyclonedx":
+			output, err = sbom.EncodeCycloneDX(result.Components, scannedAt)
+		case "spdx-json":
+			output, err = sbom.EncodeSPDXJSON(result.Components, name, scannedAt)
+		default:
+			output = sbom.EncodeSPDXTagValue(result.Components, name, scannedAt)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error executing HTML template: %v\n", err)
-			os.Exit(1)
+			return err
+		}
+		if _, err := w.Write(output); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
 		}
 	case "json":
 		fallthrough
 	default:
-		output, err := json.MarshalIndent(result, "", "  ")
+		if result.Groups != nil {
+			result.Dependencies = nil
+		}
+		report := *result
+		if !showSummary {
+			report.Summary = ScanResult{}.Summary
+		}
+		output, err := json.MarshalIndent(report, "", "  ")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		if _, err := w.Write(output); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
 		}
-		fmt.Print(string(output))
 	}
+	return nil
+}
+
+// writeDetails writes the full report to path and a concise text summary to
+// w, keeping CI logs readable while preserving the full artifact
+func writeDetails(w io.Writer, path string, result *ScanResult, format, title, logo string, showSummary bool) error {
+	if err := writeReportFile(path, result, format, title, logo, showSummary); err != nil {
+		return err
+	}
+
+	writeSummary(w, result)
+	fmt.Fprintf(w, "Full report written to %s\n", path)
+	return nil
+}
+
+// writeReportFile writes the report to path, creating its parent directories
+func writeReportFile(path string, result *ScanResult, format, title, logo string, showSummary bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory of %s: %w", path, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := writeReport(file, result, format, title, logo, showSummary); err != nil {
+		_ = file.Close() // The write error is more relevant
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// outputFormat infers the format from a .json, .html or .csv output path,
+// keeping fallback for other extensions
+func outputFormat(path, fallback string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".html", ".htm":
+		return "html"
+	case ".csv":
+		return "csv"
+	}
+	return fallback
+}
+
+// writeSummary prints the summary as plain text
+func writeSummary(w io.Writer, result *ScanResult) {
+	summary := result.Summary
+	fmt.Fprintf(w, "Dependencies:        %d\n", summary.TotalDependencies)
+	fmt.Fprintf(w, "Risk level:          %s\n", summary.RiskLevel)
+	fmt.Fprintf(w, "Project license:     %s\n", summary.ProjectLicense)
+	fmt.Fprintf(w, "Predominant license: %s\n", summary.PredominantLicense)
+	fmt.Fprintf(w, "Licenses:            %s\n", strings.Join(summary.UniqueLicenses, ", "))
+	for _, conflict := range summary.Conflicts {
+		fmt.Fprintf(w, "Conflict: %s\n", conflict)
+	}
+	for _, denied := range summary.Denied {
+		fmt.Fprintf(w, "Denied: %s\n", denied)
+	}
+	for _, incompatible := range summary.IncompatibleWithProject {
+		fmt.Fprintf(w, "Incompatible with project license: %s\n", incompatible)
+	}
+	for _, warning := range summary.Warnings {
+		fmt.Fprintln(w, warning)
+	}
+	for _, recommendation := range summary.Recommendations {
+		fmt.Fprintln(w, recommendation)
+	}
+	if result.Diff != nil {
+		fmt.Fprintf(w, "Since baseline:      %d added, %d removed, %d changed\n",
+			len(result.Diff.Added), len(result.Diff.Removed), len(result.Diff.Changed))
+	}
+	if result.Footprint != nil {
+		var licenses []string
+		for license := range result.Footprint.Licenses {
+			licenses = append(licenses, license)
+		}
+		sort.Strings(licenses)
+		fmt.Fprintf(w, "Footprint of %s:     %s (%s)\n", result.Footprint.Package, strings.Join(licenses, ", "), result.Footprint.Category)
+	}
+}
+
+// writeActions lists dependencies under what to do about them, most urgent first
+func writeActions(w io.Writer, result *ScanResult) {
+	for i, group := range result.Actions {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", group.Key, group.Count)
+		for _, dep := range group.Dependencies {
+			fmt.Fprintf(w, "  %s@%s  %s  (%s, confidence %.2f)\n", dep.Name, dep.Version, dep.License, dep.Source, dep.Confidence)
+		}
+	}
+}
+
+// expandedSections are listed package by package in the console-grouped
+// format; the others are collapsed to per-license counts
+var expandedSections = map[string]bool{report.SectionHighRisk: true, report.SectionUnknown: true}
+
+// writeConsoleGrouped prints the risk sections so that the findings dominate
+// the screen: high risk and unknown packages and the conflicts are expanded,
+// the rest is collapsed to counts
+func writeConsoleGrouped(w io.Writer, result *ScanResult) {
+	for _, section := range result.Sections {
+		if !expandedSections[section.Key] {
+			fmt.Fprintf(w, "▸ %s (%d)", section.Key, section.Count)
+			if tally := licenseTally(section.Dependencies); tally != "" {
+				fmt.Fprintf(w, ": %s", tally)
+			}
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "▾ %s (%d)\n", section.Key, section.Count)
+		for _, dep := range section.Dependencies {
+			fmt.Fprintf(w, "    %s@%s  %s  (%s, confidence %.2f)\n", dep.Name, dep.Version, dep.License, dep.Source, dep.Confidence)
+		}
+	}
+
+	fmt.Fprintf(w, "▾ Conflicts (%d)\n", len(result.Summary.Conflicts))
+	for _, conflict := range result.Summary.Conflicts {
+		fmt.Fprintf(w, "    %s\n", conflict)
+	}
+}
+
+// licenseTally summarizes dependencies as "MIT 120, ISC 14", most common
+// license first
+func licenseTally(dependencies []report.Dependency) string {
+	counts := make(map[string]int)
+	var licenses []string
+	for _, dep := range dependencies {
+		if counts[dep.License] == 0 {
+			licenses = append(licenses, dep.License)
+		}
+		counts[dep.License]++
+	}
+	sort.Slice(licenses, func(i, j int) bool {
+		if counts[licenses[i]] != counts[licenses[j]] {
+			return counts[licenses[i]] > counts[licenses[j]]
+		}
+		return licenses[i] < licenses[j]
+	})
+
+	parts := make([]string, len(licenses))
+	for i, license := range licenses {
+		parts[i] = fmt.Sprintf("%s %d", license, counts[license])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeMetricsLine prints the headline numbers as one line of key=value
+// pairs, for appending to a log or ingesting into a time series database
+func writeMetricsLine(w io.Writer, result *ScanResult, scannedAt time.Time) {
+	unknown := 0
+	for _, dep := range result.Dependencies {
+		if dep.License == constants.UnknownLicense {
+			unknown++
+		}
+	}
+	fmt.Fprintf(w, "timestamp=%s total=%d unknown=%d high_risk_count=%d risk_score=%.1f\n",
+		scannedAt.UTC().Format(time.RFC3339), result.Summary.TotalDependencies, unknown,
+		result.Summary.HighRiskCount, result.Summary.RiskScore)
+}
+
+// writeCSV prints the dependencies as a flat table for spreadsheets, with
+// the category of each license, after -license-aliases and -license-db, as
+// its risk category
+func writeCSV(w io.Writer, result *ScanResult) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"name", "version", "license", "confidence", "source", "riskCategory"}}
+	for _, dep := range result.Dependencies {
+		rows = append(rows, []string{
+			dep.Name,
+			dep.Version,
+			dep.License,
+			strconv.FormatFloat(dep.Confidence, 'f', -1, 64),
+			dep.Source,
+			dep.Category.String(),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// writeSchema prints the JSON Schema of the JSON report
+func writeSchema(w io.Writer) error {
+	output, err := json.MarshalIndent(schema.Generate(ScanResult{}), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
 }
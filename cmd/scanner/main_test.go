@@ -0,0 +1,790 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+	"github.com/StefanoA1/license-scanner/internal/approval"
+	"github.com/StefanoA1/license-scanner/internal/report"
+	"github.com/StefanoA1/license-scanner/internal/sbom"
+)
+
+func TestWriteDetails(t *testing.T) {
+	var result ScanResult
+	result.Summary.TotalDependencies = 2
+	result.Summary.RiskLevel = "high"
+	result.Summary.UniqueLicenses = []string{"MIT", "GPL-3.0"}
+	result.Summary.Recommendations = []string{"📋 Consider legal review if distributing proprietary software"}
+	result.Dependencies = []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0, Source: "package.json"},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0, Source: "package.json"},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	var stdout bytes.Buffer
+	if err := writeDetails(&stdout, path, &result, "json", "", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := stdout.String()
+	if !strings.Contains(summary, "Risk level:          high") || !strings.Contains(summary, "Full report written to "+path) {
+		t.Errorf("expected the text summary on stdout, got %q", summary)
+	}
+	if strings.Contains(summary, "gpl-package") || strings.Contains(summary, "{") {
+		t.Errorf("expected no dependency details on stdout, got %q", summary)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read details file: %v", err)
+	}
+
+	var details ScanResult
+	if err := json.Unmarshal(data, &details); err != nil {
+		t.Fatalf("details file is not valid JSON: %v", err)
+	}
+	if len(details.Dependencies) != 2 || details.Summary.RiskLevel != "high" {
+		t.Errorf("expected the full result in the details file, got %+v", details)
+	}
+}
+
+func TestWriteReport_NoSummary(t *testing.T) {
+	var result ScanResult
+	result.Summary.TotalDependencies = 1
+	result.Summary.RiskLevel = "low"
+	result.Dependencies = []Dependency{{Name: "react", Version: "18.2.0", License: "MIT"}}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, &result, "json", "", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report map[string]json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if _, ok := report["summary"]; ok {
+		t.Errorf("expected no summary key, got %s", out.String())
+	}
+	if _, ok := report["dependencies"]; !ok {
+		t.Errorf("expected the dependencies to remain, got %s", out.String())
+	}
+	if result.Summary.RiskLevel != "low" {
+		t.Error("expected the result summary to be left intact")
+	}
+}
+
+func TestRun_NoRecommendations(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-no-recommendations", filepath.Join("testdata", "fixtures", "yarn")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	var report struct {
+		Summary map[string]json.RawMessage `json:"summary"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if _, ok := report.Summary["recommendations"]; ok {
+		t.Errorf("expected no recommendations key, got %s", report.Summary["recommendations"])
+	}
+	if _, ok := report.Summary["riskLevel"]; !ok {
+		t.Errorf("expected the rest of the summary to remain, got %s", stdout.String())
+	}
+}
+
+func TestRun_NoRecommendations_KeepsWarnings(t *testing.T) {
+	// Without a lock file the scanner warns that versions are unresolved
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies": {"left-pad": "^1.3.0"}}`), 0o644); err != nil {
+		t.Fatalf("failed to write package.json: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-no-recommendations", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var report ScanResult
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if len(report.Summary.Warnings) != 1 || !strings.Contains(report.Summary.Warnings[0], "No lock file found") {
+		t.Errorf("expected the unresolved versions warning, got %v", report.Summary.Warnings)
+	}
+	if len(report.Summary.Recommendations) != 0 {
+		t.Errorf("expected no recommendations, got %v", report.Summary.Recommendations)
+	}
+}
+
+func TestRun_UniqueLicensesSorted(t *testing.T) {
+	// The licenses come from a map, so several runs would catch a random order
+	for range 5 {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{filepath.Join("testdata", "fixtures", "npm")}, &stdout, &stderr); code != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+		}
+
+		var report ScanResult
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			t.Fatalf("report is not valid JSON: %v", err)
+		}
+		expected := []string{"Apache-2.0", "GPL-3.0", "ISC", "MIT"}
+		if !reflect.DeepEqual(report.Summary.UniqueLicenses, expected) {
+			t.Fatalf("expected unique licenses %v, got %v", expected, report.Summary.UniqueLicenses)
+		}
+	}
+}
+
+func TestRun_Output(t *testing.T) {
+	fixture := filepath.Join("testdata", "fixtures", "yarn")
+	dir := t.TempDir()
+
+	// The format follows the extension and missing directories are created
+	path := filepath.Join(dir, "reports", "nested", "licenses.html")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-output", path, fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected a clean stdout, got %s", stdout.String())
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(content), "<html") {
+		t.Errorf("expected an HTML report, got %.100s", content)
+	}
+
+	// An explicit -format wins over the extension
+	path = filepath.Join(dir, "licenses.html")
+	if code := run([]string{"-output", path, "-format", "json", fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	content, _ = os.ReadFile(path)
+	if !json.Valid(content) {
+		t.Errorf("expected a JSON report, got %.100s", content)
+	}
+
+	// A file in the way of the directory fails the run
+	stderr.Reset()
+	path = filepath.Join(dir, "licenses.html", "report.json")
+	if code := run([]string{"-output", path, fixture}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Error writing report") {
+		t.Errorf("expected a clear error, got %q", stderr.String())
+	}
+}
+
+func TestWriteReport_Actions(t *testing.T) {
+	var result ScanResult
+	result.Actions = []report.Group{
+		{Key: report.ActionRemove, Count: 1, Dependencies: []report.Dependency{
+			{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0, Source: "package.json"},
+		}},
+		{Key: report.ActionReview, Dependencies: []report.Dependency{}},
+		{Key: report.ActionOK, Count: 1, Dependencies: []report.Dependency{
+			{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0, Source: "package.json"},
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, &result, "actions", "", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Remove or replace (1)\n  gpl-package@1.0.0  GPL-3.0  (package.json, confidence 1.00)\n\n" +
+		"Review (0)\n\n" +
+		"OK (1)\n  react@18.2.0  MIT  (package.json, confidence 1.00)\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestWriteReport_ConsoleGrouped(t *testing.T) {
+	var result ScanResult
+	result.Summary.Conflicts = []string{"GPL-2.0 and Apache-2.0 licenses are incompatible"}
+	result.Sections = []report.Group{
+		{Key: report.SectionHighRisk, Count: 1, Dependencies: []report.Dependency{
+			{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0, Source: "package.json"},
+		}},
+		{Key: report.SectionUnknown, Dependencies: []report.Dependency{}},
+		{Key: report.SectionMediumRisk, Dependencies: []report.Dependency{}},
+		{Key: report.SectionLowRisk, Count: 3, Dependencies: []report.Dependency{
+			{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0, Source: "package.json"},
+			{Name: "lodash", Version: "4.17.21", License: "MIT", Confidence: 1.0, Source: "package.json"},
+			{Name: "semver", Version: "7.5.4", License: "ISC", Confidence: 1.0, Source: "package.json"},
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, &result, "console-grouped", "", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "▾ High risk (1)\n    gpl-package@1.0.0  GPL-3.0  (package.json, confidence 1.00)\n" +
+		"▾ Unknown license (0)\n" +
+		"▸ Medium risk (0)\n" +
+		"▸ Low risk (3): MIT 2, ISC 1\n" +
+		"▾ Conflicts (1)\n    GPL-2.0 and Apache-2.0 licenses are incompatible\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+	if strings.Contains(out.String(), "react") {
+		t.Error("expected permissive packages to be summarized by count only")
+	}
+}
+
+func TestWriteReport_CycloneDX(t *testing.T) {
+	var result ScanResult
+	result.Components = []sbom.Component{
+		{Name: "react", Version: "18.2.0", License: "MIT", Manager: "npm"},
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, &result, "cyclonedx", "", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document struct {
+		BOMFormat string `json:"bomFormat"`
+		Metadata  struct {
+			Timestamp string `json:"timestamp"`
+		} `json:"metadata"`
+		Components []struct {
+			PURL string `json:"purl"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if document.BOMFormat != "CycloneDX" || document.Metadata.Timestamp == "" {
+		t.Errorf("expected a timestamped CycloneDX document, got %s", out.String())
+	}
+	if len(document.Components) != 1 || document.Components[0].PURL != "pkg:npm/react@18.2.0" {
+		t.Errorf("expected the react component, got %+v", document.Components)
+	}
+}
+
+func TestWriteReport_SPDXTag(t *testing.T) {
+	var result ScanResult
+	result.Components = []sbom.Component{
+		{Name: "react", Version: "18.2.0", License: "MIT", Manager: "npm"},
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, &result, "spdx-tag", "my-app", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"SPDXVersion: SPDX-2.3\n", "DocumentName: my-app\n", "PackageName: react\n", "PackageLicenseConcluded: MIT\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the SPDX document, got %s", expected, out.String())
+		}
+	}
+}
+
+func TestWriteMetricsLine(t *testing.T) {
+	var result ScanResult
+	result.Summary.TotalDependencies = 4
+	result.Summary.HighRiskCount = 1
+	result.Summary.RiskScore = 37.5
+	result.Dependencies = []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0"},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown"},
+		{Name: "lgpl-package", Version: "2.0.0", License: "LGPL-3.0"},
+	}
+
+	var out bytes.Buffer
+	writeMetricsLine(&out, &result, time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60)))
+
+	expected := "timestamp=2024-05-01T12:30:00Z total=4 unknown=1 high_risk_count=1 risk_score=37.5\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var result ScanResult
+	result.Dependencies = []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1, Source: "package.json", Category: analyzer.Permissive},
+		{Name: "odd,name", Version: "1.0.0", License: "GPL-3.0", Confidence: 0.8, Source: "LICENSE file", Category: analyzer.StrongCopyleft},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Confidence: 0, Source: "none", Category: analyzer.Unknown},
+	}
+
+	var out bytes.Buffer
+	if err := writeCSV(&out, &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "name,version,license,confidence,source,riskCategory\n" +
+		"react,18.2.0,MIT,1,package.json,permissive\n" +
+		"\"odd,name\",1.0.0,GPL-3.0,0.8,LICENSE file,strong-copyleft\n" +
+		"mystery,0.1.0,Unknown,0,none,unknown\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRun_CSV(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-format", "csv", filepath.Join("testdata", "fixtures", "yarn")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) < 2 || records[0][5] != "riskCategory" {
+		t.Errorf("expected a header and dependency rows, got %v", records)
+	}
+	if outputFormat("licenses.csv", "json") != "csv" {
+		t.Error("expected a .csv output path to select the csv format")
+	}
+
+	// The risk category follows the configured aliases
+	aliases := filepath.Join(t.TempDir(), "aliases.json")
+	if err := os.WriteFile(aliases, []byte(`{"LGPL-3.0": "MIT"}`), 0o644); err != nil {
+		t.Fatalf("failed to write aliases: %v", err)
+	}
+	stdout.Reset()
+	if code := run([]string{"-format", "csv", "-license-aliases", aliases, filepath.Join("testdata", "fixtures", "yarn")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	records, err = csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	for _, record := range records[1:] {
+		if record[2] == "LGPL-3.0" && record[5] != "permissive" {
+			t.Errorf("expected the aliased LGPL-3.0 to be permissive, got %v", record)
+		}
+	}
+}
+
+func TestRun_AllowFileRiskBudget(t *testing.T) {
+	// GPL-2.0 and Apache-2.0 conflict unless the GPL package is approved
+	dir := t.TempDir()
+	files := map[string]string{
+		"package-lock.json": `{"lockfileVersion": 3, "packages": {
+  "": {"dependencies": {"apache-lib": "^1.0.0", "gpl2-lib": "^1.0.0"}},
+  "node_modules/apache-lib": {"version": "1.0.0", "license": "Apache-2.0"},
+  "node_modules/gpl2-lib": {"version": "1.0.0", "license": "GPL-2.0"}
+}}`,
+		"package.json":                         `{"dependencies": {"apache-lib": "^1.0.0", "gpl2-lib": "^1.0.0"}}`,
+		"node_modules/apache-lib/package.json": `{"name": "apache-lib", "version": "1.0.0", "license": "Apache-2.0"}`,
+		"node_modules/gpl2-lib/package.json":   `{"name": "gpl2-lib", "version": "1.0.0", "license": "GPL-2.0"}`,
+		"allow.txt":                            "gpl2-lib@1.0.0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-risk-budget", "0", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected the conflict to exceed the budget, got exit code %d", code)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"-risk-budget", "0", "-allow-file", filepath.Join(dir, "allow.txt"), dir}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected the approved package to stay within the budget, got exit code %d: %s", code, stderr.String())
+	}
+}
+
+func TestRun_ProjectLicense(t *testing.T) {
+	fixture := filepath.Join("testdata", "fixtures", "npm")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-project-license", "Apache-2.0", fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var report ScanResult
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(report.Summary.IncompatibleWithProject, []string{"gpl-lib@1.0.0 (GPL-3.0)"}) {
+		t.Errorf("expected gpl-lib to be incompatible with Apache-2.0, got %v", report.Summary.IncompatibleWithProject)
+	}
+	// The license checked against is reported, not package.json's MIT
+	if report.Summary.ProjectLicense != "Apache-2.0" {
+		t.Errorf("expected project license Apache-2.0, got %s", report.Summary.ProjectLicense)
+	}
+
+	// A GPL-3.0 project can take GPL-3.0 dependencies
+	stdout.Reset()
+	if code := run([]string{"-project-license", "GPL-3.0", fixture}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "incompatibleWithProject") {
+		t.Errorf("expected no incompatible dependencies, got %s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"-project-license", "bogus", fixture}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown license, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown project license "bogus"`) {
+		t.Errorf("expected an unknown license error, got %q", stderr.String())
+	}
+}
+
+func TestWatchArgs(t *testing.T) {
+	args := watchArgs([]string{"-watch", "-format", "html", "--watch=true", "-verbose", "app"}, "report.html")
+	expected := []string{"-details-file", "report.html", "-format", "html", "-verbose", "app"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+
+	// A configured details file or -output is kept as is
+	args = watchArgs([]string{"--watch", "-details-file", "report.json", "."}, "")
+	expected = []string{"-details-file", "report.json", "."}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while a watch writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunWatch(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"package.json", "yarn.lock"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "fixtures", "yarn", name))
+		if err != nil {
+			t.Fatalf("failed to read fixture: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout, stderr syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- runWatch(ctx, []string{"-watch", "-format", "json", dir}, []string{dir}, "json", "", &stdout, &stderr)
+	}()
+
+	// waitFor polls the summaries printed to stdout
+	waitFor := func(summaries int) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for strings.Count(stdout.String(), "Dependencies:") < summaries {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d summaries, got stdout %q and stderr %q", summaries, stdout.String(), stderr.String())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitFor(1)
+
+	// Each watcher gets its own report file
+	reportFile := strings.TrimSpace(strings.TrimPrefix(strings.SplitN(stderr.String(), "\n", 2)[0], "Writing the full report to "))
+	if filepath.Dir(reportFile) != filepath.Clean(os.TempDir()) || !strings.HasSuffix(reportFile, ".json") || filepath.Base(reportFile) == "license-scanner-report.json" {
+		t.Errorf("expected a unique temporary report file, got %q", reportFile)
+	}
+	defer os.Remove(reportFile)
+
+	lockFile := filepath.Join(dir, "yarn.lock")
+	lock, err := os.ReadFile(lockFile)
+	if err != nil {
+		t.Fatalf("failed to read lock file: %v", err)
+	}
+	if err := os.WriteFile(lockFile, append(lock, '\n'), 0o644); err != nil {
+		t.Fatalf("failed to update lock file: %v", err)
+	}
+	waitFor(2)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if report, err := os.ReadFile(reportFile); err != nil || !json.Valid(report) {
+		t.Errorf("expected the JSON report in %s, got %v", reportFile, err)
+	}
+}
+
+func TestWriteSchema(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeSchema(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	if document["$schema"] != "https://json-schema.org/draft/2020-12/schema" || document["type"] != "object" {
+		t.Errorf("expected a JSON Schema object document, got %v", document["$schema"])
+	}
+
+	properties := document["properties"].(map[string]interface{})
+	summary, ok := properties["summary"].(map[string]interface{})
+	if !ok || summary["properties"].(map[string]interface{})["riskLevel"] == nil {
+		t.Errorf("expected a summary definition with riskLevel, got %v", properties["summary"])
+	}
+	if recommendations, ok := summary["properties"].(map[string]interface{})["structuredRecommendations"].(map[string]interface{}); !ok ||
+		recommendations["items"].(map[string]interface{})["$ref"] != "#/$defs/analyzer.Recommendation" {
+		t.Errorf("expected structuredRecommendations to reference the Recommendation definition, got %v", summary["properties"])
+	}
+
+	dependencies, ok := properties["dependencies"].(map[string]interface{})
+	if !ok || dependencies["items"].(map[string]interface{})["$ref"] != "#/$defs/Dependency" {
+		t.Errorf("expected dependencies to reference the Dependency definition, got %v", properties["dependencies"])
+	}
+
+	// Every reference must resolve
+	defs := document["$defs"].(map[string]interface{})
+	for _, ref := range strings.Split(out.String(), `"$ref": "#/$defs/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := defs[name]; !ok {
+			t.Errorf("unresolved reference to %s", name)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	var result ScanResult
+	result.Summary.RiskLevel = "high"
+	result.ApprovalViolations = []approval.Violation{
+		{Package: "gpl-package@1.0.0", License: "GPL-3.0", Reason: "license not previously approved"},
+	}
+
+	var stderr bytes.Buffer
+	if code := exitCode(&stderr, &result, "", -1, -1, false); code != 1 {
+		t.Errorf("expected exit code 1 for unapproved changes, got %d", code)
+	}
+
+	stderr.Reset()
+	if code := exitCode(&stderr, &result, "", -1, -1, true); code != 0 {
+		t.Errorf("expected -exit-zero to force exit code 0, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "gpl-package@1.0.0") {
+		t.Errorf("expected findings to be reported with -exit-zero, got %q", stderr.String())
+	}
+}
+
+func TestExitCode_TextReviewViolations(t *testing.T) {
+	var result ScanResult
+	result.TextReviewViolations = []approval.Violation{
+		{Package: "lodash@4.17.21", License: "MIT", Reason: "license text changed since review"},
+	}
+
+	var stderr bytes.Buffer
+	if code := exitCode(&stderr, &result, "", -1, -1, false); code != 1 {
+		t.Errorf("expected exit code 1 for a changed license text, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "License text review required: lodash@4.17.21") {
+		t.Errorf("expected the changed license text to be reported, got %q", stderr.String())
+	}
+}
+
+func TestExitCode_FailUnknownAbove(t *testing.T) {
+	tests := []struct {
+		unknown  float64
+		expected int
+	}{
+		{unknown: 10, expected: 0},
+		{unknown: 40, expected: 1},
+	}
+
+	for _, tt := range tests {
+		var result ScanResult
+		result.Summary.UnknownPercentage = tt.unknown
+
+		var stderr bytes.Buffer
+		if code := exitCode(&stderr, &result, "", 25, -1, false); code != tt.expected {
+			t.Errorf("%v%% unknown against a 25%% gate: expected exit code %d, got %d", tt.unknown, tt.expected, code)
+		}
+	}
+
+	// The gate is disabled by default
+	var result ScanResult
+	result.Summary.UnknownPercentage = 100
+	if code := exitCode(&bytes.Buffer{}, &result, "", -1, -1, false); code != 0 {
+		t.Errorf("expected a disabled gate to pass, got exit code %d", code)
+	}
+}
+
+func TestExitCode_FailOn(t *testing.T) {
+	tests := []struct {
+		riskLevel string
+		failOn    string
+		expected  int
+	}{
+		{riskLevel: "low", failOn: "", expected: 0},
+		{riskLevel: "high", failOn: "", expected: 0},
+		{riskLevel: "low", failOn: "medium", expected: 0},
+		{riskLevel: "medium", failOn: "medium", expected: 1},
+		{riskLevel: "high", failOn: "medium", expected: 1},
+		{riskLevel: "medium", failOn: "high", expected: 0},
+		{riskLevel: "low", failOn: "low", expected: 1},
+	}
+
+	for _, tt := range tests {
+		var result ScanResult
+		result.Summary.RiskLevel = tt.riskLevel
+
+		var stderr bytes.Buffer
+		if code := exitCode(&stderr, &result, tt.failOn, -1, -1, false); code != tt.expected {
+			t.Errorf("%s risk with -fail-on %q: expected exit code %d, got %d", tt.riskLevel, tt.failOn, tt.expected, code)
+		}
+	}
+
+	// The report is written before the gate, for both JSON and HTML
+	var result ScanResult
+	result.Summary.RiskLevel = "high"
+	result.Dependencies = []Dependency{{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0"}}
+	for _, format := range []string{"json", "html"} {
+		var stdout, stderr bytes.Buffer
+		if err := writeReport(&stdout, &result, format, "", "", true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if code := exitCode(&stderr, &result, "high", -1, -1, false); code != 1 || !strings.Contains(stdout.String(), "gpl-package") {
+			t.Errorf("%s: expected the report and exit code 1, got code %d", format, code)
+		}
+	}
+}
+
+func TestExitCode_RiskBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		dependencies []analyzer.Dependency
+		expected     int
+	}{
+		{
+			// 2 unknown + 3 weak copyleft = 5 points
+			name: "under budget",
+			dependencies: []analyzer.Dependency{
+				{Name: "a", Version: "1.0.0", License: "Unknown"},
+				{Name: "b", Version: "1.0.0", License: "Unknown"},
+				{Name: "c", Version: "1.0.0", License: "MPL-2.0", Confidence: 1.0},
+				{Name: "d", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+			},
+			expected: 0,
+		},
+		{
+			// 10 strong copyleft + 3 weak copyleft = 13 points
+			name: "over budget",
+			dependencies: []analyzer.Dependency{
+				{Name: "a", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+				{Name: "c", Version: "1.0.0", License: "MPL-2.0", Confidence: 1.0},
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result ScanResult
+			result.Summary.RiskPoints = analyzer.New().Analyze(tt.dependencies).RiskPoints()
+
+			var stderr bytes.Buffer
+			if code := exitCode(&stderr, &result, "", -1, 10, false); code != tt.expected {
+				t.Errorf("expected exit code %d for %d risk points against a budget of 10, got %d", tt.expected, result.Summary.RiskPoints, code)
+			}
+		})
+	}
+}
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	stop, err := startProfiling(cpuPath, memPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Give the CPU profile something to sample
+	var result ScanResult
+	for i := 0; i < 1000; i++ {
+		result.Dependencies = append(result.Dependencies, Dependency{Name: "package", Version: "1.0.0", License: "MIT"})
+	}
+	if err := writeReport(&bytes.Buffer{}, &result, "json", "", "", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected profile %s: %v", path, err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected a non-empty profile in %s", path)
+		}
+	}
+}
+
+func TestRun_ProfilingStopsOnError(t *testing.T) {
+	dir := t.TempDir()
+	cpuPath := filepath.Join(dir, "cpu.pprof")
+	memPath := filepath.Join(dir, "mem.pprof")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-cpuprofile", cpuPath, "-memprofile", memPath, "-fail-on", "critical", filepath.Join("testdata", "fixtures", "npm")}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1 for an invalid -fail-on, got %d", code)
+	}
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected profile %s: %v", path, err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected a non-empty profile in %s", path)
+		}
+	}
+
+	// The CPU profile was stopped, so the next run can start one
+	stop, err := startProfiling(filepath.Join(dir, "next.pprof"), "")
+	if err != nil {
+		t.Fatalf("expected the CPU profile to be stopped: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
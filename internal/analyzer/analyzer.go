@@ -2,7 +2,13 @@ package analyzer
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // LicenseCategory represents the type of license
@@ -16,6 +22,22 @@ const (
 	Unknown
 )
 
+// String returns the human-readable name of the category
+func (c LicenseCategory) String() string {
+	switch c {
+	case Permissive:
+		return "permissive"
+	case WeakCopyleft:
+		return "weak-copyleft"
+	case StrongCopyleft:
+		return "strong-copyleft"
+	case Proprietary:
+		return "proprietary"
+	default:
+		return "unknown"
+	}
+}
+
 // LicenseInfo contains metadata about a license type
 type LicenseInfo struct {
 	Name      string
@@ -30,7 +52,6 @@ var KnownLicenses = map[string]LicenseInfo{
 	"BSD-2-Clause": {Name: "BSD-2-Clause", Category: Permissive, RiskLevel: "low"},
 	"BSD-3-Clause": {Name: "BSD-3-Clause", Category: Permissive, RiskLevel: "low"},
 	"Apache-2.0":   {Name: "Apache-2.0", Category: Permissive, RiskLevel: "low"},
-	"Apache 2.0":   {Name: "Apache-2.0", Category: Permissive, RiskLevel: "low"},
 	"MPL-2.0":      {Name: "MPL-2.0", Category: WeakCopyleft, RiskLevel: "medium"},
 	"LGPL-2.1":     {Name: "LGPL-2.1", Category: WeakCopyleft, RiskLevel: "medium"},
 	"LGPL-3.0":     {Name: "LGPL-3.0", Category: WeakCopyleft, RiskLevel: "medium"},
@@ -38,6 +59,127 @@ var KnownLicenses = map[string]LicenseInfo{
 	"GPL-3.0":      {Name: "GPL-3.0", Category: StrongCopyleft, RiskLevel: "high"},
 	"AGPL-3.0":     {Name: "AGPL-3.0", Category: StrongCopyleft, RiskLevel: "high"},
 	"UNLICENSED":   {Name: "UNLICENSED", Category: Proprietary, RiskLevel: "high"},
+	"0BSD":         {Name: "0BSD", Category: Permissive, RiskLevel: "low"},
+	"Unlicense":    {Name: "Unlicense", Category: Permissive, RiskLevel: "low"},
+	"WTFPL":        {Name: "WTFPL", Category: Permissive, RiskLevel: "low"},
+	"Zlib":         {Name: "Zlib", Category: Permissive, RiskLevel: "low"},
+	"Python-2.0":   {Name: "Python-2.0", Category: Permissive, RiskLevel: "low"},
+	"PostgreSQL":   {Name: "PostgreSQL", Category: Permissive, RiskLevel: "low"},
+	"BSL-1.0":      {Name: "BSL-1.0", Category: Permissive, RiskLevel: "low"},
+	"EPL-2.0":      {Name: "EPL-2.0", Category: WeakCopyleft, RiskLevel: "medium"},
+	// Creative Commons licenses are meant for content: CC0 is a public
+	// domain dedication, CC-BY only asks for attribution but has no patent
+	// grant, and share-alike binds derivatives to the same license
+	"CC0-1.0":      {Name: "CC0-1.0", Category: Permissive, RiskLevel: "low"},
+	"CC-BY-3.0":    {Name: "CC-BY-3.0", Category: Permissive, RiskLevel: "medium"},
+	"CC-BY-4.0":    {Name: "CC-BY-4.0", Category: Permissive, RiskLevel: "medium"},
+	"CC-BY-SA-4.0": {Name: "CC-BY-SA-4.0", Category: WeakCopyleft, RiskLevel: "medium"},
+}
+
+// licenseAliases maps common lowercase spellings of licenses to their SPDX
+// identifiers
+var licenseAliases = map[string]string{
+	"0bsd":                       "0BSD",
+	"bsd-zero-clause":            "0BSD",
+	"unlicense":                  "Unlicense",
+	"the unlicense":              "Unlicense",
+	"public domain (unlicense)":  "Unlicense",
+	"cc0":                        "CC0-1.0",
+	"cc0 1.0":                    "CC0-1.0",
+	"cc-0":                       "CC0-1.0",
+	"cc-by-3":                    "CC-BY-3.0",
+	"cc by 3.0":                  "CC-BY-3.0",
+	"cc-by-4":                    "CC-BY-4.0",
+	"cc by 4.0":                  "CC-BY-4.0",
+	"cc-by-sa-4":                 "CC-BY-SA-4.0",
+	"cc by-sa 4.0":               "CC-BY-SA-4.0",
+	"wtfpl-2.0":                  "WTFPL",
+	"zlib/libpng":                "Zlib",
+	"zlib license":               "Zlib",
+	"python":                     "Python-2.0",
+	"psf":                        "Python-2.0",
+	"psf-2.0":                    "Python-2.0",
+	"postgresql license":         "PostgreSQL",
+	"boost":                      "BSL-1.0",
+	"boost software license":     "BSL-1.0",
+	"epl 2.0":                    "EPL-2.0",
+	"epl-2":                      "EPL-2.0",
+	"eclipse public license 2.0": "EPL-2.0",
+}
+
+// Obligations that licenses place on the project distributing them
+const (
+	ObligationAttribution     = "Include copyright notice and license text"
+	ObligationStateChanges    = "State significant changes made to the code"
+	ObligationNotice          = "Include the NOTICE file"
+	ObligationNoEndorsement   = "Do not use contributors' names for endorsement"
+	ObligationDiscloseFiles   = "Provide source of modified files"
+	ObligationRelinking       = "Allow relinking against modified library versions"
+	ObligationProvideSource   = "Provide source on request"
+	ObligationSameLicense     = "License derivative works under the same license"
+	ObligationNetworkDisclose = "Provide source to users interacting over a network"
+)
+
+// LicenseObligations maps license identifiers to the obligations they trigger
+var LicenseObligations = map[string][]string{
+	"MIT":          {ObligationAttribution},
+	"ISC":          {ObligationAttribution},
+	"BSD-2-Clause": {ObligationAttribution},
+	"BSD-3-Clause": {ObligationAttribution, ObligationNoEndorsement},
+	"Apache-2.0":   {ObligationAttribution, ObligationStateChanges, ObligationNotice},
+	"MPL-2.0":      {ObligationAttribution, ObligationDiscloseFiles},
+	"LGPL-2.1":     {ObligationAttribution, ObligationProvideSource, ObligationRelinking},
+	"LGPL-3.0":     {ObligationAttribution, ObligationProvideSource, ObligationRelinking},
+	"GPL-2.0":      {ObligationAttribution, ObligationProvideSource, ObligationSameLicense},
+	"GPL-3.0":      {ObligationAttribution, ObligationStateChanges, ObligationProvideSource, ObligationSameLicense},
+	"AGPL-3.0":     {ObligationAttribution, ObligationStateChanges, ObligationProvideSource, ObligationSameLicense, ObligationNetworkDisclose},
+	"Zlib":         {ObligationStateChanges},
+	"Python-2.0":   {ObligationAttribution, ObligationStateChanges},
+	"PostgreSQL":   {ObligationAttribution},
+	"BSL-1.0":      {ObligationAttribution},
+	"EPL-2.0":      {ObligationAttribution, ObligationDiscloseFiles},
+	"CC-BY-3.0":    {ObligationAttribution, ObligationStateChanges},
+	"CC-BY-4.0":    {ObligationAttribution, ObligationStateChanges},
+	"CC-BY-SA-4.0": {ObligationAttribution, ObligationStateChanges, ObligationSameLicense},
+}
+
+// Obligation is a deduplicated obligation with the number of packages triggering it
+type Obligation struct {
+	Obligation string `json:"obligation"`
+	Count      int    `json:"count"`
+}
+
+// Recommendation categories, naming what a recommendation is about
+const (
+	RecommendationConflict        = "conflict"
+	RecommendationNetworkCopyleft = "network-copyleft"
+	RecommendationStrongCopyleft  = "strong-copyleft"
+	RecommendationWeakCopyleft    = "weak-copyleft"
+	RecommendationUnknown         = "unknown"
+	RecommendationLowConfidence   = "low-confidence"
+	RecommendationBundled         = "bundled"
+	RecommendationDenied          = "denied"
+	RecommendationProjectLicense  = "project-license"
+	RecommendationAllClear        = "all-clear"
+)
+
+// Recommendation is a recommendation with the packages (name@version) it
+// concerns, for UIs that link to them
+type Recommendation struct {
+	Category         string   `json:"category"`
+	Severity         string   `json:"severity"`
+	AffectedPackages []string `json:"affectedPackages"`
+	Message          string   `json:"message"`
+}
+
+// Conflict is a license conflict with the dependencies involved, as direct
+// dependencies are easier to replace than transitive ones
+type Conflict struct {
+	Message string `json:"message"`
+	// DirectPackages are the direct dependencies holding one of the licenses
+	DirectPackages []string `json:"directPackages"`
+	// Transitive is set when deeper dependencies hold one of the licenses
+	Transitive bool `json:"transitive"`
 }
 
 // AnalysisResult contains the results of license analysis
@@ -46,49 +188,410 @@ type AnalysisResult struct {
 	Conflicts       []string
 	Recommendations []string
 	LicenseCounts   map[string]int
+	// Obligations is the union of obligations triggered by any dependency
+	Obligations []Obligation
+	// Denied lists dependencies whose license category is denied
+	Denied []string
+	// IncompatibleWithProject lists dependencies that cannot be distributed
+	// under the project license, when one is set
+	IncompatibleWithProject []string
+	// PredominantLicense is the most common known license (ties broken alphabetically)
+	PredominantLicense string
+	CopyleftTainted    []string
+	// ConfidenceHistogram counts dependencies per detection confidence band
+	ConfidenceHistogram map[string]int
+	// DepthCounts counts dependencies per depth in the dependency graph
+	DepthCounts map[int]int
+	// Approved lists pre-approved dependencies excluded from risk and denial
+	Approved []string
+	// SeverityCounts counts the gating dependencies per risk level of their
+	// license; denied licenses are high, unrecognized ones medium by default
+	SeverityCounts map[string]int
+	// CategoryCounts counts the gating dependencies per license category,
+	// unrecognized licenses included as Unknown
+	CategoryCounts map[LicenseCategory]int
+	// DirectCategoryCounts is the part of CategoryCounts from direct
+	// dependencies, which weigh DirectRiskWeight times in the risk points
+	DirectCategoryCounts map[LicenseCategory]int
+	// StructuredRecommendations are the Recommendations, in the same order,
+	// with their category, severity and affected packages
+	StructuredRecommendations []Recommendation
+	// StructuredConflicts are the Conflicts, in the same order, with the
+	// direct and transitive dependencies involved
+	StructuredConflicts []Conflict
 }
 
 // Dependency represents a dependency with license information
 type Dependency struct {
-	Name       string
-	Version    string
-	License    string
-	Confidence float64
+	Name         string
+	Version      string
+	License      string
+	Confidence   float64
+	Dependencies []string // Names of direct dependencies
+	Depth        int      // 1 for direct dependencies, 0 if unknown
+	Bundled      bool     // Shipped inside the package via bundleDependencies
+	Local        bool     // First-party file: or link: dependency
+	Direct       bool     // Listed in the root package.json
 }
 
 // Analyzer performs license compatibility and risk analysis
-type Analyzer struct{}
+type Analyzer struct {
+	aliases          map[string]string
+	deniedCategories map[LicenseCategory]bool
+	approved         map[string]bool
+	severities       map[LicenseCategory]string
+	private          bool
+	excludePatterns  []string
+	catalog          *Catalog
+	// skipRecommendations leaves the recommendations out of the analysis
+	skipRecommendations bool
+	// projectLicense is the license the project is distributed under
+	projectLicense string
+}
+
+// TypesPattern matches type-only stub packages from DefinitelyTyped, which are
+// uniformly MIT and carry no runtime license concern
+const TypesPattern = "@types/*"
+
+// DefaultSeverities maps license categories to the risk level a single
+// dependency in them raises the project to. Unknown licenses are absent as
+// they escalate by count instead.
+var DefaultSeverities = map[LicenseCategory]string{
+	Permissive:     "low",
+	WeakCopyleft:   "medium",
+	StrongCopyleft: "high",
+}
+
+// CategoryRiskPoints is what a dependency of each category adds to the risk
+// budget; other categories add nothing
+var CategoryRiskPoints = map[LicenseCategory]int{
+	Unknown:        1,
+	WeakCopyleft:   3,
+	StrongCopyleft: 10,
+}
+
+// DirectRiskWeight multiplies the risk points of a direct dependency: the
+// project chose it and can replace it, unlike a transitive one
+const DirectRiskWeight = 2
+
+// ConflictRiskPoints is what each license conflict adds to the risk budget
+const ConflictRiskPoints = 20
+
+// LicensePair is an unordered pair of license identifiers or license
+// category names, such as "proprietary", in the CompatibilityMatrix
+type LicensePair [2]string
+
+// Incompatibility is a CompatibilityMatrix entry
+type Incompatibility struct {
+	// Message is the conflict reported for the pair. It may name the
+	// licenses with %[1]s and %[2]s, in pair order.
+	Message string
+	// Caveat marks pairs that are usually compatible but need checking;
+	// they are reported as conflicts but do not make a dependency
+	// incompatible with the project license
+	Caveat bool
+}
+
+// CompatibilityMatrix maps the license pairs that conflict to the conflict
+// reported for them. Specific licenses take precedence over categories.
+var CompatibilityMatrix = map[LicensePair]Incompatibility{
+	{"GPL-2.0", "Apache-2.0"}: {Message: "GPL-2.0 and Apache-2.0 licenses are incompatible"},
+	{"GPL-2.0", "GPL-3.0"}:    {Message: "GPL-2.0 and GPL-3.0 detected - verify 'or later' clauses for compatibility", Caveat: true},
+	{"GPL-2.0", "AGPL-3.0"}:   {Message: "GPL-2.0 and AGPL-3.0 licenses are incompatible"},
+	// MPL-2.0 code may be relicensed under the GPL unless it is marked
+	// incompatible with secondary licenses
+	{"MPL-2.0", StrongCopyleft.String()}: {
+		Message: "%[1]s and %[2]s detected - verify no %[1]s files are marked 'Incompatible With Secondary Licenses'",
+		Caveat:  true,
+	},
+	// AGPL network use discloses the source of the whole combined work
+	{"AGPL-3.0", Proprietary.String()}: {
+		Message: "%[1]s and %[2]s licenses are incompatible - network use of the combined work requires disclosing the %[2]s code",
+	},
+	{StrongCopyleft.String(), Proprietary.String()}: {
+		Message: "%[1]s and %[2]s licenses are incompatible - copyleft code cannot be combined with proprietary code",
+	},
+}
+
+// laterVersions lists the later versions of licenses with an "or later"
+// option, which a project licensed e.g. GPL-2.0-or-later can be upgraded to
+var laterVersions = map[string][]string{
+	"GPL-2.0":  {"GPL-3.0"},
+	"LGPL-2.1": {"LGPL-3.0"},
+}
+
+// riskRank orders risk levels from least to most severe
+var riskRank = map[string]int{"low": 0, "medium": 1, "high": 2}
+
+// ValidRiskLevel reports whether level is low, medium or high
+func ValidRiskLevel(level string) bool {
+	_, valid := riskRank[level]
+	return valid
+}
+
+// RiskAtLeast reports whether a risk level meets or exceeds a threshold
+func RiskAtLeast(level, threshold string) bool {
+	return riskRank[level] >= riskRank[threshold]
+}
 
 // New creates a new Analyzer
 func New() *Analyzer {
 	return &Analyzer{}
 }
 
+// NewWithAliases creates a new Analyzer that maps custom license spellings
+// to canonical identifiers before the built-in normalization
+func NewWithAliases(aliases map[string]string) *Analyzer {
+	normalized := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		normalized[strings.ToLower(strings.TrimSpace(alias))] = strings.TrimSpace(canonical)
+	}
+	return &Analyzer{aliases: normalized}
+}
+
+// DenyCategories flags every dependency whose license falls in one of the
+// given categories and raises the risk level to high
+func (a *Analyzer) DenyCategories(categories ...LicenseCategory) {
+	if a.deniedCategories == nil {
+		a.deniedCategories = make(map[LicenseCategory]bool)
+	}
+	for _, category := range categories {
+		a.deniedCategories[category] = true
+	}
+}
+
+// Denies reports whether licenses of the category are denied
+func (a *Analyzer) Denies(category LicenseCategory) bool {
+	return a.deniedCategories[category]
+}
+
+// Approve exempts the given name@version packages from risk and denial
+// regardless of their license, e.g. after a legal review of that exact version
+func (a *Analyzer) Approve(packages ...string) {
+	if a.approved == nil {
+		a.approved = make(map[string]bool)
+	}
+	for _, pkg := range packages {
+		a.approved[pkg] = true
+	}
+}
+
+// ExcludeFromRisk keeps packages whose name matches one of the glob patterns
+// (e.g. TypesPattern) in the license counts but out of risk and denial
+func (a *Analyzer) ExcludeFromRisk(patterns ...string) {
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			a.excludePatterns = append(a.excludePatterns, pattern)
+		}
+	}
+}
+
+// isExcluded reports whether the package is first-party or its name matches
+// an exclude pattern
+func (a *Analyzer) isExcluded(dep Dependency) bool {
+	if dep.Local {
+		return true
+	}
+	for _, pattern := range a.excludePatterns {
+		if matched, _ := path.Match(pattern, dep.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isApproved reports whether the exact package version was pre-approved
+func (a *Analyzer) isApproved(dep Dependency) bool {
+	return a.approved[dep.Name+"@"+dep.Version]
+}
+
+// SetPrivate marks the project as private (not published or distributed),
+// which relaxes the copyleft warnings that only apply on distribution
+func (a *Analyzer) SetPrivate(private bool) {
+	a.private = private
+}
+
+// SetProjectLicense sets the license the project is distributed under, e.g.
+// Apache-2.0 or GPL-2.0-or-later, to check every dependency against it. An
+// unrecognized license is rejected, as every check against it would fail.
+func (a *Analyzer) SetProjectLicense(license string) error {
+	license = strings.TrimSpace(license)
+	if license != "" {
+		base, _ := splitOrLater(license)
+		if _, known := a.licenseInfo(a.normalize(base)); !known {
+			return fmt.Errorf("unknown project license %q", license)
+		}
+	}
+	a.projectLicense = license
+	return nil
+}
+
+// SetSkipRecommendations skips generating recommendations, for consumers
+// that only use the risk, conflict and count data
+func (a *Analyzer) SetSkipRecommendations(skip bool) {
+	a.skipRecommendations = skip
+}
+
+// SetSeverities overrides the risk level of the given categories, e.g.
+// WeakCopyleft -> "high" for organizations avoiding LGPL/MPL entirely
+func (a *Analyzer) SetSeverities(severities map[LicenseCategory]string) {
+	if a.severities == nil {
+		a.severities = make(map[LicenseCategory]string)
+	}
+	for category, level := range severities {
+		a.severities[category] = level
+	}
+}
+
+// Severity returns the risk level of a category, honoring SetSeverities, or
+// an empty string if it has none
+func (a *Analyzer) Severity(category LicenseCategory) string {
+	level, _ := a.severity(category)
+	return level
+}
+
+// severity returns the risk level of a category and whether one is set
+func (a *Analyzer) severity(category LicenseCategory) (string, bool) {
+	if level, ok := a.severities[category]; ok {
+		return level, true
+	}
+	level, ok := DefaultSeverities[category]
+	return level, ok
+}
+
+// LoadSeverities reads a JSON or YAML file mapping category names to risk
+// levels (low, medium, high)
+func LoadSeverities(path string) (map[LicenseCategory]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read severity map: %w", err)
+	}
+
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse severity map: %w", err)
+	}
+
+	severities := make(map[LicenseCategory]string, len(raw))
+	for name, level := range raw {
+		category, err := ParseCategory(name)
+		if err != nil {
+			return nil, err
+		}
+		level = strings.ToLower(strings.TrimSpace(level))
+		if _, valid := riskRank[level]; !valid {
+			return nil, fmt.Errorf("invalid risk level for %s: %s (expected low, medium or high)", name, level)
+		}
+		severities[category] = level
+	}
+
+	return severities, nil
+}
+
+// LoadAliases reads a license alias map from a JSON or YAML file
+func LoadAliases(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alias file: %w", err)
+	}
+
+	var aliases map[string]string
+	if err := yaml.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to parse alias file: %w", err)
+	}
+
+	return aliases, nil
+}
+
 // Analyze performs comprehensive license analysis
 func (a *Analyzer) Analyze(dependencies []Dependency) *AnalysisResult {
 	result := &AnalysisResult{
 		Conflicts:       []string{},
 		Recommendations: []string{},
 		LicenseCounts:   make(map[string]int),
+		ConfidenceHistogram: map[string]int{
+			"0": 0, "0-0.5": 0, "0.5-0.9": 0, "0.9-1.0": 0,
+		},
+		DepthCounts: make(map[int]int),
+		Denied:      []string{},
+		Approved:    []string{},
+
+		IncompatibleWithProject: []string{},
+
+		SeverityCounts: map[string]int{"low": 0, "medium": 0, "high": 0},
+		CategoryCounts: make(map[LicenseCategory]int),
+
+		DirectCategoryCounts: make(map[LicenseCategory]int),
 	}
 
 	// Count licenses by category
-	permissiveCount := 0
-	weakCopyleftCount := 0
-	strongCopyleftCount := 0
 	unknownCount := 0
+	unknownLicenseCount := 0
 	lowConfidenceCount := 0
+	categoryCounts := make(map[LicenseCategory]int)
 	hasLGPL := false
 	hasMPL := false
+	// Packages behind each recommendation, and the license of every gating
+	// package for the conflicts
+	affected := make(map[string][]string)
+	var unknownLicensePackages, unrecognizedPackages []string
+	gatingLicenses := make(map[string]string)
+	gatingCounts := make(map[string]int)
+	var gatingDeps []Dependency
 
 	for _, dep := range dependencies {
-		license := normalizeLicense(dep.License)
+		license := a.normalize(dep.License)
 		result.LicenseCounts[license]++
+		result.ConfidenceHistogram[confidenceBand(dep.Confidence)]++
+		if dep.Depth > 0 {
+			result.DepthCounts[dep.Depth]++
+		}
+
+		// Pre-approved and excluded packages are counted but never gate the result
+		if a.isApproved(dep) {
+			result.Approved = append(result.Approved, dep.Name+"@"+dep.Version)
+			continue
+		}
+		if a.isExcluded(dep) {
+			continue
+		}
+		id := dep.Name + "@" + dep.Version
+		gatingLicenses[id] = license
+		gatingCounts[license]++
+		gatingDeps = append(gatingDeps, dep)
+		if license == "Unknown" {
+			unknownLicenseCount++
+			unknownLicensePackages = append(unknownLicensePackages, id)
+		}
+
+		info, known := a.licenseInfo(license)
+		category := Unknown
+		if known {
+			category = info.Category
+		}
+		result.CategoryCounts[category]++
+		if dep.Direct {
+			result.DirectCategoryCounts[category]++
+		}
+		if a.deniedCategories[category] {
+			result.Denied = append(result.Denied,
+				fmt.Sprintf("%s@%s (%s, %s)", dep.Name, dep.Version, license, category))
+			affected[RecommendationDenied] = append(affected[RecommendationDenied], id)
+			result.SeverityCounts["high"]++
+		} else if severity, ok := a.severity(category); ok {
+			result.SeverityCounts[severity]++
+		} else if category == Unknown {
+			result.SeverityCounts["medium"]++
+		}
+		if a.projectLicense != "" && a.incompatibleWithProject(license) {
+			result.IncompatibleWithProject = append(result.IncompatibleWithProject, fmt.Sprintf("%s@%s (%s)", dep.Name, dep.Version, license))
+			affected[RecommendationProjectLicense] = append(affected[RecommendationProjectLicense], id)
+		}
 
-		info, known := KnownLicenses[license]
 		if !known {
 			if license != "Unknown" {
 				unknownCount++
+				unrecognizedPackages = append(unrecognizedPackages, id)
 			}
 			continue
 		}
@@ -96,13 +599,13 @@ func (a *Analyzer) Analyze(dependencies []Dependency) *AnalysisResult {
 		// Track low confidence detections
 		if dep.Confidence < 0.5 {
 			lowConfidenceCount++
+			affected[RecommendationLowConfidence] = append(affected[RecommendationLowConfidence], id)
 		}
+		categoryCounts[info.Category]++
 
 		switch info.Category {
-		case Permissive:
-			permissiveCount++
 		case WeakCopyleft:
-			weakCopyleftCount++
+			affected[RecommendationWeakCopyleft] = append(affected[RecommendationWeakCopyleft], id)
 			if license == "LGPL-2.1" || license == "LGPL-3.0" {
 				hasLGPL = true
 			}
@@ -110,126 +613,574 @@ func (a *Analyzer) Analyze(dependencies []Dependency) *AnalysisResult {
 				hasMPL = true
 			}
 		case StrongCopyleft:
-			strongCopyleftCount++
+			affected[RecommendationStrongCopyleft] = append(affected[RecommendationStrongCopyleft], id)
+			if dep.Bundled {
+				affected[RecommendationBundled] = append(affected[RecommendationBundled], id)
+			}
+			if license == "AGPL-3.0" {
+				affected[RecommendationNetworkCopyleft] = append(affected[RecommendationNetworkCopyleft], id)
+			}
 		}
 	}
 
 	// Calculate unknown count from license counts
-	if count, exists := result.LicenseCounts["Unknown"]; exists {
-		unknownCount = count
+	affected[RecommendationUnknown] = unrecognizedPackages
+	if unknownLicenseCount > 0 {
+		unknownCount = unknownLicenseCount
+		affected[RecommendationUnknown] = unknownLicensePackages
 	}
 
+	result.PredominantLicense = predominantLicense(result.LicenseCounts)
+	result.Obligations = a.aggregateObligations(result.LicenseCounts)
+
 	// Determine overall risk level
-	result.RiskLevel = a.calculateRiskLevel(strongCopyleftCount, weakCopyleftCount, unknownCount, lowConfidenceCount)
+	result.RiskLevel = a.calculateRiskLevel(categoryCounts, unknownCount, lowConfidenceCount)
+	if len(result.Denied) > 0 || len(result.IncompatibleWithProject) > 0 {
+		result.RiskLevel = "high"
+	}
 
-	// Check for GPL conflicts
-	result.Conflicts = a.detectConflicts(result.LicenseCounts)
+	// Check for GPL conflicts among the gating packages, as approved and
+	// excluded ones must not add conflict risk points
+	var conflictLicenses map[string]bool
+	result.StructuredConflicts, conflictLicenses = a.detectConflicts(gatingCounts, gatingDeps)
+	for _, conflict := range result.StructuredConflicts {
+		result.Conflicts = append(result.Conflicts, conflict.Message)
+	}
+	for id, license := range gatingLicenses {
+		if conflictLicenses[license] {
+			affected[RecommendationConflict] = append(affected[RecommendationConflict], id)
+		}
+	}
+
+	// Find packages pulling in strong copyleft code through the dependency graph
+	result.CopyleftTainted = a.detectCopyleftTaint(dependencies)
+
+	if a.skipRecommendations {
+		return result
+	}
 
 	// Generate recommendations
-	result.Recommendations = a.generateRecommendations(
-		permissiveCount,
-		weakCopyleftCount,
-		strongCopyleftCount,
-		unknownCount,
-		lowConfidenceCount,
-		len(result.Conflicts) > 0,
-		hasLGPL,
-		hasMPL,
-	)
+	for _, packages := range affected {
+		sort.Strings(packages)
+	}
+	recommendations := a.generateRecommendations(affected, hasLGPL, hasMPL)
+
+	// Bundling redistributes the package even when the project is private
+	if bundled := affected[RecommendationBundled]; len(bundled) > 0 {
+		recommendations = append(recommendations, Recommendation{
+			Category:         RecommendationBundled,
+			Severity:         "high",
+			AffectedPackages: bundled,
+			Message: fmt.Sprintf("⚠️  %d GPL/AGPL dependencies are bundled into the package - bundling redistributes them, so copyleft obligations apply",
+				len(bundled)),
+		})
+	}
+
+	if incompatible := affected[RecommendationProjectLicense]; len(incompatible) > 0 {
+		if len(recommendations) == 1 && recommendations[0].Category == RecommendationAllClear {
+			recommendations = nil
+		}
+		recommendations = append(recommendations, Recommendation{
+			Category:         RecommendationProjectLicense,
+			Severity:         "high",
+			AffectedPackages: incompatible,
+			Message: fmt.Sprintf("⚠️  %d dependencies are incompatible with the project license %s - they cannot be distributed under it",
+				len(incompatible), a.projectLicense),
+		})
+	}
+
+	if len(result.Denied) > 0 {
+		if len(recommendations) == 1 && recommendations[0].Category == RecommendationAllClear {
+			recommendations = nil
+		}
+		recommendations = append([]Recommendation{{
+			Category:         RecommendationDenied,
+			Severity:         "high",
+			AffectedPackages: affected[RecommendationDenied],
+			Message:          fmt.Sprintf("⛔ %d dependencies use denied license categories - replace them or request an exception", len(result.Denied)),
+		}}, recommendations...)
+	}
+
+	result.StructuredRecommendations = recommendations
+	for _, recommendation := range recommendations {
+		result.Recommendations = append(result.Recommendations, recommendation.Message)
+	}
 
 	return result
 }
 
-// calculateRiskLevel determines the overall risk based on license types
-func (a *Analyzer) calculateRiskLevel(strongCopyleft, weakCopyleft, unknown, lowConfidence int) string {
-	if strongCopyleft > 0 || unknown > 5 {
-		return "high"
+// UnknownPercentage returns the share of dependencies with an Unknown
+// license, from 0 to 100
+func (r *AnalysisResult) UnknownPercentage() float64 {
+	total := 0
+	for _, count := range r.LicenseCounts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(r.LicenseCounts["Unknown"]) / float64(total) * 100
+}
+
+// RiskScore condenses the severity counts into a number from 0 to 100 for
+// trend tracking: the share of dependencies at high risk, with medium risk
+// ones weighted half
+func (r *AnalysisResult) RiskScore() float64 {
+	total := 0
+	for _, count := range r.LicenseCounts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	weighted := float64(r.SeverityCounts["high"]) + float64(r.SeverityCounts["medium"])/2
+	return weighted / float64(total) * 100
+}
+
+// RiskPoints sums the weighted findings for the risk budget: each gating
+// dependency by its category, direct ones DirectRiskWeight times, and each
+// license conflict
+func (r *AnalysisResult) RiskPoints() int {
+	points := len(r.Conflicts) * ConflictRiskPoints
+	for category, count := range r.CategoryCounts {
+		points += count * CategoryRiskPoints[category]
+	}
+	for category, count := range r.DirectCategoryCounts {
+		points += count * CategoryRiskPoints[category] * (DirectRiskWeight - 1)
+	}
+	return points
+}
+
+// aggregateObligations returns each obligation once with the number of
+// packages triggering it, most widespread first
+func (a *Analyzer) aggregateObligations(licenseCounts map[string]int) []Obligation {
+	counts := make(map[string]int)
+	for license, count := range licenseCounts {
+		for _, obligation := range a.obligations(license) {
+			counts[obligation] += count
+		}
+	}
+
+	obligations := []Obligation{}
+	for obligation, count := range counts {
+		obligations = append(obligations, Obligation{Obligation: obligation, Count: count})
+	}
+	sort.Slice(obligations, func(i, j int) bool {
+		if obligations[i].Count != obligations[j].Count {
+			return obligations[i].Count > obligations[j].Count
+		}
+		return obligations[i].Obligation < obligations[j].Obligation
+	})
+
+	return obligations
+}
+
+// predominantLicense returns the license with the highest count, ignoring
+// unknown licenses; ties are broken alphabetically for deterministic output
+func predominantLicense(licenseCounts map[string]int) string {
+	predominant := ""
+	for license, count := range licenseCounts {
+		if license == "Unknown" {
+			continue
+		}
+		best := licenseCounts[predominant]
+		if predominant == "" || count > best || (count == best && license < predominant) {
+			predominant = license
+		}
+	}
+	return predominant
+}
+
+// confidenceBand returns the histogram bucket for a detection confidence
+func confidenceBand(confidence float64) string {
+	switch {
+	case confidence <= 0:
+		return "0"
+	case confidence < 0.5:
+		return "0-0.5"
+	case confidence < 0.9:
+		return "0.5-0.9"
+	default:
+		return "0.9-1.0"
+	}
+}
+
+// calculateRiskLevel determines the overall risk as the most severe level
+// among the categories present
+func (a *Analyzer) calculateRiskLevel(categoryCounts map[LicenseCategory]int, unknown, lowConfidence int) string {
+	level := "low"
+	raise := func(to string) {
+		if riskRank[to] > riskRank[level] {
+			level = to
+		}
+	}
+
+	for category, count := range categoryCounts {
+		if severity, ok := a.severity(category); ok && count > 0 {
+			raise(severity)
+		}
+	}
+
+	if severity, ok := a.severity(Unknown); ok {
+		if unknown > 0 {
+			raise(severity)
+		}
+	} else if unknown > 5 {
+		raise("high")
+	} else if unknown > 0 {
+		raise("medium")
+	}
+
+	if lowConfidence > 3 {
+		raise("medium")
+	}
+	return level
+}
+
+// detectConflicts identifies incompatible license combinations with the
+// dependencies involved. Conflicts that can be resolved by changing a direct
+// dependency are listed first.
+func (a *Analyzer) detectConflicts(licenseCounts map[string]int, dependencies []Dependency) ([]Conflict, map[string]bool) {
+	directConflicts := []Conflict{}
+	otherConflicts := []Conflict{}
+	direct := make(map[string][]string)
+	transitive := make(map[string]bool)
+	for _, dep := range dependencies {
+		license := a.normalize(dep.License)
+		switch {
+		case dep.Depth == 1:
+			direct[license] = append(direct[license], dep.Name)
+		case dep.Depth > 1:
+			transitive[license] = true
+		}
+	}
+
+	// add attributes a conflict to the direct packages holding its licenses
+	// and notes whether deeper dependencies hold them too
+	conflicting := make(map[string]bool)
+	add := func(message string, licenses ...string) {
+		conflict := Conflict{Message: message, DirectPackages: []string{}}
+		for _, license := range licenses {
+			conflicting[license] = true
+			conflict.DirectPackages = append(conflict.DirectPackages, direct[license]...)
+			conflict.Transitive = conflict.Transitive || transitive[license]
+		}
+		if len(conflict.DirectPackages) == 0 {
+			otherConflicts = append(otherConflicts, conflict)
+			return
+		}
+		sort.Strings(conflict.DirectPackages)
+		directConflicts = append(directConflicts, conflict)
+	}
+
+	// AGPL is the most restrictive - report first. Its network clause binds
+	// the project whatever it is combined with, even on its own.
+	if licenseCounts["AGPL-3.0"] > 0 {
+		add("AGPL-3.0 requires source disclosure for network use - ensure compliance", "AGPL-3.0")
+	}
+
+	// Each pair of distinct licenses is looked up once
+	licenses := make([]string, 0, len(licenseCounts))
+	for license, count := range licenseCounts {
+		if count > 0 {
+			licenses = append(licenses, license)
+		}
+	}
+	sort.Strings(licenses)
+	reported := make(map[string]bool)
+	for i, first := range licenses {
+		for _, second := range licenses[i+1:] {
+			if conflict, ok := a.incompatibility(first, second); ok && !reported[conflict.Message] {
+				reported[conflict.Message] = true
+				add(conflict.Message, first, second)
+			}
+		}
+	}
+
+	return append(directConflicts, otherConflicts...), conflicting
+}
+
+// incompatibility looks up two licenses in the CompatibilityMatrix, trying
+// each license before its category and both orders of the pair. The message
+// of the returned entry names the licenses.
+func (a *Analyzer) incompatibility(first, second string) (Incompatibility, bool) {
+	keys := func(license string) []string {
+		if info, known := a.licenseInfo(license); known {
+			return []string{license, info.Category.String()}
+		}
+		return []string{license}
+	}
+
+	for _, x := range keys(first) {
+		for _, y := range keys(second) {
+			if entry, ok := CompatibilityMatrix[LicensePair{x, y}]; ok {
+				entry.Message = formatConflict(entry.Message, first, second)
+				return entry, true
+			}
+			if entry, ok := CompatibilityMatrix[LicensePair{y, x}]; ok {
+				entry.Message = formatConflict(entry.Message, second, first)
+				return entry, true
+			}
+		}
+	}
+	return Incompatibility{}, false
+}
+
+// incompatibleWithProject reports whether a dependency license cannot be
+// combined into a work distributed under the project license. A project
+// licensed "or later" only conflicts if no version it may use is compatible.
+func (a *Analyzer) incompatibleWithProject(license string) bool {
+	base, orLater := splitOrLater(a.projectLicense)
+	project := a.normalize(base)
+	projects := []string{project}
+	if orLater {
+		projects = append(projects, laterVersions[project]...)
+	}
+	for _, project := range projects {
+		if !a.incompatibleWith(project, license, orLater) {
+			return false
+		}
+	}
+	return true
+}
+
+// incompatibleWith reports whether a dependency license cannot be combined
+// into a work under the project license: the pair is a hard conflict of the
+// CompatibilityMatrix, the dependency is a later version the project cannot
+// be upgraded to, or it is strong copyleft, whose terms would extend to the
+// whole work, while the project is not
+func (a *Analyzer) incompatibleWith(project, license string, orLater bool) bool {
+	if license == project || license == "Unknown" {
+		return false
+	}
+	if entry, ok := a.incompatibility(project, license); ok && !entry.Caveat {
+		return true
 	}
-	if weakCopyleft > 0 || unknown > 0 || lowConfidence > 3 {
-		return "medium"
+	if !orLater && slices.Contains(laterVersions[project], license) {
+		return true
 	}
-	return "low"
+	info, known := a.licenseInfo(license)
+	if !known || info.Category != StrongCopyleft {
+		return false
+	}
+	projectInfo, known := a.licenseInfo(project)
+	return !known || projectInfo.Category != StrongCopyleft
 }
 
-// detectConflicts identifies incompatible license combinations
-func (a *Analyzer) detectConflicts(licenseCounts map[string]int) []string {
-	conflicts := []string{}
+// splitOrLater strips the "-or-later" (or deprecated "+") suffix of an SPDX
+// license id, reporting whether it had one
+func splitOrLater(license string) (string, bool) {
+	license = strings.TrimSpace(license)
+	if base, found := strings.CutSuffix(license, "-or-later"); found {
+		return base, true
+	}
+	if base, found := strings.CutSuffix(license, "+"); found {
+		return base, true
+	}
+	return license, false
+}
 
-	hasGPL2 := licenseCounts["GPL-2.0"] > 0
-	hasGPL3 := licenseCounts["GPL-3.0"] > 0
-	hasAGPL := licenseCounts["AGPL-3.0"] > 0
-	hasApache := licenseCounts["Apache-2.0"] > 0 || licenseCounts["Apache 2.0"] > 0
+// formatConflict names the licenses in a CompatibilityMatrix message
+func formatConflict(message, first, second string) string {
+	if !strings.Contains(message, "%[") {
+		return message
+	}
+	return fmt.Sprintf(message, first, second)
+}
 
-	// AGPL is the most restrictive - report first
-	if hasAGPL {
-		conflicts = append(conflicts, "AGPL-3.0 requires source disclosure for network use - ensure compliance")
+// detectCopyleftTaint lists every package that transitively depends on a strong copyleft package
+func (a *Analyzer) detectCopyleftTaint(dependencies []Dependency) []string {
+	// dependents maps each package to the packages that depend on it
+	dependents := make(map[string][]string)
+	var queue []string
+	for _, dep := range dependencies {
+		for _, child := range dep.Dependencies {
+			dependents[child] = append(dependents[child], dep.Name)
+		}
+		if info, known := a.licenseInfo(a.normalize(dep.License)); known && info.Category == StrongCopyleft && !a.isApproved(dep) && !a.isExcluded(dep) {
+			queue = append(queue, dep.Name)
+		}
 	}
 
-	// GPL-2.0 and Apache-2.0 are incompatible
-	if hasGPL2 && hasApache {
-		conflicts = append(conflicts, "GPL-2.0 and Apache-2.0 licenses are incompatible")
+	if len(queue) == 0 {
+		return []string{}
 	}
 
-	// GPL-3.0 with GPL-2.0 (without "or later" clause) can be problematic
-	if hasGPL2 && hasGPL3 {
-		conflicts = append(conflicts, "GPL-2.0 and GPL-3.0 detected - verify 'or later' clauses for compatibility")
+	// Walking up from the copyleft packages reaches every package depending
+	// on one, cycles included
+	tainted := make(map[string]bool)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, parent := range dependents[name] {
+			if !tainted[parent] {
+				tainted[parent] = true
+				queue = append(queue, parent)
+			}
+		}
 	}
 
-	return conflicts
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, dep := range dependencies {
+		if seen[dep.Name] {
+			continue
+		}
+		seen[dep.Name] = true
+		if tainted[dep.Name] {
+			names = append(names, dep.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
 }
 
-// generateRecommendations creates actionable guidance based on analysis
-func (a *Analyzer) generateRecommendations(
-	permissive, weakCopyleft, strongCopyleft, unknown, lowConfidence int,
-	hasConflicts, hasLGPL, hasMPL bool,
-) []string {
-	recommendations := []string{}
+// allClearRecommendation is reported when no other recommendation applies
+const allClearRecommendation = "✓ All licenses are permissive and compatible - no compliance issues detected"
+
+// generateRecommendations creates actionable guidance based on analysis,
+// from the packages affected per recommendation category
+func (a *Analyzer) generateRecommendations(affected map[string][]string, hasLGPL, hasMPL bool) []Recommendation {
+	recommendations := []Recommendation{}
+	recommend := func(category, severity, message string) {
+		packages := affected[category]
+		if packages == nil {
+			packages = []string{}
+		}
+		recommendations = append(recommendations, Recommendation{
+			Category:         category,
+			Severity:         severity,
+			AffectedPackages: packages,
+			Message:          message,
+		})
+	}
 
 	// Conflict-based recommendations
-	if hasConflicts {
-		recommendations = append(recommendations, "⚠️  License conflicts detected - review dependencies for compatibility issues")
+	if len(affected[RecommendationConflict]) > 0 {
+		recommend(RecommendationConflict, "high", "⚠️  License conflicts detected - review dependencies for compatibility issues")
 	}
 
-	// Strong copyleft recommendations
-	if strongCopyleft > 0 {
-		recommendations = append(recommendations,
+	// Strong copyleft recommendations. Private projects are not distributed,
+	// so only network copyleft (AGPL) still requires action.
+	strongCopyleft := len(affected[RecommendationStrongCopyleft])
+	if strongCopyleft > 0 && a.private {
+		if networkCopyleft := len(affected[RecommendationNetworkCopyleft]); networkCopyleft > 0 {
+			recommend(RecommendationNetworkCopyleft, "high",
+				fmt.Sprintf("⚠️  Found %d AGPL dependencies - network use requires source disclosure even in a private project", networkCopyleft))
+		}
+		recommend(RecommendationStrongCopyleft, "low",
+			fmt.Sprintf("ℹ️  Found %d GPL/AGPL dependencies in a private project - distribution obligations apply only if it is published", strongCopyleft))
+	} else if strongCopyleft > 0 {
+		recommend(RecommendationStrongCopyleft, "high",
 			fmt.Sprintf("⚠️  Found %d GPL/AGPL dependencies - ensure compliance with copyleft requirements", strongCopyleft))
-		recommendations = append(recommendations, "📋 Consider legal review if distributing proprietary software")
+		recommend(RecommendationStrongCopyleft, "medium", "📋 Consider legal review if distributing proprietary software")
 	}
 
 	// Weak copyleft recommendations
-	if weakCopyleft > 0 && (hasLGPL || hasMPL) {
-		recommendations = append(recommendations,
+	if weakCopyleft := len(affected[RecommendationWeakCopyleft]); weakCopyleft > 0 && (hasLGPL || hasMPL) {
+		recommend(RecommendationWeakCopyleft, "low",
 			fmt.Sprintf("ℹ️  Found %d LGPL/MPL dependencies - these allow proprietary use with conditions", weakCopyleft))
 	}
 
 	// Unknown license recommendations
-	if unknown > 0 {
-		recommendations = append(recommendations,
+	if unknown := len(affected[RecommendationUnknown]); unknown > 0 {
+		recommend(RecommendationUnknown, "medium",
 			fmt.Sprintf("⚠️  %d dependencies have unknown licenses - manual review required", unknown))
-		recommendations = append(recommendations, "🔍 Check package repositories or contact maintainers for license clarification")
+		recommend(RecommendationUnknown, "low", "🔍 Check package repositories or contact maintainers for license clarification")
 	}
 
 	// Low confidence recommendations
-	if lowConfidence > 0 {
-		recommendations = append(recommendations,
+	if lowConfidence := len(affected[RecommendationLowConfidence]); lowConfidence > 0 {
+		recommend(RecommendationLowConfidence, "medium",
 			fmt.Sprintf("⚠️  %d dependencies have low-confidence license detection - verify manually", lowConfidence))
 	}
 
 	// All clear
 	if len(recommendations) == 0 {
-		recommendations = append(recommendations, "✓ All licenses are permissive and compatible - no compliance issues detected")
+		recommend(RecommendationAllClear, "low", allClearRecommendation)
 	}
 
 	return recommendations
 }
 
-// normalizeLicense normalizes license strings for consistent comparison
+// ParseCategory parses a category name such as "strongCopyleft" or "strong-copyleft"
+func ParseCategory(name string) (LicenseCategory, error) {
+	key := strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.TrimSpace(name)))
+	for _, category := range []LicenseCategory{Permissive, WeakCopyleft, StrongCopyleft, Proprietary, Unknown} {
+		if strings.ReplaceAll(category.String(), "-", "") == key {
+			return category, nil
+		}
+	}
+	return Unknown, fmt.Errorf("unknown license category: %s", name)
+}
+
+// Normalize returns the canonical identifier the analyzer counts a license
+// under, after aliases and the license catalog
+func (a *Analyzer) Normalize(license string) string {
+	return a.normalize(license)
+}
+
+// Category returns the category of a license as the analyzer classifies it,
+// taking aliases and the license catalog into account
+func (a *Analyzer) Category(license string) LicenseCategory {
+	if info, known := a.licenseInfo(a.normalize(license)); known {
+		return info.Category
+	}
+	return Unknown
+}
+
+// Categorize returns the category of a license, or Unknown if it is not recognized
+func Categorize(license string) LicenseCategory {
+	if info, known := builtinLicenseInfo(normalizeLicense(license)); known {
+		return info.Category
+	}
+	return Unknown
+}
+
+// normalize applies user-provided aliases, then the built-in normalization,
+// so an alias target such as "Apache 2.0" still counts as Apache-2.0
+func (a *Analyzer) normalize(license string) string {
+	if canonical, ok := a.aliases[strings.ToLower(strings.TrimSpace(license))]; ok {
+		license = canonical
+	}
+	if a.catalog != nil {
+		if _, ok := a.catalog.Licenses[strings.TrimSpace(license)]; ok {
+			return strings.TrimSpace(license)
+		}
+	}
+	if parsed, err := ParseLicenseExpression(license); err == nil && parsed.Compound() {
+		resolved, _ := parsed.resolve(a.licenseInfo)
+		return resolved
+	}
+	return normalizeLicenseID(license)
+}
+
+// normalizeLicense normalizes license strings for consistent comparison. SPDX
+// expressions resolve to their least restrictive OR branch; AND expressions
+// keep their normalized parts.
 func normalizeLicense(license string) string {
+	if parsed, err := ParseLicenseExpression(license); err == nil && parsed.Compound() {
+		resolved, _ := parsed.resolve(builtinLicenseInfo)
+		return resolved
+	}
+	return normalizeLicenseID(license)
+}
+
+// normalizeLicenseID normalizes common spellings of a single license
+func normalizeLicenseID(license string) string {
 	normalized := strings.TrimSpace(license)
 
 	// Handle common variations
 	lower := strings.ToLower(normalized)
+	if id, ok := licenseAliases[lower]; ok {
+		return id
+	}
+	for id := range KnownLicenses {
+		if strings.ToLower(id) == lower {
+			return id
+		}
+	}
 	if strings.Contains(lower, "apache") {
 		return "Apache-2.0"
 	}
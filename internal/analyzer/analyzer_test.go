@@ -1,6 +1,11 @@
 package analyzer
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -217,6 +222,148 @@ func TestAnalyze_LicenseCounts(t *testing.T) {
 	}
 }
 
+func TestAnalyze_ApacheSpellingsMerged(t *testing.T) {
+	analyzer := NewWithAliases(map[string]string{"ASL2": "Apache 2.0"})
+	deps := []Dependency{
+		{Name: "pkg1", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0},
+		{Name: "pkg2", Version: "1.0.0", License: "Apache 2.0", Confidence: 1.0},
+		{Name: "pkg3", Version: "1.0.0", License: "ASL2", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if result.LicenseCounts["Apache-2.0"] != 3 {
+		t.Errorf("Expected 3 Apache-2.0 licenses, got %d", result.LicenseCounts["Apache-2.0"])
+	}
+	if len(result.LicenseCounts) != 1 {
+		t.Errorf("Expected a single license entry, got %v", result.LicenseCounts)
+	}
+}
+
+func TestAnalyze_PredominantLicense(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "pkg1", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg2", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg3", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg4", Version: "1.0.0", License: "ISC", Confidence: 1.0},
+		{Name: "pkg5", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+		{Name: "pkg6", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+		{Name: "pkg7", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+		{Name: "pkg8", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if result.PredominantLicense != "MIT" {
+		t.Errorf("Expected predominant license 'MIT', got '%s'", result.PredominantLicense)
+	}
+
+	tied := analyzer.Analyze([]Dependency{
+		{Name: "pkg1", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg2", Version: "1.0.0", License: "ISC", Confidence: 1.0},
+	})
+
+	if tied.PredominantLicense != "ISC" {
+		t.Errorf("Expected ties to be broken alphabetically, got '%s'", tied.PredominantLicense)
+	}
+}
+
+func TestAnalyze_Obligations(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "pkg1", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg2", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg3", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := []Obligation{
+		{Obligation: ObligationAttribution, Count: 3},
+		{Obligation: ObligationSameLicense, Count: 1},
+		{Obligation: ObligationProvideSource, Count: 1},
+	}
+	if len(result.Obligations) != len(expected) {
+		t.Fatalf("Expected obligations %v, got %v", expected, result.Obligations)
+	}
+	for i, obligation := range expected {
+		if result.Obligations[i] != obligation {
+			t.Errorf("Expected obligation %v at %d, got %v", obligation, i, result.Obligations[i])
+		}
+	}
+}
+
+func TestAnalyze_DenyCategory(t *testing.T) {
+	analyzer := New()
+	analyzer.DenyCategories(StrongCopyleft)
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if len(result.Denied) != 1 {
+		t.Fatalf("Expected 1 denied dependency, got %v", result.Denied)
+	}
+
+	if !containsString(result.Denied[0], "gpl-package@1.0.0") {
+		t.Errorf("Expected gpl-package to be denied, got %s", result.Denied[0])
+	}
+
+	if result.RiskLevel != "high" {
+		t.Errorf("Expected risk level 'high', got '%s'", result.RiskLevel)
+	}
+}
+
+func TestAnalyze_DenyCategoryElevatesRisk(t *testing.T) {
+	analyzer := New()
+	analyzer.DenyCategories(WeakCopyleft)
+	deps := []Dependency{
+		{Name: "mpl-package", Version: "1.0.0", License: "MPL-2.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if result.RiskLevel != "high" {
+		t.Errorf("Expected denied weak copyleft to raise risk to 'high', got '%s'", result.RiskLevel)
+	}
+
+	if !containsString(result.Recommendations[0], "denied license categories") {
+		t.Errorf("Expected denied recommendation first, got %v", result.Recommendations)
+	}
+}
+
+func TestParseCategory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected LicenseCategory
+	}{
+		{"strongCopyleft", StrongCopyleft},
+		{"strong-copyleft", StrongCopyleft},
+		{"weakCopyleft", WeakCopyleft},
+		{"Proprietary", Proprietary},
+		{"permissive", Permissive},
+		{"unknown", Unknown},
+	}
+
+	for _, tt := range tests {
+		category, err := ParseCategory(tt.input)
+		if err != nil {
+			t.Errorf("ParseCategory(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if category != tt.expected {
+			t.Errorf("ParseCategory(%q) = %v, expected %v", tt.input, category, tt.expected)
+		}
+	}
+
+	if _, err := ParseCategory("copyleft-ish"); err == nil {
+		t.Error("Expected error for unknown category")
+	}
+}
+
 func TestNormalizeLicense(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -230,6 +377,8 @@ func TestNormalizeLicense(t *testing.T) {
 		{"gpl-3.0", "GPL-3.0"},
 		{"MIT", "MIT"},
 		{"  MIT  ", "MIT"},
+		// BSL is as often the Business Source License as the Boost one
+		{"BSL", "BSL"},
 	}
 
 	for _, tt := range tests {
@@ -240,6 +389,45 @@ func TestNormalizeLicense(t *testing.T) {
 	}
 }
 
+func TestKnownLicenses_Categories(t *testing.T) {
+	tests := []struct {
+		input     string
+		category  LicenseCategory
+		riskLevel string
+	}{
+		{"0BSD", Permissive, "low"},
+		{"unlicense", Permissive, "low"},
+		{"The Unlicense", Permissive, "low"},
+		{"cc0", Permissive, "low"},
+		{"CC0-1.0", Permissive, "low"},
+		{"CC-BY-4.0", Permissive, "medium"},
+		{"CC-BY-SA-4.0", WeakCopyleft, "medium"},
+		{"wtfpl", Permissive, "low"},
+		{"Zlib", Permissive, "low"},
+		{"zlib/libpng", Permissive, "low"},
+		{"Python-2.0", Permissive, "low"},
+		{"PostgreSQL", Permissive, "low"},
+		{"EPL-2.0", WeakCopyleft, "medium"},
+		{"Eclipse Public License 2.0", WeakCopyleft, "medium"},
+		{"BSL-1.0", Permissive, "low"},
+		{"Boost", Permissive, "low"},
+		// Not to be mistaken for the Unlicense
+		{"UNLICENSED", Proprietary, "high"},
+		{"unlicensed", Proprietary, "high"},
+	}
+
+	for _, tt := range tests {
+		info, known := KnownLicenses[normalizeLicense(tt.input)]
+		if !known {
+			t.Errorf("%q: expected a known license, normalized to %q", tt.input, normalizeLicense(tt.input))
+			continue
+		}
+		if info.Category != tt.category || info.RiskLevel != tt.riskLevel {
+			t.Errorf("%q: expected %s/%s, got %s/%s", tt.input, tt.category, tt.riskLevel, info.Category, info.RiskLevel)
+		}
+	}
+}
+
 func TestAnalyze_GPL2AndGPL3Conflict(t *testing.T) {
 	analyzer := New()
 	deps := []Dependency{
@@ -262,6 +450,154 @@ func TestAnalyze_GPL2AndGPL3Conflict(t *testing.T) {
 	}
 }
 
+func TestAnalyze_CopyleftTaint(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "app-core", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"middleware"}},
+		{Name: "middleware", Version: "2.0.0", License: "ISC", Confidence: 1.0, Dependencies: []string{"gpl-leaf", "helper"}},
+		{Name: "helper", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-leaf", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "standalone", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"helper"}},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := []string{"app-core", "middleware"}
+	if len(result.CopyleftTainted) != len(expected) {
+		t.Fatalf("Expected tainted packages %v, got %v", expected, result.CopyleftTainted)
+	}
+	for i, name := range expected {
+		if result.CopyleftTainted[i] != name {
+			t.Errorf("Expected tainted package %q at %d, got %q", name, i, result.CopyleftTainted[i])
+		}
+	}
+}
+
+func TestAnalyze_CopyleftTaint_Cycle(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "a", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"b"}},
+		{Name: "b", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"a"}},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if len(result.CopyleftTainted) != 0 {
+		t.Errorf("Expected no tainted packages, got %v", result.CopyleftTainted)
+	}
+}
+
+func TestAnalyze_CopyleftTaint_CycleReachingCopyleft(t *testing.T) {
+	analyzer := New()
+	// b is visited while a is still on the stack; it is tainted through a
+	deps := []Dependency{
+		{Name: "a", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"b", "gpl-leaf"}},
+		{Name: "b", Version: "1.0.0", License: "MIT", Confidence: 1.0, Dependencies: []string{"a"}},
+		{Name: "gpl-leaf", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := []string{"a", "b"}
+	if !reflect.DeepEqual(result.CopyleftTainted, expected) {
+		t.Errorf("Expected tainted packages %v, got %v", expected, result.CopyleftTainted)
+	}
+}
+
+func TestAnalyze_CustomAliases(t *testing.T) {
+	analyzer := NewWithAliases(map[string]string{
+		"Expat":                   "MIT",
+		"GNU GPL v3 or something": "GPL-3.0",
+	})
+	deps := []Dependency{
+		{Name: "expat-package", Version: "1.0.0", License: "expat", Confidence: 1.0},
+		{Name: "odd-gpl", Version: "1.0.0", License: "GNU GPL v3 or something", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if result.LicenseCounts["MIT"] != 1 {
+		t.Errorf("Expected alias to resolve to MIT, got counts %v", result.LicenseCounts)
+	}
+
+	if result.LicenseCounts["GPL-3.0"] != 1 {
+		t.Errorf("Expected alias to resolve to GPL-3.0, got counts %v", result.LicenseCounts)
+	}
+
+	if result.RiskLevel != "high" {
+		t.Errorf("Expected risk level 'high', got '%s'", result.RiskLevel)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	content := `{"Apache License 2.0": "Apache-2.0", "Expat": "MIT"}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write alias file: %v", err)
+	}
+
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if aliases["Expat"] != "MIT" || aliases["Apache License 2.0"] != "Apache-2.0" {
+		t.Errorf("Unexpected aliases: %v", aliases)
+	}
+
+	if _, err := LoadAliases(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing alias file")
+	}
+}
+
+func TestAnalyze_ConfidenceHistogram(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "pkg1", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+		{Name: "pkg2", Version: "1.0.0", License: "MIT", Confidence: 0.9},
+		{Name: "pkg3", Version: "1.0.0", License: "BSD-3-Clause", Confidence: 0.8},
+		{Name: "pkg4", Version: "1.0.0", License: "Unknown", Confidence: 0.2},
+		{Name: "pkg5", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+		{Name: "pkg6", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := map[string]int{"0": 2, "0-0.5": 1, "0.5-0.9": 1, "0.9-1.0": 2}
+	for band, count := range expected {
+		if result.ConfidenceHistogram[band] != count {
+			t.Errorf("Expected %d dependencies in band %s, got %d", count, band, result.ConfidenceHistogram[band])
+		}
+	}
+
+	if len(result.ConfidenceHistogram) != len(expected) {
+		t.Errorf("Unexpected bands in histogram: %v", result.ConfidenceHistogram)
+	}
+}
+
+func TestAnalyze_DepthCounts(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "express", Version: "4.18.0", License: "MIT", Confidence: 1.0, Depth: 1},
+		{Name: "jest", Version: "29.0.0", License: "MIT", Confidence: 1.0, Depth: 1},
+		{Name: "body-parser", Version: "1.20.0", License: "MIT", Confidence: 1.0, Depth: 2},
+		{Name: "deep-gpl", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0, Depth: 5},
+		{Name: "unlinked", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := map[int]int{1: 2, 2: 1, 5: 1}
+	if len(result.DepthCounts) != len(expected) {
+		t.Errorf("Expected depth counts %v, got %v", expected, result.DepthCounts)
+	}
+	for depth, count := range expected {
+		if result.DepthCounts[depth] != count {
+			t.Errorf("Expected %d dependencies at depth %d, got %d", count, depth, result.DepthCounts[depth])
+		}
+	}
+}
+
 // Helper function to check if a string contains a substring
 func containsString(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && containsSubstring(s, substr))
@@ -275,3 +611,659 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAnalyze_ApprovedPackages(t *testing.T) {
+	analyzer := New()
+	analyzer.DenyCategories(StrongCopyleft)
+	analyzer.Approve("reviewed-gpl@1.0.0")
+
+	approvedOnly := analyzer.Analyze([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "reviewed-gpl", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+	})
+
+	if len(approvedOnly.Denied) != 0 {
+		t.Errorf("Expected approved GPL package not to be denied, got %v", approvedOnly.Denied)
+	}
+
+	if approvedOnly.RiskLevel != "low" {
+		t.Errorf("Expected risk level 'low', got '%s'", approvedOnly.RiskLevel)
+	}
+
+	if len(approvedOnly.Approved) != 1 || approvedOnly.Approved[0] != "reviewed-gpl@1.0.0" {
+		t.Errorf("Expected reviewed-gpl@1.0.0 to be approved, got %v", approvedOnly.Approved)
+	}
+
+	// Approval is per exact version
+	unapproved := analyzer.Analyze([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "reviewed-gpl", Version: "1.0.1", License: "GPL-3.0", Confidence: 1.0},
+	})
+
+	if len(unapproved.Denied) != 1 || !containsString(unapproved.Denied[0], "reviewed-gpl@1.0.1") {
+		t.Errorf("Expected unapproved version to be denied, got %v", unapproved.Denied)
+	}
+
+	if unapproved.RiskLevel != "high" {
+		t.Errorf("Expected risk level 'high', got '%s'", unapproved.RiskLevel)
+	}
+
+	// An approved package takes no part in conflicts, whose risk points
+	// would otherwise exceed a -risk-budget
+	analyzer.Approve("reviewed-gpl2@1.0.0")
+	approvedConflict := analyzer.Analyze([]Dependency{
+		{Name: "apache-package", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0},
+		{Name: "reviewed-gpl2", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0},
+	})
+	if len(approvedConflict.Conflicts) != 0 || approvedConflict.RiskPoints() != 0 {
+		t.Errorf("Expected no conflicts or risk points, got %v and %d points", approvedConflict.Conflicts, approvedConflict.RiskPoints())
+	}
+}
+
+func TestAnalyze_SeverityMap(t *testing.T) {
+	deps := []Dependency{
+		{Name: "lgpl-package", Version: "1.0.0", License: "LGPL-3.0", Confidence: 1.0},
+	}
+
+	if result := New().Analyze(deps); result.RiskLevel != "medium" {
+		t.Fatalf("Expected default risk level 'medium', got '%s'", result.RiskLevel)
+	}
+
+	analyzer := New()
+	analyzer.SetSeverities(map[LicenseCategory]string{WeakCopyleft: "high"})
+
+	if result := analyzer.Analyze(deps); result.RiskLevel != "high" {
+		t.Errorf("Expected weak copyleft mapped to 'high', got '%s'", result.RiskLevel)
+	}
+}
+
+func TestLoadSeverities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "severities.yaml")
+	if err := os.WriteFile(path, []byte("weakCopyleft: high\nunknown: low\n"), 0o644); err != nil {
+		t.Fatalf("failed to write severity map: %v", err)
+	}
+
+	severities, err := LoadSeverities(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if severities[WeakCopyleft] != "high" || severities[Unknown] != "low" {
+		t.Errorf("Unexpected severities: %v", severities)
+	}
+
+	if err := os.WriteFile(path, []byte("weakCopyleft: critical\n"), 0o644); err != nil {
+		t.Fatalf("failed to write severity map: %v", err)
+	}
+	if _, err := LoadSeverities(path); err == nil {
+		t.Error("Expected error for an invalid risk level")
+	}
+}
+
+func TestAnalyze_PrivateProject(t *testing.T) {
+	analyzer := New()
+	analyzer.SetPrivate(true)
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	for _, rec := range result.Recommendations {
+		if containsString(rec, "ensure compliance with copyleft") || containsString(rec, "if distributing") {
+			t.Errorf("Expected distribution warnings to be suppressed, got %q", rec)
+		}
+	}
+
+	if !containsString(strings.Join(result.Recommendations, "\n"), "private project") {
+		t.Errorf("Expected a private project note, got %v", result.Recommendations)
+	}
+
+	// Network copyleft still applies without distribution
+	result = analyzer.Analyze([]Dependency{
+		{Name: "agpl-package", Version: "1.0.0", License: "AGPL-3.0", Confidence: 1.0},
+	})
+	if !containsString(strings.Join(result.Recommendations, "\n"), "network use") {
+		t.Errorf("Expected AGPL warning for a private project, got %v", result.Recommendations)
+	}
+}
+
+func TestAnalyze_ExcludeFromRisk(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "@types/node", Version: "20.0.0", License: "Unknown", Confidence: 0.0},
+	}
+
+	if result := New().Analyze(deps); result.RiskLevel != "medium" {
+		t.Fatalf("Expected the unknown @types/node to raise risk to 'medium', got '%s'", result.RiskLevel)
+	}
+
+	analyzer := New()
+	analyzer.ExcludeFromRisk(TypesPattern)
+	result := analyzer.Analyze(deps)
+
+	if result.RiskLevel != "low" {
+		t.Errorf("Expected @types/node to be excluded from risk, got '%s'", result.RiskLevel)
+	}
+
+	if result.LicenseCounts["Unknown"] != 1 {
+		t.Errorf("Expected @types/node to remain in the license counts, got %v", result.LicenseCounts)
+	}
+
+	// Excluded packages add no conflict risk points either
+	analyzer.ExcludeFromRisk("vendored-*")
+	result = analyzer.Analyze([]Dependency{
+		{Name: "apache-package", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0},
+		{Name: "vendored-gpl2", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0},
+	})
+	if len(result.Conflicts) != 0 || result.RiskPoints() != 0 {
+		t.Errorf("Expected no conflicts or risk points, got %v and %d points", result.Conflicts, result.RiskPoints())
+	}
+}
+
+func TestAnalysisResult_UnknownPercentage(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "lodash", Version: "4.17.21", License: "MIT", Confidence: 1.0},
+		{Name: "mystery", Version: "1.0.0", License: "Unknown", Confidence: 0.0},
+		{Name: "tslib", Version: "2.6.0", License: "0BSD", Confidence: 1.0},
+	}
+
+	if percentage := New().Analyze(deps).UnknownPercentage(); percentage != 25 {
+		t.Errorf("Expected 25%% unknown, got %v", percentage)
+	}
+
+	if percentage := New().Analyze(nil).UnknownPercentage(); percentage != 0 {
+		t.Errorf("Expected 0%% unknown without dependencies, got %v", percentage)
+	}
+}
+
+func BenchmarkAnalyze(b *testing.B) {
+	licenses := []string{"MIT", "ISC", "Apache-2.0", "BSD-3-Clause", "LGPL-3.0", "GPL-3.0", "Unknown"}
+	deps := make([]Dependency, 5000)
+	for i := range deps {
+		deps[i] = Dependency{
+			Name:         fmt.Sprintf("pkg-%d", i),
+			Version:      "1.0.0",
+			License:      licenses[i%len(licenses)],
+			Confidence:   1.0,
+			Dependencies: []string{fmt.Sprintf("pkg-%d", (i+1)%len(deps))},
+			Depth:        i%5 + 1,
+		}
+	}
+	analyzer := New()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyzer.Analyze(deps)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "licenses.json")
+	content := `{"licenses": {"EUPL-1.2": {"category": "weak-copyleft", "obligations": ["Provide source of modified files"]}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write license catalog: %v", err)
+	}
+
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info := catalog.Licenses["EUPL-1.2"]; info.Category != WeakCopyleft || info.RiskLevel != "medium" {
+		t.Errorf("Unexpected catalog entry: %+v", info)
+	}
+
+	content = `{"licenses": {"BSD-3-Clause": {"category": "permissive", "textConfidence": 0.6}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write license catalog: %v", err)
+	}
+	catalog, err = LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if confidence, ok := catalog.TextConfidence["BSD-3-Clause"]; !ok || confidence != 0.6 {
+		t.Errorf("Expected a BSD-3-Clause text confidence of 0.6, got %v", catalog.TextConfidence)
+	}
+
+	invalid := []string{
+		`{"licenses": {}}`,
+		`{"licenses": {"EUPL-1.2": {}}}`,
+		`{"licenses": {"EUPL-1.2": {"category": "viral"}}}`,
+		`{"licences": {"EUPL-1.2": {"category": "permissive"}}}`,
+		`{"licenses": {"BSD-3-Clause": {"category": "permissive", "textConfidence": 1.5}}}`,
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write license catalog: %v", err)
+		}
+		if _, err := LoadCatalog(path); err == nil {
+			t.Errorf("Expected error for catalog %s", content)
+		}
+	}
+}
+
+func TestAnalyze_CustomCatalog(t *testing.T) {
+	deps := []Dependency{
+		{Name: "blueoak-package", Version: "1.0.0", License: "BlueOak-1.0.0", Confidence: 1.0},
+		{Name: "mit-package", Version: "1.0.0", License: "MIT", Confidence: 1.0},
+	}
+
+	if result := New().Analyze(deps); result.RiskLevel == "low" {
+		t.Fatal("Expected an unrecognized license to raise the risk without a catalog")
+	}
+
+	analyzer := New()
+	analyzer.UseCatalog(&Catalog{
+		Licenses:    map[string]LicenseInfo{"BlueOak-1.0.0": {Name: "BlueOak-1.0.0", Category: Permissive, RiskLevel: "low"}},
+		Obligations: map[string][]string{"BlueOak-1.0.0": {ObligationNoEndorsement}},
+	})
+	result := analyzer.Analyze(deps)
+
+	if result.RiskLevel != "low" {
+		t.Errorf("Expected low risk for a catalogued permissive license, got %s", result.RiskLevel)
+	}
+	if result.LicenseCounts["BlueOak-1.0.0"] != 1 {
+		t.Errorf("Expected BlueOak-1.0.0 to be counted, got %v", result.LicenseCounts)
+	}
+
+	found := false
+	for _, obligation := range result.Obligations {
+		if obligation.Obligation == ObligationNoEndorsement {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected catalog obligations to be aggregated, got %v", result.Obligations)
+	}
+}
+
+func TestAnalyze_ConflictsDirectFirst(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "agpl-package", Version: "1.0.0", License: "AGPL-3.0", Confidence: 1.0, Depth: 3},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0, Depth: 1},
+		{Name: "apache-package", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0, Depth: 2},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := []Conflict{
+		{Message: "GPL-2.0 and AGPL-3.0 licenses are incompatible", DirectPackages: []string{"gpl-package"}, Transitive: true},
+		{Message: "GPL-2.0 and Apache-2.0 licenses are incompatible", DirectPackages: []string{"gpl-package"}, Transitive: true},
+		{Message: "AGPL-3.0 requires source disclosure for network use - ensure compliance", DirectPackages: []string{}, Transitive: true},
+	}
+	if !reflect.DeepEqual(result.StructuredConflicts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result.StructuredConflicts)
+	}
+
+	// The messages are unchanged by the attribution
+	for i, conflict := range expected {
+		if result.Conflicts[i] != conflict.Message {
+			t.Errorf("Expected conflict %q, got %q", conflict.Message, result.Conflicts[i])
+		}
+	}
+}
+
+func TestAnalyze_CompatibilityMatrix(t *testing.T) {
+	tests := []struct {
+		name     string
+		licenses []string
+		expected []string
+	}{
+		{
+			name:     "permissive only",
+			licenses: []string{"MIT", "Apache-2.0", "BSD-3-Clause"},
+			expected: []string{},
+		},
+		{
+			name:     "MPL-2.0 with GPL",
+			licenses: []string{"GPL-3.0", "MPL-2.0"},
+			expected: []string{"MPL-2.0 and GPL-3.0 detected - verify no MPL-2.0 files are marked 'Incompatible With Secondary Licenses'"},
+		},
+		{
+			name:     "AGPL with proprietary",
+			licenses: []string{"UNLICENSED", "AGPL-3.0"},
+			expected: []string{
+				"AGPL-3.0 requires source disclosure for network use - ensure compliance",
+				"AGPL-3.0 and UNLICENSED licenses are incompatible - network use of the combined work requires disclosing the UNLICENSED code",
+			},
+		},
+		{
+			name:     "copyleft with proprietary",
+			licenses: []string{"GPL-2.0", "UNLICENSED", "MIT"},
+			expected: []string{"GPL-2.0 and UNLICENSED licenses are incompatible - copyleft code cannot be combined with proprietary code"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deps []Dependency
+			for i, license := range tt.licenses {
+				// Two packages per license must not duplicate a conflict
+				deps = append(deps,
+					Dependency{Name: fmt.Sprintf("pkg-%d-a", i), Version: "1.0.0", License: license, Confidence: 1.0},
+					Dependency{Name: fmt.Sprintf("pkg-%d-b", i), Version: "1.0.0", License: license, Confidence: 1.0})
+			}
+
+			result := New().Analyze(deps)
+			if !reflect.DeepEqual(result.Conflicts, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result.Conflicts)
+			}
+		})
+	}
+}
+
+func TestAnalyze_CompatibilityMatrixExtended(t *testing.T) {
+	pair := LicensePair{"EPL-2.0", "GPL-2.0"}
+	CompatibilityMatrix[pair] = Incompatibility{Message: "EPL-2.0 and GPL-2.0 licenses are incompatible"}
+	defer delete(CompatibilityMatrix, pair)
+
+	deps := []Dependency{
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0},
+		{Name: "epl-package", Version: "1.0.0", License: "EPL-2.0", Confidence: 1.0},
+	}
+	result := New().Analyze(deps)
+	if !reflect.DeepEqual(result.Conflicts, []string{"EPL-2.0 and GPL-2.0 licenses are incompatible"}) {
+		t.Errorf("Expected the added conflict, got %v", result.Conflicts)
+	}
+}
+
+func TestAnalyze_ProjectLicense(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "apache-package", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0},
+		{Name: "gpl3-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "agpl-package", Version: "1.0.0", License: "AGPL-3.0", Confidence: 1.0},
+		{Name: "mpl-package", Version: "2.0.0", License: "MPL-2.0", Confidence: 1.0},
+	}
+
+	tests := []struct {
+		projectLicense string
+		expected       []string
+	}{
+		{"", []string{}},
+		{"Apache-2.0", []string{"gpl3-package@1.0.0 (GPL-3.0)", "agpl-package@1.0.0 (AGPL-3.0)"}},
+		// The matrix forbids Apache-2.0 code in a GPL-2.0 project; MPL-2.0 is
+		// only a caveat
+		{"GPL-2.0-only", []string{"apache-package@1.0.0 (Apache-2.0)", "gpl3-package@1.0.0 (GPL-3.0)", "agpl-package@1.0.0 (AGPL-3.0)"}},
+		// The project can be distributed under GPL-3.0 instead
+		{"GPL-2.0-or-later", []string{}},
+		{"GPL-3.0", []string{}},
+		{"UNLICENSED", []string{"gpl3-package@1.0.0 (GPL-3.0)", "agpl-package@1.0.0 (AGPL-3.0)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.projectLicense, func(t *testing.T) {
+			analyzer := New()
+			if err := analyzer.SetProjectLicense(tt.projectLicense); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result := analyzer.Analyze(deps)
+
+			if !reflect.DeepEqual(result.IncompatibleWithProject, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result.IncompatibleWithProject)
+			}
+			found := false
+			for _, recommendation := range result.StructuredRecommendations {
+				found = found || recommendation.Category == RecommendationProjectLicense
+			}
+			if found != (len(tt.expected) > 0) {
+				t.Errorf("Expected a project license recommendation: %v, got %v", len(tt.expected) > 0, result.Recommendations)
+			}
+		})
+	}
+}
+
+func TestSetProjectLicense_Unknown(t *testing.T) {
+	if err := New().SetProjectLicense("bogus"); err == nil {
+		t.Error("Expected an error for an unknown project license")
+	}
+	if err := New().SetProjectLicense("LGPL-2.1-or-later"); err != nil {
+		t.Errorf("Expected an or-later license to be accepted, got %v", err)
+	}
+}
+
+func TestAnalyze_BundledCopyleftInPrivateProject(t *testing.T) {
+	analyzer := New()
+	analyzer.SetPrivate(true)
+	deps := []Dependency{
+		{Name: "gpl-lib", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0, Bundled: true},
+		{Name: "gpl-tool", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if !containsString(strings.Join(result.Recommendations, "\n"), "1 GPL/AGPL dependencies are bundled") {
+		t.Errorf("Expected a bundled copyleft recommendation, got %v", result.Recommendations)
+	}
+}
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		canonical  string
+		category   LicenseCategory
+	}{
+		{"MIT", "MIT", Permissive},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0", Permissive},
+		{"GPL-3.0-only OR MIT", "GPL-3.0 OR MIT", Permissive},
+		{"MIT AND GPL-3.0-or-later", "MIT AND GPL-3.0", StrongCopyleft},
+		{"(GPL-2.0-or-later WITH Classpath-exception-2.0)", "GPL-2.0 WITH Classpath-exception-2.0", StrongCopyleft},
+		{"LGPL-2.1+ AND (MIT OR (BSD-3-Clause AND GPL-3.0))", "LGPL-2.1 AND (MIT OR (BSD-3-Clause AND GPL-3.0))", WeakCopyleft},
+		{"Custom-1.0 OR ISC", "Custom-1.0 OR ISC", Permissive},
+		{"MIT AND Custom-1.0", "MIT AND Custom-1.0", Unknown},
+		{"MIT or apache-2.0", "MIT OR Apache-2.0", Permissive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			parsed, err := ParseLicenseExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.String() != tt.canonical {
+				t.Errorf("expected %q, got %q", tt.canonical, parsed.String())
+			}
+			if parsed.Category() != tt.category {
+				t.Errorf("expected category %s, got %s", tt.category, parsed.Category())
+			}
+		})
+	}
+
+	parsed, _ := ParseLicenseExpression("MIT OR (Apache-2.0 AND BSD-2-Clause)")
+	if parsed.Operator != OperatorOR || len(parsed.Licenses) != 2 || parsed.Licenses[1].Operator != OperatorAND {
+		t.Errorf("unexpected structure: %+v", parsed)
+	}
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT)", "Apache License 2.0", "MIT WITH", "(MIT OR ISC) WITH X"} {
+		if _, err := ParseLicenseExpression(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestAnalyze_LicenseExpressions(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "dual", Version: "1.0.0", License: "GPL-3.0 OR MIT", Confidence: 1.0},
+		{Name: "classpath", Version: "1.0.0", License: "(GPL-2.0-or-later WITH Classpath-exception-2.0)", Confidence: 1.0},
+		{Name: "both", Version: "1.0.0", License: "MIT AND LGPL-3.0", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := map[string]int{"MIT": 1, "GPL-2.0": 1, "MIT AND LGPL-3.0": 1}
+	if !reflect.DeepEqual(result.LicenseCounts, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, result.LicenseCounts)
+	}
+	// The WITH exception keeps GPL-2.0 strong copyleft
+	if result.RiskLevel != "high" {
+		t.Errorf("Expected high risk from GPL-2.0 WITH an exception, got %s", result.RiskLevel)
+	}
+
+	found := false
+	for _, obligation := range result.Obligations {
+		if obligation.Obligation == ObligationRelinking {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the LGPL obligations of an AND expression, got %v", result.Obligations)
+	}
+
+	if normalizeLicense("MIT OR GPL-3.0") != "MIT" || Categorize("Apache-2.0 AND GPL-3.0") != StrongCopyleft {
+		t.Error("Expected OR to resolve to the least and AND to the most restrictive license")
+	}
+}
+
+func TestAnalyze_LocalDependenciesExcluded(t *testing.T) {
+	analyzer := New()
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "internal-ui", Version: "1.0.0", License: "UNLICENSED", Confidence: 1.0, Local: true},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	if result.RiskLevel != "low" {
+		t.Errorf("Expected first-party packages to be out of risk, got %s", result.RiskLevel)
+	}
+	if result.LicenseCounts["UNLICENSED"] != 1 {
+		t.Errorf("Expected local packages to still be counted, got %v", result.LicenseCounts)
+	}
+}
+
+func TestAnalyze_SeverityCountsAndRiskScore(t *testing.T) {
+	analyzer := New()
+	analyzer.DenyCategories(Proprietary)
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "lgpl-package", Version: "1.0.0", License: "LGPL-3.0", Confidence: 1.0},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Confidence: 0.0},
+		{Name: "internal", Version: "1.0.0", License: "UNLICENSED", Confidence: 1.0},
+	}
+
+	result := analyzer.Analyze(deps)
+
+	expected := map[string]int{"low": 1, "medium": 2, "high": 2}
+	if !reflect.DeepEqual(result.SeverityCounts, expected) {
+		t.Errorf("Expected severity counts %v, got %v", expected, result.SeverityCounts)
+	}
+	// (2 high + 2 medium / 2) of 5 dependencies
+	if score := result.RiskScore(); score != 60 {
+		t.Errorf("Expected risk score 60, got %v", score)
+	}
+
+	if score := New().Analyze(nil).RiskScore(); score != 0 {
+		t.Errorf("Expected risk score 0 without dependencies, got %v", score)
+	}
+}
+
+func TestAnalyze_RiskPoints(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl2-package", Version: "1.0.0", License: "GPL-2.0", Confidence: 1.0},
+		{Name: "apache-package", Version: "1.0.0", License: "Apache-2.0", Confidence: 1.0},
+		{Name: "lgpl-package", Version: "1.0.0", License: "LGPL-3.0", Confidence: 1.0},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Confidence: 0.0},
+	}
+
+	result := New().Analyze(deps)
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected the GPL-2.0/Apache-2.0 conflict, got %v", result.Conflicts)
+	}
+	// 10 strong copyleft + 3 weak copyleft + 1 unknown + 20 conflict
+	if points := result.RiskPoints(); points != 34 {
+		t.Errorf("Expected 34 risk points, got %d", points)
+	}
+
+	if points := New().Analyze(nil).RiskPoints(); points != 0 {
+		t.Errorf("Expected no risk points without dependencies, got %d", points)
+	}
+}
+
+func TestAnalyze_RiskPoints_Direct(t *testing.T) {
+	deps := []Dependency{
+		{Name: "readline", Version: "2.0.0", License: "GPL-3.0", Confidence: 1.0, Direct: true},
+		{Name: "lgpl-lib", Version: "2.0.1", License: "LGPL-3.0", Confidence: 1.0},
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0, Direct: true},
+	}
+
+	result := New().Analyze(deps)
+	if result.DirectCategoryCounts[StrongCopyleft] != 1 || result.DirectCategoryCounts[WeakCopyleft] != 0 {
+		t.Errorf("Expected only the GPL dependency counted as direct copyleft, got %v", result.DirectCategoryCounts)
+	}
+	// 10 strong copyleft doubled for the direct dependency + 3 weak copyleft
+	if points := result.RiskPoints(); points != 23 {
+		t.Errorf("Expected 23 risk points, got %d", points)
+	}
+}
+
+func TestAnalyze_StructuredRecommendations(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "readline", Version: "2.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "agpl-server", Version: "1.1.0", License: "AGPL-3.0", Confidence: 1.0},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Confidence: 0.0},
+	}
+
+	result := New().Analyze(deps)
+	if len(result.StructuredRecommendations) != len(result.Recommendations) {
+		t.Fatalf("Expected a structured recommendation per recommendation, got %d and %d",
+			len(result.StructuredRecommendations), len(result.Recommendations))
+	}
+	for i, recommendation := range result.StructuredRecommendations {
+		if recommendation.Message != result.Recommendations[i] {
+			t.Errorf("Expected recommendation %d to be %q, got %q", i, result.Recommendations[i], recommendation.Message)
+		}
+	}
+
+	find := func(category string) Recommendation {
+		for _, recommendation := range result.StructuredRecommendations {
+			if recommendation.Category == category {
+				return recommendation
+			}
+		}
+		t.Fatalf("Expected a %s recommendation, got %+v", category, result.StructuredRecommendations)
+		return Recommendation{}
+	}
+
+	gpl := find(RecommendationStrongCopyleft)
+	if gpl.Severity != "high" || !containsString(gpl.Message, "Found 2 GPL/AGPL dependencies") {
+		t.Errorf("Expected the high severity GPL recommendation, got %+v", gpl)
+	}
+	if expected := []string{"agpl-server@1.1.0", "readline@2.0.0"}; !reflect.DeepEqual(gpl.AffectedPackages, expected) {
+		t.Errorf("Expected the GPL recommendation to affect %v, got %v", expected, gpl.AffectedPackages)
+	}
+	if conflict := find(RecommendationConflict); !reflect.DeepEqual(conflict.AffectedPackages, []string{"agpl-server@1.1.0"}) {
+		t.Errorf("Expected the AGPL conflict to affect agpl-server@1.1.0, got %v", conflict.AffectedPackages)
+	}
+	if unknown := find(RecommendationUnknown); !reflect.DeepEqual(unknown.AffectedPackages, []string{"mystery@0.1.0"}) {
+		t.Errorf("Expected the unknown license recommendation to affect mystery@0.1.0, got %v", unknown.AffectedPackages)
+	}
+
+	allClear := New().Analyze(deps[:1]).StructuredRecommendations
+	if len(allClear) != 1 || allClear[0].Category != RecommendationAllClear || len(allClear[0].AffectedPackages) != 0 {
+		t.Errorf("Expected a single all-clear recommendation, got %+v", allClear)
+	}
+}
+
+func TestAnalyze_SkipRecommendations(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "readline", Version: "2.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+
+	a := New()
+	a.SetSkipRecommendations(true)
+	result := a.Analyze(deps)
+	if len(result.Recommendations) != 0 || len(result.StructuredRecommendations) != 0 {
+		t.Errorf("Expected no recommendations, got %v", result.Recommendations)
+	}
+	if result.RiskLevel != "high" || result.LicenseCounts["GPL-3.0"] != 1 {
+		t.Errorf("Expected the risk and counts to remain, got %s and %v", result.RiskLevel, result.LicenseCounts)
+	}
+}
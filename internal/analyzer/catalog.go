@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Catalog is license metadata loaded from an external file, merged over the
+// embedded KnownLicenses and LicenseObligations
+type Catalog struct {
+	Licenses    map[string]LicenseInfo
+	Obligations map[string][]string
+	// TextConfidence caps the confidence of license text matches, for
+	// licenses whose texts are easily confused (e.g. the BSD variants)
+	TextConfidence map[string]float64
+}
+
+// catalogFile is the on-disk format of a license catalog:
+//
+//	{"licenses": {"EUPL-1.2": {"category": "weak-copyleft", "obligations": ["..."], "textConfidence": 0.7}}}
+type catalogFile struct {
+	Licenses map[string]struct {
+		Category       string   `json:"category"`
+		Obligations    []string `json:"obligations"`
+		TextConfidence *float64 `json:"textConfidence"`
+	} `json:"licenses"`
+}
+
+// LoadCatalog reads and validates a JSON license catalog. Every entry needs a
+// known category; its risk level follows from DefaultSeverities.
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license catalog: %w", err)
+	}
+
+	var file catalogFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse license catalog: %w", err)
+	}
+	if len(file.Licenses) == 0 {
+		return nil, fmt.Errorf("license catalog %s defines no licenses", path)
+	}
+
+	catalog := &Catalog{
+		Licenses:    make(map[string]LicenseInfo, len(file.Licenses)),
+		Obligations: make(map[string][]string),
+
+		TextConfidence: make(map[string]float64),
+	}
+	for id, entry := range file.Licenses {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, fmt.Errorf("license catalog contains an empty license id")
+		}
+		if entry.Category == "" {
+			return nil, fmt.Errorf("license %s in catalog has no category", id)
+		}
+		category, err := ParseCategory(entry.Category)
+		if err != nil {
+			return nil, fmt.Errorf("license %s in catalog: %w", id, err)
+		}
+
+		riskLevel, ok := DefaultSeverities[category]
+		if !ok {
+			riskLevel = "high"
+		}
+		catalog.Licenses[id] = LicenseInfo{Name: id, Category: category, RiskLevel: riskLevel}
+		if len(entry.Obligations) > 0 {
+			catalog.Obligations[id] = entry.Obligations
+		}
+		if entry.TextConfidence != nil {
+			if *entry.TextConfidence < 0 || *entry.TextConfidence > 1 {
+				return nil, fmt.Errorf("license %s in catalog has textConfidence %v outside 0-1", id, *entry.TextConfidence)
+			}
+			catalog.TextConfidence[id] = *entry.TextConfidence
+		}
+	}
+
+	return catalog, nil
+}
+
+// UseCatalog merges a catalog over the embedded license data. Catalog ids
+// are matched exactly, before the built-in normalization.
+func (a *Analyzer) UseCatalog(catalog *Catalog) {
+	if a.catalog == nil {
+		a.catalog = &Catalog{Licenses: make(map[string]LicenseInfo), Obligations: make(map[string][]string)}
+	}
+	for id, info := range catalog.Licenses {
+		a.catalog.Licenses[id] = info
+	}
+	for id, obligations := range catalog.Obligations {
+		a.catalog.Obligations[id] = obligations
+	}
+}
+
+// licenseInfo looks a normalized license up in the catalog, then in
+// KnownLicenses, deriving AND expressions from their parts
+func (a *Analyzer) licenseInfo(license string) (LicenseInfo, bool) {
+	if a.catalog != nil {
+		if info, ok := a.catalog.Licenses[license]; ok {
+			return info, true
+		}
+	}
+	if info, ok := KnownLicenses[license]; ok {
+		return info, true
+	}
+	return expressionInfo(license, a.licenseInfo)
+}
+
+// obligations returns the obligations of a license, preferring the catalog.
+// An AND expression triggers the obligations of all of its parts.
+func (a *Analyzer) obligations(license string) []string {
+	if a.catalog != nil {
+		if obligations, ok := a.catalog.Obligations[license]; ok {
+			return obligations
+		}
+	}
+	if obligations, ok := LicenseObligations[license]; ok {
+		return obligations
+	}
+
+	parsed, err := ParseLicenseExpression(license)
+	if err != nil || parsed.Operator != OperatorAND {
+		return nil
+	}
+	var obligations []string
+	seen := make(map[string]bool)
+	for _, part := range parsed.Licenses {
+		for _, obligation := range a.obligations(part.String()) {
+			if !seen[obligation] {
+				seen[obligation] = true
+				obligations = append(obligations, obligation)
+			}
+		}
+	}
+	return obligations
+}
@@ -0,0 +1,286 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operators of compound SPDX license expressions
+const (
+	OperatorOR  = "OR"
+	OperatorAND = "AND"
+)
+
+// restrictiveness orders categories for choosing between expression
+// branches. Unknown ranks last: an OR prefers any known branch, while an AND
+// with an unknown part needs review.
+var restrictiveness = map[LicenseCategory]int{
+	Permissive:     0,
+	WeakCopyleft:   1,
+	StrongCopyleft: 2,
+	Proprietary:    3,
+	Unknown:        4,
+}
+
+// ParsedLicense is a parsed SPDX license expression. A single license has a
+// License (and optionally an Exception); a compound expression combines its
+// Licenses with Operator. Suffix keeps the -or-later, -only or + dropped
+// from License.
+type ParsedLicense struct {
+	Operator  string           `json:"operator,omitempty"`
+	License   string           `json:"license,omitempty"`
+	Suffix    string           `json:"suffix,omitempty"`
+	Exception string           `json:"exception,omitempty"`
+	Licenses  []*ParsedLicense `json:"licenses,omitempty"`
+}
+
+// ParseLicenseExpression parses an SPDX expression such as
+// "MIT OR (GPL-2.0-or-later WITH Classpath-exception-2.0)". WITH binds
+// tighter than AND, which binds tighter than OR. License ids are normalized
+// and the -or-later, -only and + suffixes dropped, as they do not change the
+// category; Suffix keeps them for SPDX output.
+func ParseLicenseExpression(expression string) (*ParsedLicense, error) {
+	p := &expressionParser{tokens: tokenizeExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], expression)
+	}
+	return parsed, nil
+}
+
+// Compound reports whether the expression combines licenses or carries an
+// exception, i.e. is more than a single license id
+func (p *ParsedLicense) Compound() bool {
+	return p.Operator != "" || p.Exception != ""
+}
+
+// String returns the canonical form of the expression
+func (p *ParsedLicense) String() string {
+	return p.format(false)
+}
+
+// SPDX returns the canonical form of the expression with the -or-later, -only
+// and + suffixes kept, as written to SPDX documents
+func (p *ParsedLicense) SPDX() string {
+	return p.format(true)
+}
+
+func (p *ParsedLicense) format(suffix bool) string {
+	if p.Operator == "" {
+		license := p.License
+		if suffix {
+			license += p.Suffix
+		}
+		if p.Exception != "" {
+			return license + " WITH " + p.Exception
+		}
+		return license
+	}
+
+	parts := make([]string, len(p.Licenses))
+	for i, operand := range p.Licenses {
+		parts[i] = operand.format(suffix)
+		if operand.Operator != "" && operand.Operator != p.Operator {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+p.Operator+" ")
+}
+
+// Category returns the category of the expression using the built-in
+// license data: the least restrictive branch of an OR, the most restrictive
+// part of an AND
+func (p *ParsedLicense) Category() LicenseCategory {
+	_, category := p.resolve(builtinLicenseInfo)
+	return category
+}
+
+// resolve returns the license the expression effectively puts the project
+// under and its category. An OR resolves to its least restrictive branch; an
+// AND keeps all of its (resolved) parts. Exceptions do not change the base
+// license.
+func (p *ParsedLicense) resolve(lookup func(string) (LicenseInfo, bool)) (string, LicenseCategory) {
+	switch p.Operator {
+	case OperatorOR:
+		best, bestCategory := "", Unknown
+		for i, operand := range p.Licenses {
+			license, category := operand.resolve(lookup)
+			if i == 0 || restrictiveness[category] < restrictiveness[bestCategory] {
+				best, bestCategory = license, category
+			}
+		}
+		return best, bestCategory
+	case OperatorAND:
+		var parts []string
+		seen := make(map[string]bool)
+		worst := Permissive
+		for _, operand := range p.Licenses {
+			license, category := operand.resolve(lookup)
+			if !seen[license] {
+				seen[license] = true
+				parts = append(parts, license)
+			}
+			if restrictiveness[category] > restrictiveness[worst] {
+				worst = category
+			}
+		}
+		if len(parts) == 1 {
+			return parts[0], worst
+		}
+		return strings.Join(parts, " AND "), worst
+	default:
+		if info, known := lookup(p.License); known {
+			return p.License, info.Category
+		}
+		return p.License, Unknown
+	}
+}
+
+// builtinLicenseInfo looks a license or AND expression up in KnownLicenses
+func builtinLicenseInfo(license string) (LicenseInfo, bool) {
+	if info, known := KnownLicenses[license]; known {
+		return info, true
+	}
+	return expressionInfo(license, builtinLicenseInfo)
+}
+
+// expressionInfo derives the metadata of an AND expression (as resolved
+// licenses are keyed) from its parts
+func expressionInfo(license string, lookup func(string) (LicenseInfo, bool)) (LicenseInfo, bool) {
+	if !strings.Contains(license, " ") {
+		return LicenseInfo{}, false
+	}
+	parsed, err := ParseLicenseExpression(license)
+	if err != nil || parsed.Operator != OperatorAND {
+		return LicenseInfo{}, false
+	}
+
+	_, category := parsed.resolve(lookup)
+	riskLevel, ok := DefaultSeverities[category]
+	if !ok {
+		riskLevel = "high"
+	}
+	return LicenseInfo{Name: license, Category: category, RiskLevel: riskLevel}, true
+}
+
+// tokenizeExpression splits an expression into parentheses and words
+func tokenizeExpression(expression string) []string {
+	spaced := strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression)
+	return strings.Fields(spaced)
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *expressionParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *expressionParser) parseOr() (*ParsedLicense, error) {
+	return p.parseBinary(OperatorOR, p.parseAnd)
+}
+
+func (p *expressionParser) parseAnd() (*ParsedLicense, error) {
+	return p.parseBinary(OperatorAND, p.parseWith)
+}
+
+// parseBinary parses operands joined by operator, flattening chains such
+// as "A OR B OR C" into one node
+func (p *expressionParser) parseBinary(operator string, operand func() (*ParsedLicense, error)) (*ParsedLicense, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekOperator(operator) {
+		return first, nil
+	}
+
+	node := &ParsedLicense{Operator: operator}
+	add := func(child *ParsedLicense) {
+		if child.Operator == operator {
+			node.Licenses = append(node.Licenses, child.Licenses...)
+		} else {
+			node.Licenses = append(node.Licenses, child)
+		}
+	}
+	add(first)
+	for p.peekOperator(operator) {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		add(next)
+	}
+	return node, nil
+}
+
+func (p *expressionParser) parseWith() (*ParsedLicense, error) {
+	license, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekOperator("WITH") {
+		return license, nil
+	}
+	if license.Operator != "" {
+		return nil, fmt.Errorf("WITH must follow a single license")
+	}
+
+	p.pos++
+	if p.pos >= len(p.tokens) || isExpressionKeyword(p.tokens[p.pos]) {
+		return nil, fmt.Errorf("missing exception after WITH")
+	}
+	license.Exception = p.tokens[p.pos]
+	p.pos++
+	return license, nil
+}
+
+func (p *expressionParser) parsePrimary() (*ParsedLicense, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of license expression")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case token == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis in license expression")
+		}
+		p.pos++
+		return inner, nil
+	case token == ")" || isExpressionKeyword(token):
+		return nil, fmt.Errorf("unexpected %q in license expression", token)
+	default:
+		license := stripVersionSuffix(token)
+		return &ParsedLicense{License: normalizeLicenseID(license), Suffix: token[len(license):]}, nil
+	}
+}
+
+func isExpressionKeyword(token string) bool {
+	return strings.EqualFold(token, OperatorOR) || strings.EqualFold(token, OperatorAND) || strings.EqualFold(token, "WITH")
+}
+
+// stripVersionSuffix drops the "-or-later", "-only" and "+" suffixes of a
+// license id, e.g. GPL-2.0-or-later -> GPL-2.0
+func stripVersionSuffix(license string) string {
+	for _, suffix := range []string{"-or-later", "-only", "+"} {
+		if trimmed, found := strings.CutSuffix(license, suffix); found {
+			return trimmed
+		}
+	}
+	return license
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Footprint is what adopting a package means license-wise: the licenses of
+// the package and everything it transitively depends on
+type Footprint struct {
+	Package string `json:"package"`
+	// Licenses maps each license of the subtree to its packages (name@version)
+	Licenses map[string][]string `json:"licenses"`
+	// Category is the most restrictive category in the subtree
+	Category string `json:"category"`
+}
+
+// LicenseFootprint walks the dependency graph from the named package and
+// collects the licenses of its whole subtree. All installed versions of a
+// package are included, as the graph is keyed by name.
+func (a *Analyzer) LicenseFootprint(dependencies []Dependency, name string) (*Footprint, error) {
+	byName := make(map[string][]Dependency)
+	for _, dep := range dependencies {
+		byName[dep.Name] = append(byName[dep.Name], dep)
+	}
+	if _, ok := byName[name]; !ok {
+		return nil, fmt.Errorf("package %s is not in the dependency tree", name)
+	}
+
+	footprint := &Footprint{Package: name, Licenses: make(map[string][]string)}
+	worst := Permissive
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range byName[current] {
+			license := a.normalize(dep.License)
+			footprint.Licenses[license] = append(footprint.Licenses[license], dep.Name+"@"+dep.Version)
+
+			category := Unknown
+			if info, known := a.licenseInfo(license); known {
+				category = info.Category
+			}
+			if restrictiveness[category] > restrictiveness[worst] {
+				worst = category
+			}
+
+			for _, child := range dep.Dependencies {
+				if !seen[child] {
+					seen[child] = true
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+
+	for _, packages := range footprint.Licenses {
+		sort.Strings(packages)
+	}
+	footprint.Category = worst.String()
+	return footprint, nil
+}
@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLicenseFootprint(t *testing.T) {
+	deps := []Dependency{
+		{Name: "app-framework", Version: "2.0.0", License: "MIT", Dependencies: []string{"router", "templating"}},
+		{Name: "router", Version: "1.0.0", License: "ISC", Dependencies: []string{"path-utils"}},
+		{Name: "templating", Version: "3.1.0", License: "LGPL-3.0", Dependencies: []string{"path-utils", "app-framework"}},
+		{Name: "path-utils", Version: "0.4.0", License: "MIT"},
+		{Name: "path-utils", Version: "0.5.0", License: "Apache-2.0"},
+		// Not reachable from app-framework
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Dependencies: []string{"router"}},
+	}
+
+	footprint, err := New().LicenseFootprint(deps, "app-framework")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"MIT":        {"app-framework@2.0.0", "path-utils@0.4.0"},
+		"ISC":        {"router@1.0.0"},
+		"LGPL-3.0":   {"templating@3.1.0"},
+		"Apache-2.0": {"path-utils@0.5.0"},
+	}
+	if !reflect.DeepEqual(footprint.Licenses, expected) {
+		t.Errorf("expected %v, got %v", expected, footprint.Licenses)
+	}
+	if footprint.Category != WeakCopyleft.String() {
+		t.Errorf("expected the subtree to be weak copyleft at worst, got %s", footprint.Category)
+	}
+
+	if _, err := New().LicenseFootprint(deps, "missing"); err == nil {
+		t.Error("expected an error for a package outside the tree")
+	}
+}
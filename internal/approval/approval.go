@@ -0,0 +1,157 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Dependency is a package and its detected license
+type Dependency struct {
+	Name    string
+	Version string
+	License string
+	// TextHash is the SHA-256 of the license file, if there is one
+	TextHash string
+}
+
+// Snapshot is the approved set of packages and licenses. It is the source of
+// truth for change control: anything not covered by it requires re-approval.
+type Snapshot struct {
+	// Packages maps name@version to the approved license
+	Packages map[string]string `json:"packages"`
+}
+
+// Violation describes a license change that has not been approved
+type Violation struct {
+	Package string `json:"package"`
+	License string `json:"license"`
+	Reason  string `json:"reason"`
+}
+
+// NewSnapshot creates a snapshot approving the given dependencies
+func NewSnapshot(dependencies []Dependency) *Snapshot {
+	snapshot := &Snapshot{Packages: make(map[string]string, len(dependencies))}
+	for _, dep := range dependencies {
+		snapshot.Packages[dep.Name+"@"+dep.Version] = dep.License
+	}
+	return snapshot
+}
+
+// Load reads an approved snapshot from disk
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approved snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse approved snapshot: %w", err)
+	}
+	if snapshot.Packages == nil {
+		snapshot.Packages = make(map[string]string)
+	}
+
+	return &snapshot, nil
+}
+
+// Save writes the snapshot to disk
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approved snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write approved snapshot: %w", err)
+	}
+	return nil
+}
+
+// Check returns the violations introduced by the dependencies relative to the
+// snapshot: licenses never approved before, and packages whose license differs
+// from what was approved for them. New packages under an approved license pass.
+func (s *Snapshot) Check(dependencies []Dependency) []Violation {
+	approvedLicenses := make(map[string]bool)
+	licensesByName := make(map[string]map[string]bool)
+	for key, license := range s.Packages {
+		approvedLicenses[license] = true
+		name := key
+		if at := strings.LastIndex(key, "@"); at > 0 {
+			name = key[:at]
+		}
+		if licensesByName[name] == nil {
+			licensesByName[name] = make(map[string]bool)
+		}
+		licensesByName[name][license] = true
+	}
+
+	violations := []Violation{}
+	for _, dep := range dependencies {
+		key := dep.Name + "@" + dep.Version
+
+		if license, exists := s.Packages[key]; exists {
+			if license != dep.License {
+				violations = append(violations, Violation{
+					Package: key,
+					License: dep.License,
+					Reason:  fmt.Sprintf("license changed from %s", license),
+				})
+			}
+			continue
+		}
+
+		if previous, exists := licensesByName[dep.Name]; exists && !previous[dep.License] {
+			violations = append(violations, Violation{
+				Package: key,
+				License: dep.License,
+				Reason:  fmt.Sprintf("license differs from approved versions (%s)", joinKeys(previous)),
+			})
+			continue
+		}
+
+		if !approvedLicenses[dep.License] {
+			violations = append(violations, Violation{
+				Package: key,
+				License: dep.License,
+				Reason:  "license not previously approved",
+			})
+		}
+	}
+
+	return violations
+}
+
+func joinKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// LoadAllowList reads pre-approved packages from a file listing one
+// name@version per line. Blank lines and lines starting with # are ignored.
+func LoadAllowList(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allow file: %w", err)
+	}
+
+	allowed := make(map[string]bool)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.LastIndex(line, "@") <= 0 {
+			return nil, fmt.Errorf("invalid allow file entry on line %d: %q is not name@version", i+1, line)
+		}
+		allowed[line] = true
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,97 @@
+package approval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck_Unchanged(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "@types/node", Version: "18.0.0", License: "MIT"},
+		{Name: "tslib", Version: "2.6.0", License: "0BSD"},
+	}
+
+	path := filepath.Join(t.TempDir(), "approved.json")
+	if err := NewSnapshot(deps).Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snapshot, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A version bump under an already-approved license still passes
+	deps[0].Version = "18.3.0"
+
+	if violations := snapshot.Check(deps); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestCheck_NewLicense(t *testing.T) {
+	snapshot := NewSnapshot([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT"},
+	})
+
+	violations := snapshot.Check([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT"},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0"},
+	})
+
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %v", violations)
+	}
+
+	if violations[0].Package != "gpl-package@1.0.0" || violations[0].License != "GPL-3.0" {
+		t.Errorf("Unexpected violation: %+v", violations[0])
+	}
+}
+
+func TestCheck_LicenseChange(t *testing.T) {
+	snapshot := NewSnapshot([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "elastic", Version: "7.10.0", License: "Apache-2.0"},
+	})
+
+	violations := snapshot.Check([]Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "elastic", Version: "7.11.0", License: "MIT"},
+	})
+
+	if len(violations) != 1 || violations[0].Package != "elastic@7.11.0" {
+		t.Errorf("Expected a license change violation for elastic, got %v", violations)
+	}
+}
+
+func TestLoadAllowList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow.txt")
+	content := "# reviewed by legal\n@scope/gpl-package@2.0.0\n\ngpl-package@1.0.0\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	allowed, err := LoadAllowList(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(allowed) != 2 || !allowed["gpl-package@1.0.0"] || !allowed["@scope/gpl-package@2.0.0"] {
+		t.Errorf("Expected both entries to be allowed, got %v", allowed)
+	}
+}
+
+func TestLoadAllowList_InvalidEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allow.txt")
+	if err := os.WriteFile(path, []byte("gpl-package\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := LoadAllowList(path); err == nil {
+		t.Error("Expected an error for an entry without a version")
+	}
+}
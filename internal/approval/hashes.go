@@ -0,0 +1,78 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReviewedHashes records which license texts were reviewed. A license text
+// edited in place keeps the package version and license id, so only its
+// hash reveals the change.
+type ReviewedHashes struct {
+	// Packages maps name@version to the SHA-256 of its reviewed license file
+	Packages map[string]string `json:"packages"`
+}
+
+// NewReviewedHashes marks the license texts of the given dependencies as
+// reviewed. Dependencies without a license file hash are left out.
+func NewReviewedHashes(dependencies []Dependency) *ReviewedHashes {
+	reviewed := &ReviewedHashes{Packages: make(map[string]string, len(dependencies))}
+	for _, dep := range dependencies {
+		if dep.TextHash != "" {
+			reviewed.Packages[dep.Name+"@"+dep.Version] = dep.TextHash
+		}
+	}
+	return reviewed
+}
+
+// LoadReviewedHashes reads a reviewed hashes file from disk
+func LoadReviewedHashes(path string) (*ReviewedHashes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reviewed hashes: %w", err)
+	}
+
+	var reviewed ReviewedHashes
+	if err := json.Unmarshal(data, &reviewed); err != nil {
+		return nil, fmt.Errorf("failed to parse reviewed hashes: %w", err)
+	}
+	if reviewed.Packages == nil {
+		reviewed.Packages = make(map[string]string)
+	}
+
+	return &reviewed, nil
+}
+
+// Save writes the reviewed hashes to disk
+func (r *ReviewedHashes) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode reviewed hashes: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write reviewed hashes: %w", err)
+	}
+	return nil
+}
+
+// Check returns the packages whose license text no longer matches the
+// reviewed one. Packages without a reviewed hash are not reported; they are
+// covered by the next review.
+func (r *ReviewedHashes) Check(dependencies []Dependency) []Violation {
+	violations := []Violation{}
+	for _, dep := range dependencies {
+		key := dep.Name + "@" + dep.Version
+		reviewed, exists := r.Packages[key]
+		if !exists || dep.TextHash == reviewed {
+			continue
+		}
+
+		reason := "license text changed since review"
+		if dep.TextHash == "" {
+			reason = "reviewed license file is gone"
+		}
+		violations = append(violations, Violation{Package: key, License: dep.License, Reason: reason})
+	}
+	return violations
+}
@@ -0,0 +1,49 @@
+package approval
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReviewedHashes_Check(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", TextHash: "aaaa"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT", TextHash: "bbbb"},
+		{Name: "no-license-file", Version: "1.0.0", License: "ISC"},
+	}
+
+	path := filepath.Join(t.TempDir(), "reviewed-hashes.json")
+	if err := NewReviewedHashes(deps).Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reviewed, err := LoadReviewedHashes(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reviewed.Packages) != 2 {
+		t.Errorf("expected only packages with a license file to be recorded, got %v", reviewed.Packages)
+	}
+
+	if violations := reviewed.Check(deps); len(violations) != 0 {
+		t.Errorf("expected no violations for the reviewed texts, got %v", violations)
+	}
+
+	// The license text of lodash was edited in place: same version, same id
+	deps[1].TextHash = "cccc"
+	// New packages are covered by the next review
+	deps = append(deps, Dependency{Name: "left-pad", Version: "1.3.0", License: "WTFPL", TextHash: "dddd"})
+
+	violations := reviewed.Check(deps)
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got %v", violations)
+	}
+	if violations[0].Package != "lodash@4.17.21" || violations[0].Reason != "license text changed since review" {
+		t.Errorf("unexpected violation: %+v", violations[0])
+	}
+
+	// A removed license file also needs a look
+	deps[0].TextHash = ""
+	if violations := reviewed.Check(deps); len(violations) != 2 || violations[0].Reason != "reviewed license file is gone" {
+		t.Errorf("expected a violation for the removed react license file, got %v", violations)
+	}
+}
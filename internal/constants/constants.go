@@ -4,7 +4,22 @@ package constants
 const (
 	NodeModulesDir  = "node_modules"
 	PnpmStoreDir    = ".pnpm"
+	YarnCacheDir    = ".yarn/cache"
 	PackageJSONFile = "package.json"
+	BowerJSONFile   = "bower.json"
+	BowerRCFile     = ".bowerrc"
+	BowerDir        = "bower_components"
+	DebianDocDir    = "usr/share/doc"
+	RPMLicenseDir   = "usr/share/licenses"
+	DpkgStatusFile  = "var/lib/dpkg/status"
+	APKDatabaseFile = "lib/apk/db/installed"
+	CopyrightFile   = "copyright"
+	GoModFile       = "go.mod"
+	CargoTomlFile   = "Cargo.toml"
+	CargoVendorDir  = "vendor"
+	// Python project manifests next to the lock files
+	PyprojectTOMLFile = "pyproject.toml"
+	PipfileFile       = "Pipfile"
 )
 
 // License-related constants
@@ -12,6 +27,22 @@ const (
 	UnknownLicense        = "Unknown"
 	LicenseFileSource     = "LICENSE file"
 	PackageJSONSource     = "package.json"
+	MalformedSource       = "package.json (malformed)"
+	BowerJSONSource       = "bower.json"
+	AltManifestSource     = "alternate manifest"
+	LockFileSource        = "lock file"
+	RegistrySource        = "registry"
+	RepositorySource      = "repository LICENSE"
+	PythonMetadataSource  = "METADATA"
+	DebianCopyrightSource = "debian/copyright"
+	RPMLicenseSource      = "RPM %license"
+	APKDatabaseSource     = "apk database"
+	BannerSource          = "license banner"
+	SPDXHeaderSource      = "SPDX header"
+	SBOMSource            = "SBOM"
+	GoModSource           = "go.mod"
+	CargoTomlSource       = "Cargo.toml"
+	PkgGoDevSource        = "pkg.go.dev"
 	NotFoundSource        = "not found"
 	DetectionFailedSource = "detection failed"
 )
@@ -19,10 +50,29 @@ const (
 // Lock file names
 const (
 	PackageLockJSON = "package-lock.json"
+	ShrinkwrapJSON  = "npm-shrinkwrap.json"
 	YarnLock        = "yarn.lock"
 	PnpmLockYAML    = "pnpm-lock.yaml"
+	CargoLock       = "Cargo.lock"
+	PoetryLock      = "poetry.lock"
+	PipfileLock     = "Pipfile.lock"
+	RequirementsTxt = "requirements.txt"
 )
 
+// PnpmWorkspaceYAML lists the member packages of a pnpm workspace
+const PnpmWorkspaceYAML = "pnpm-workspace.yaml"
+
+// PnpDataJSON is the Yarn Plug'n'Play dependency map, written instead of
+// inlining it into .pnp.cjs when pnpEnableInlining is false
+const PnpDataJSON = ".pnp.data.json"
+
+// AlternateManifestFiles are manifest names some packages ship instead of
+// package.json
+var AlternateManifestFiles = []string{
+	"package.json5",
+	".package.json",
+}
+
 // LicenseFileVariants contains all possible LICENSE file name variations
 var LicenseFileVariants = []string{
 	"LICENSE",
@@ -31,11 +81,25 @@ var LicenseFileVariants = []string{
 	"LICENCE",
 	"LICENCE.txt",
 	"LICENCE.md",
+	// Lower-case names are common on npm and need their own entries on
+	// case-sensitive file systems
+	"license",
+	"license.txt",
+	"license.md",
+	"COPYING",
+	"COPYING.txt",
+	"COPYING.md",
 }
 
 // Package manager names
 const (
-	PackageManagerNPM  = "npm"
-	PackageManagerYarn = "yarn"
-	PackageManagerPnpm = "pnpm"
+	PackageManagerNPM    = "npm"
+	PackageManagerYarn   = "yarn"
+	PackageManagerPnpm   = "pnpm"
+	PackageManagerBower  = "bower"
+	PackageManagerCargo  = "cargo"
+	PackageManagerGo     = "go"
+	PackageManagerPoetry = "poetry"
+	PackageManagerPipenv = "pipenv"
+	PackageManagerPip    = "pip"
 )
@@ -0,0 +1,207 @@
+package detector
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// Cache stores detection results across scans; implementations must be safe
+// for concurrent use
+type Cache interface {
+	Get(key string) (*LicenseInfo, bool)
+	Set(key string, info *LicenseInfo)
+}
+
+// MemoryCache is an in-memory Cache, e.g. for the lifetime of watch mode
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]LicenseInfo
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]LicenseInfo)}
+}
+
+func (c *MemoryCache) Get(key string) (*LicenseInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	info, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+func (c *MemoryCache) Set(key string, info *LicenseInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = *info
+}
+
+// NewWithCache creates a detector reusing the results cached for packages
+// whose files are unchanged
+func NewWithCache(fs FileSystem, cache Cache) *Detector {
+	d := NewWithFileSystem(fs)
+	d.SetCache(cache)
+	return d
+}
+
+// SetCache makes detection reuse the results cached for unchanged packages,
+// e.g. in a cache shared by several scans; nil disables caching
+func (d *Detector) SetCache(cache Cache) {
+	d.cache = cache
+}
+
+// CacheHits returns how many detections were answered from the cache
+func (d *Detector) CacheHits() int64 {
+	return d.cacheHits.Load()
+}
+
+// cachedDetectLicense answers from the cache while the package is unchanged
+func (d *Detector) cachedDetectLicense(packagePath string) (*LicenseInfo, error) {
+	key := d.cacheKey(packagePath)
+	if info, ok := d.cache.Get(key); ok {
+		d.cacheHits.Add(1)
+		return info, nil
+	}
+	info, err := d.detectLicense(packagePath)
+	if err == nil {
+		d.cache.Set(key, info)
+	}
+	return info, err
+}
+
+// cacheKey identifies the package path, the license size limit and a hash
+// of the contents detection reads: the manifests, the license files and the
+// head of the main entry file. Contents rather than modification times are
+// hashed, as a file edited in place keeps its size and may keep its mtime.
+// Reading them is cheap next to matching the license texts.
+func (d *Detector) cacheKey(packagePath string) string {
+	contents := sha256.New()
+	fmt.Fprintf(contents, "%d", d.maxLicenseSize)
+	paths := []string{
+		d.fs.Join(packagePath, constants.PackageJSONFile),
+		d.fs.Join(packagePath, constants.BowerJSONFile),
+		d.fs.Join(packagePath, constants.CargoTomlFile),
+	}
+	for _, name := range d.alternateManifests {
+		paths = append(paths, d.fs.Join(packagePath, name))
+	}
+	for _, path := range append(paths, d.licenseFiles(packagePath)...) {
+		d.hashFile(contents, path, -1)
+	}
+	// The SPDX header and banner are read from the main entry file, which
+	// only package.json can move away from index.js
+	entry := "index.js"
+	if _, err := d.fs.Stat(paths[0]); err == nil {
+		entry = d.mainEntry(packagePath)
+	}
+	d.hashFile(contents, d.fs.Join(packagePath, entry), bannerScanLimit)
+	return fmt.Sprintf("%s\x00%x", packagePath, contents.Sum(nil))
+}
+
+// hashFile adds the path and contents of a file, or only its first limit
+// bytes when limit is positive, to h; missing files add nothing
+func (d *Detector) hashFile(h hash.Hash, path string, limit int64) {
+	file, err := d.fs.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	var reader io.Reader = file
+	if limit > 0 {
+		reader = io.LimitReader(file, limit)
+	}
+	fmt.Fprintf(h, "\x00%s\x00", path)
+	n, _ := io.Copy(h, reader)
+	fmt.Fprintf(h, "\x00%d", n)
+}
+
+// DefaultLRUCapacity is how many detections an LRUCache holds by default
+const DefaultLRUCapacity = 10000
+
+// CacheStats are the counters of an LRUCache, for monitoring its hit rate
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Size      int   `json:"size"`
+}
+
+// LRUCache is a size-bounded Cache evicting the least recently used
+// detection, meant to be shared by long-running processes scanning
+// repeatedly
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	stats    CacheStats
+}
+
+type lruEntry struct {
+	key  string
+	info LicenseInfo
+}
+
+// NewLRUCache creates an LRUCache holding up to capacity detections; zero
+// or less uses DefaultLRUCapacity
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultLRUCapacity
+	}
+	return &LRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) (*LicenseInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	info := element.Value.(*lruEntry).info
+	return &info, true
+}
+
+func (c *LRUCache) Set(key string, info *LicenseInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).info = *info
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, info: *info})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *LRUCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
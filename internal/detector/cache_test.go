@@ -0,0 +1,149 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDetector_DetectLicense_Cache(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddDir("pkg")
+	mit := "MIT License\n\nPermission is hereby granted, free of charge"
+	fs.AddFile("pkg/LICENSE", mit)
+	cache := NewMemoryCache()
+
+	first, err := NewWithCache(fs, cache).DetectLicense("pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new detector sharing the cache skips analyzing the unchanged package
+	d := NewWithCache(fs, cache)
+	second, err := d.DetectLicense("pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.CacheHits() != 1 {
+		t.Errorf("expected a cache hit, got %d hits", d.CacheHits())
+	}
+	if *second != *first {
+		t.Errorf("expected the cached %+v, got %+v", first, second)
+	}
+
+	// An edit in place keeping the size and modification time is still a miss
+	isc := "ISC License\n\nPermission to use, copy, modify"
+	isc += strings.Repeat(" ", len(mit)-len(isc))
+	fs.AddFile("pkg/LICENSE", isc)
+	third, err := d.DetectLicense("pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.CacheHits() != 1 || third.License != "ISC" {
+		t.Errorf("expected a fresh ISC detection, got %s with %d hits", third.License, d.CacheHits())
+	}
+}
+
+func TestDetector_DetectLicense_CacheEntryFile(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddDir("pkg")
+	fs.AddFile("pkg/package.json", `{"main": "lib/main.js"}`)
+	fs.AddFile("pkg/lib/main.js", "// SPDX-License-Identifier: MIT\n")
+	d := NewWithCache(fs, NewMemoryCache())
+
+	if info, err := d.DetectLicense("pkg"); err != nil || info.License != "MIT" {
+		t.Fatalf("expected MIT from the SPDX header, got %+v (%v)", info, err)
+	}
+
+	// The SPDX header is read from the main entry file, so changing it is a miss
+	fs.AddFile("pkg/lib/main.js", "// SPDX-License-Identifier: Apache-2.0\n")
+	info, err := d.DetectLicense("pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.CacheHits() != 0 || info.License != "Apache-2.0" {
+		t.Errorf("expected a fresh Apache-2.0 detection, got %s with %d hits", info.License, d.CacheHits())
+	}
+}
+
+func TestDetector_DetectLicense_NoCache(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("pkg/package.json", `{"license": "MIT"}`)
+
+	d := NewWithFileSystem(fs)
+	for range 2 {
+		if _, err := d.DetectLicense("pkg"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if d.CacheHits() != 0 {
+		t.Errorf("expected no cache hits without a cache, got %d", d.CacheHits())
+	}
+}
+
+func TestLRUCache_Eviction(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", &LicenseInfo{License: "MIT"})
+	cache.Set("b", &LicenseInfo{License: "ISC"})
+
+	// Reading a makes b the least recently used
+	if info, ok := cache.Get("a"); !ok || info.License != "MIT" {
+		t.Fatalf("expected MIT for a, got %+v", info)
+	}
+	cache.Set("c", &LicenseInfo{License: "Apache-2.0"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	// Updating an entry does not grow the cache
+	cache.Set("c", &LicenseInfo{License: "BSD-3-Clause"})
+	if info, _ := cache.Get("c"); info.License != "BSD-3-Clause" {
+		t.Errorf("expected the updated license, got %s", info.License)
+	}
+
+	expected := CacheStats{Hits: 4, Misses: 1, Evictions: 1, Size: 2}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestLRUCache_ConcurrentAccess(t *testing.T) {
+	mock := NewMockFileSystem()
+	for i := range 50 {
+		mock.AddFile(fmt.Sprintf("pkg-%d/package.json", i), `{"license": "MIT"}`)
+	}
+	cache := NewLRUCache(20)
+
+	// Detectors of concurrent scans share the cache; run with -race
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := NewWithCache(mock, cache)
+			for i := range 200 {
+				info, err := d.DetectLicense(fmt.Sprintf("pkg-%d", i%50))
+				if err != nil || info.License != "MIT" {
+					t.Errorf("expected MIT, got %+v (%v)", info, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 8*200 {
+		t.Errorf("expected every lookup counted, got %+v", stats)
+	}
+	if stats.Size > 20 || stats.Evictions == 0 {
+		t.Errorf("expected the cache bounded to 20 entries with evictions, got %+v", stats)
+	}
+}
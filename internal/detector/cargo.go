@@ -0,0 +1,54 @@
+package detector
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// detectFromCargoToml reads the license field of the [package] table of a
+// vendored crate's Cargo.toml
+func (d *Detector) detectFromCargoToml(packagePath string) *LicenseInfo {
+	file, err := d.fs.Open(d.fs.Join(packagePath, constants.CargoTomlFile))
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	inPackage := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inPackage = line == "[package]"
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !inPackage || !found || strings.TrimSpace(key) != "license" {
+			continue
+		}
+		license, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil
+		}
+		// Old manifests separate alternatives with a slash. Most crates use
+		// SPDX expressions, which the analyzer parses, so only single ids
+		// are normalized.
+		license = strings.TrimSpace(strings.ReplaceAll(license, "/", " OR "))
+		if !strings.Contains(license, " ") {
+			license = normalizedLicense(license)
+		}
+		if license != "" {
+			return &LicenseInfo{
+				License:    license,
+				Confidence: 1.0,
+				Source:     constants.CargoTomlSource,
+			}
+		}
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package detector
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/StefanoA1/license-scanner/internal/constants"
 )
@@ -15,8 +19,18 @@ type LicenseInfo struct {
 	License    string  `json:"license"`
 	Confidence float64 `json:"confidence"`
 	Source     string  `json:"source"`
+	// TextHash is the SHA-256 of the license file the detection was based on
+	TextHash string `json:"textHash,omitempty"`
+	// Truncated is set when only the head of an oversized license file was matched
+	Truncated bool `json:"truncated,omitempty"`
 }
 
+// DefaultMaxLicenseSize is how much of a license file is read for matching;
+// license signals appear early, so larger files are matched on their head
+const DefaultMaxLicenseSize = 1 << 20
+
+// FileSystem abstracts file access; implementations must be safe for
+// concurrent use, as the scanner detects licenses in parallel
 type FileSystem interface {
 	Open(path string) (io.ReadCloser, error)
 	Stat(path string) (os.FileInfo, error)
@@ -37,25 +51,86 @@ func (fs *RealFileSystem) Join(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+func (fs *RealFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+// dirReader is implemented by file systems that can list directories, which
+// is needed to find LICENSE-* files
+type dirReader interface {
+	ReadDir(path string) ([]os.DirEntry, error)
+}
+
 type Detector struct {
-	fs FileSystem
+	fs                 FileSystem
+	maxLicenseSize     int64
+	confidenceCaps     map[string]float64
+	cache              Cache
+	cacheHits          atomic.Int64
+	alternateManifests []string
 }
 
 func New() *Detector {
-	return &Detector{
-		fs: &RealFileSystem{},
-	}
+	return NewWithFileSystem(&RealFileSystem{})
 }
 
 func NewWithFileSystem(fs FileSystem) *Detector {
 	return &Detector{
-		fs: fs,
+		fs:                 newZipFileSystem(fs),
+		alternateManifests: constants.AlternateManifestFiles,
 	}
 }
 
+// AltManifestConfidence is the confidence of a license read from an
+// alternate manifest, which is parsed tolerantly
+const AltManifestConfidence = 0.9
+
+// SetAlternateManifests sets the manifest names checked when package.json
+// declares no license; nil disables them
+func (d *Detector) SetAlternateManifests(names []string) {
+	d.alternateManifests = names
+}
+
+// SetMaxLicenseSize caps how many bytes of a license file are matched;
+// zero or less restores DefaultMaxLicenseSize
+func (d *Detector) SetMaxLicenseSize(size int64) {
+	d.maxLicenseSize = size
+}
+
+// SetConfidenceCaps limits the confidence of license file text matches per
+// license id, e.g. lower for BSD variants whose texts are easily confused
+func (d *Detector) SetConfidenceCaps(caps map[string]float64) {
+	d.confidenceCaps = caps
+}
+
 func (d *Detector) DetectLicense(packagePath string) (*LicenseInfo, error) {
+	if d.cache != nil {
+		return d.cachedDetectLicense(packagePath)
+	}
+	return d.detectLicense(packagePath)
+}
+
+func (d *Detector) detectLicense(packagePath string) (*LicenseInfo, error) {
 	// Try to get license from package.json first
-	if info := d.detectFromPackageJSON(packagePath); info != nil {
+	packageInfo := d.detectFromPackageJSON(packagePath)
+	if packageInfo != nil && packageInfo.Source != constants.MalformedSource {
+		return packageInfo, nil
+	}
+
+	// Some packages ship their manifest under another name
+	if packageInfo == nil {
+		if info := d.detectFromAlternateManifest(packagePath); info != nil {
+			return info, nil
+		}
+	}
+
+	// Bower components declare their license in bower.json
+	if info := d.detectFromBowerJSON(packagePath); info != nil {
+		return info, nil
+	}
+
+	// And vendored Rust crates in Cargo.toml
+	if info := d.detectFromCargoToml(packagePath); info != nil {
 		return info, nil
 	}
 
@@ -64,6 +139,19 @@ func (d *Detector) DetectLicense(packagePath string) (*LicenseInfo, error) {
 		return info, nil
 	}
 
+	// Last resort: an SPDX tag or license banner atop the main entry file
+	if info := d.detectFromSourceHeader(packagePath); info != nil {
+		return info, nil
+	}
+	if info := d.detectFromBanner(packagePath); info != nil {
+		return info, nil
+	}
+
+	// Surface a malformed license field rather than a plain not-found
+	if packageInfo != nil {
+		return packageInfo, nil
+	}
+
 	// Default to unknown
 	return &LicenseInfo{
 		License:    constants.UnknownLicense,
@@ -96,6 +184,14 @@ func (d *Detector) detectFromPackageJSON(packagePath string) *LicenseInfo {
 		return nil
 	}
 
+	if isMalformedLicenseField(pkg.License) {
+		return &LicenseInfo{
+			License:    constants.UnknownLicense,
+			Confidence: 0.0,
+			Source:     constants.MalformedSource,
+		}
+	}
+
 	license := extractLicenseFromField(pkg.License)
 	if license != "" {
 		return &LicenseInfo{
@@ -108,37 +204,211 @@ func (d *Detector) detectFromPackageJSON(packagePath string) *LicenseInfo {
 	return nil
 }
 
-func (d *Detector) detectFromLicenseFile(packagePath string) *LicenseInfo {
-	for _, filename := range constants.LicenseFileVariants {
-		licensePath := d.fs.Join(packagePath, filename)
-		if info, err := d.fs.Stat(licensePath); err == nil && !info.IsDir() {
-			license, confidence := d.analyzeLicenseFile(licensePath)
+// manifestLicensePattern finds a license field in a manifest that is not
+// strict JSON, e.g. JSON5 with comments, unquoted keys or single quotes
+var manifestLicensePattern = regexp.MustCompile(`(?:^|[{,\s])["']?license["']?\s*:\s*(?:\{[^}]*?["']?type["']?\s*:\s*)?["']([^"'\n]+)["']`)
+
+// detectFromAlternateManifest reads the license of the first alternate
+// manifest declaring one, falling back to a tolerant match when the file is
+// not valid JSON
+func (d *Detector) detectFromAlternateManifest(packagePath string) *LicenseInfo {
+	for _, name := range d.alternateManifests {
+		file, err := d.fs.Open(d.fs.Join(packagePath, name))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			continue
+		}
+
+		var manifest struct {
+			License interface{} `json:"license"`
+		}
+		license := ""
+		if err := json.Unmarshal(data, &manifest); err == nil {
+			license = extractLicenseFromField(manifest.License)
+		} else if match := manifestLicensePattern.FindSubmatch(data); match != nil {
+			license = normalizedLicense(strings.TrimSpace(string(match[1])))
+		}
+		if license != "" {
 			return &LicenseInfo{
 				License:    license,
-				Confidence: confidence,
-				Source:     constants.LicenseFileSource,
+				Confidence: AltManifestConfidence,
+				Source:     constants.AltManifestSource,
 			}
 		}
 	}
+	return nil
+}
+
+func (d *Detector) detectFromBowerJSON(packagePath string) *LicenseInfo {
+	file, err := d.fs.Open(d.fs.Join(packagePath, constants.BowerJSONFile))
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	var manifest struct {
+		License interface{} `json:"license"`
+	}
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return nil
+	}
+
+	if license := extractLicenseFromField(manifest.License); license != "" {
+		return &LicenseInfo{
+			License:    license,
+			Confidence: 1.0,
+			Source:     constants.BowerJSONSource,
+		}
+	}
 
 	return nil
 }
 
-func (d *Detector) analyzeLicenseFile(licensePath string) (string, float64) {
+// detectFromLicenseFile matches every license file of the package. Several
+// files with different licenses, e.g. LICENSE-MIT and LICENSE-APACHE, make
+// the package dual licensed.
+func (d *Detector) detectFromLicenseFile(packagePath string) *LicenseInfo {
+	var found []*LicenseInfo
+	hashes := make(map[string]bool)
+	for _, licensePath := range d.licenseFiles(packagePath) {
+		info := d.analyzeLicenseFile(licensePath, constants.LicenseFileSource)
+		// Case-insensitive file systems find one file under several names
+		if info.TextHash != "" && hashes[info.TextHash] {
+			continue
+		}
+		hashes[info.TextHash] = true
+		found = append(found, info)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return combineLicenseFiles(found)
+}
+
+// licenseFiles returns the paths of the license files in packagePath: the
+// known names first, then any LICENSE-* or LICENCE-* file
+func (d *Detector) licenseFiles(packagePath string) []string {
+	var paths []string
+	known := make(map[string]bool)
+	for _, filename := range constants.LicenseFileVariants {
+		known[filename] = true
+		licensePath := d.fs.Join(packagePath, filename)
+		if info, err := d.fs.Stat(licensePath); err == nil && !info.IsDir() {
+			paths = append(paths, licensePath)
+		}
+	}
+
+	lister, ok := d.fs.(dirReader)
+	if !ok {
+		return paths
+	}
+	entries, err := lister.ReadDir(packagePath)
+	if err != nil {
+		return paths
+	}
+	var suffixed []string
+	for _, entry := range entries {
+		name := strings.ToUpper(entry.Name())
+		if !entry.IsDir() && !known[entry.Name()] && (strings.HasPrefix(name, "LICENSE-") || strings.HasPrefix(name, "LICENCE-")) {
+			suffixed = append(suffixed, entry.Name())
+		}
+	}
+	sort.Strings(suffixed)
+	for _, filename := range suffixed {
+		paths = append(paths, d.fs.Join(packagePath, filename))
+	}
+	return paths
+}
+
+// combineLicenseFiles merges the matches of a package's license files.
+// Distinct licenses are alternatives, as offered by dual licensed packages;
+// unrecognized files only count when no file is recognized.
+func combineLicenseFiles(found []*LicenseInfo) *LicenseInfo {
+	var recognized []*LicenseInfo
+	licenses := make(map[string]bool)
+	for _, info := range found {
+		if info.License != constants.UnknownLicense && !licenses[info.License] {
+			licenses[info.License] = true
+			recognized = append(recognized, info)
+		}
+	}
+	switch len(recognized) {
+	case 0:
+		return found[0]
+	case 1:
+		return recognized[0]
+	}
+
+	combined := &LicenseInfo{Confidence: 1.0, Source: constants.LicenseFileSource}
+	ids := make([]string, len(recognized))
+	hashes := make([]string, len(recognized))
+	for i, info := range recognized {
+		ids[i] = info.License
+		hashes[i] = info.TextHash
+		combined.Confidence = min(combined.Confidence, info.Confidence)
+		combined.Truncated = combined.Truncated || info.Truncated
+	}
+	combined.License = strings.Join(ids, " OR ")
+	// Editing any of the files changes the hash
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	combined.TextHash = hex.EncodeToString(sum[:])
+	return combined
+}
+
+// analyzeLicenseFile matches the head of a license file. The text hash still
+// covers the whole file, which is streamed rather than held in memory.
+func (d *Detector) analyzeLicenseFile(licensePath, source string) *LicenseInfo {
+	failed := &LicenseInfo{License: constants.UnknownLicense, Confidence: 0.2, Source: source}
 	file, err := d.fs.Open(licensePath)
 	if err != nil {
-		return constants.UnknownLicense, 0.2
+		return failed
 	}
 	defer func() {
 		_ = file.Close() // Ignore close error as we already read the file
 	}()
 
-	data, err := io.ReadAll(file)
+	maxSize := d.maxLicenseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxLicenseSize
+	}
+
+	hash := sha256.New()
+	head, err := io.ReadAll(io.LimitReader(io.TeeReader(file, hash), maxSize))
+	if err != nil {
+		return failed
+	}
+	rest, err := io.Copy(hash, file)
 	if err != nil {
-		return constants.UnknownLicense, 0.2
+		return failed
 	}
 
-	content := string(data)
+	license, confidence := matchLicenseText(string(head))
+	if limit, ok := d.confidenceCaps[license]; ok && confidence > limit {
+		confidence = limit
+	}
+	return &LicenseInfo{
+		License:    license,
+		Confidence: confidence,
+		Source:     source,
+		TextHash:   hex.EncodeToString(hash.Sum(nil)),
+		Truncated:  rest > 0,
+	}
+}
+
+// IdentifyLicenseText identifies a license from the text of a license file
+// obtained elsewhere, e.g. fetched from the package's repository
+func IdentifyLicenseText(content string) (string, float64) {
+	return matchLicenseText(content)
+}
+
+// matchLicenseText identifies a license from its full text
+func matchLicenseText(content string) (string, float64) {
 	content = strings.ToLower(content)
 
 	// License patterns with confidence scores
@@ -186,13 +456,167 @@ func (d *Detector) analyzeLicenseFile(licensePath string) (string, float64) {
 	return constants.UnknownLicense, 0.2
 }
 
+// bannerScanLimit bounds how much of an entry file is read for its banner
+const bannerScanLimit = 8 * 1024
+
+// sourceHeaderLines is how many lines of an entry file are searched for an
+// SPDX tag, enough to get past shebangs, "use strict" and copyright lines
+const sourceHeaderLines = 20
+
+// spdxIdentifierPattern matches an SPDX-License-Identifier tag in a comment
+var spdxIdentifierPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\n*]+?)\s*(?:\*/|\n|$)`)
+
+// bannerPatterns extract license ids from banner comments, most explicit first
+var bannerPatterns = []struct {
+	pattern    *regexp.Regexp
+	confidence float64
+}{
+	{spdxIdentifierPattern, 0.4},
+	{regexp.MustCompile(`@license\s+([^\s*]+)`), 0.3},
+	{regexp.MustCompile(`(?i)\blicen[cs]e[d]?:\s*([^\s*]+)`), 0.3},
+	{regexp.MustCompile(`(?i)released\s+under\s+the\s+(\S+)\s+licen[cs]e`), 0.3},
+}
+
+// detectFromSourceHeader looks for an SPDX-License-Identifier tag in the
+// first lines of the package's main entry file. The tag is machine readable,
+// so it is trusted more than a prose banner.
+func (d *Detector) detectFromSourceHeader(packagePath string) *LicenseInfo {
+	head, ok := d.readEntryHead(packagePath)
+	if !ok {
+		return nil
+	}
+
+	lines := strings.SplitN(head, "\n", sourceHeaderLines+1)
+	header := strings.Join(lines[:min(len(lines), sourceHeaderLines)], "\n")
+	match := spdxIdentifierPattern.FindStringSubmatch(header)
+	if match == nil {
+		return nil
+	}
+	license := normalizedLicense(strings.Trim(match[1], ".,;"))
+	if license == "" {
+		return nil
+	}
+	return &LicenseInfo{License: license, Confidence: 0.7, Source: constants.SPDXHeaderSource}
+}
+
+// detectFromBanner reads the leading comment block of the package's main
+// entry file, as minified bundles often carry only a license banner
+func (d *Detector) detectFromBanner(packagePath string) *LicenseInfo {
+	head, ok := d.readEntryHead(packagePath)
+	if !ok {
+		return nil
+	}
+
+	comment := leadingComment(head)
+	if comment == "" {
+		return nil
+	}
+
+	for _, banner := range bannerPatterns {
+		if match := banner.pattern.FindStringSubmatch(comment); match != nil {
+			return &LicenseInfo{
+				License:    normalizedLicense(strings.Trim(match[1], ".,;")),
+				Confidence: banner.confidence,
+				Source:     constants.BannerSource,
+			}
+		}
+	}
+
+	// A full license block copied into the banner
+	if license, _ := matchLicenseText(comment); license != constants.UnknownLicense {
+		return &LicenseInfo{License: license, Confidence: 0.3, Source: constants.BannerSource}
+	}
+
+	return nil
+}
+
+// readEntryHead reads the start of the package's main entry file
+func (d *Detector) readEntryHead(packagePath string) (string, bool) {
+	file, err := d.fs.Open(d.fs.Join(packagePath, d.mainEntry(packagePath)))
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(file, bannerScanLimit))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// mainEntry returns the entry file declared by package.json's main field,
+// defaulting to index.js like Node does
+func (d *Detector) mainEntry(packagePath string) string {
+	entry := "index.js"
+	if file, err := d.fs.Open(d.fs.Join(packagePath, constants.PackageJSONFile)); err == nil {
+		var manifest struct {
+			Main string `json:"main"`
+		}
+		if json.NewDecoder(file).Decode(&manifest) == nil && manifest.Main != "" {
+			entry = filepath.Clean(manifest.Main)
+		}
+		_ = file.Close() // Ignore close error as we already read the file
+	}
+
+	// Never follow an entry point outside the package
+	if strings.HasPrefix(entry, "..") || filepath.IsAbs(entry) {
+		return "index.js"
+	}
+	if filepath.Ext(entry) == "" {
+		entry += ".js"
+	}
+	return entry
+}
+
+// leadingComment returns the comments at the start of a source file,
+// skipping a shebang line
+func leadingComment(content string) string {
+	if strings.HasPrefix(content, "#!") {
+		if newline := strings.IndexByte(content, '\n'); newline >= 0 {
+			content = content[newline+1:]
+		}
+	}
+
+	var comments strings.Builder
+	for {
+		content = strings.TrimLeft(content, " \t\r\n")
+		switch {
+		case strings.HasPrefix(content, "/*"):
+			end := strings.Index(content, "*/")
+			if end < 0 {
+				return comments.String() + content
+			}
+			comments.WriteString(content[:end+2] + "\n")
+			content = content[end+2:]
+		case strings.HasPrefix(content, "//"):
+			end := strings.IndexByte(content, '\n')
+			if end < 0 {
+				return comments.String() + content
+			}
+			comments.WriteString(content[:end+1])
+			content = content[end+1:]
+		default:
+			return comments.String()
+		}
+	}
+}
+
 func extractLicenseFromField(licenseField interface{}) string {
 	switch v := licenseField.(type) {
 	case string:
 		return normalizedLicense(v)
 	case map[string]interface{}:
-		if typeVal, ok := v["type"].(string); ok {
-			return normalizedLicense(typeVal)
+		// Prefer "type", then "name" as used in the wild, then an SPDX/OSI url
+		for _, key := range []string{"type", "name"} {
+			if value, ok := v[key].(string); ok && strings.TrimSpace(value) != "" {
+				return normalizedLicense(value)
+			}
+		}
+		if url, ok := v["url"].(string); ok {
+			return licenseFromURL(url)
 		}
 	case []interface{}:
 		if len(v) > 0 {
@@ -204,17 +628,77 @@ func extractLicenseFromField(licenseField interface{}) string {
 	return ""
 }
 
+// licenseURLPattern matches license pages of the SPDX and OSI license lists
+var licenseURLPattern = regexp.MustCompile(`(?i)^https?://(?:www\.)?(?:spdx\.org|opensource\.org)/licenses/([A-Za-z0-9.+-]+?)(?:\.html|\.php|\.txt)?/?$`)
+
+// licenseFromURL returns the license id of a SPDX or OSI license url
+func licenseFromURL(url string) string {
+	if match := licenseURLPattern.FindStringSubmatch(strings.TrimSpace(url)); match != nil {
+		return normalizedLicense(match[1])
+	}
+	return ""
+}
+
+// isMalformedLicenseField reports license fields emitted as booleans or
+// numbers by broken generators (e.g. "license": false), and strings that
+// cannot name a license (e.g. "***" or "{{license}}")
+func isMalformedLicenseField(licenseField interface{}) bool {
+	switch v := licenseField.(type) {
+	case bool, float64:
+		return true
+	case string:
+		license := strings.TrimSpace(v)
+		return license != "" && garbageLicensePattern.MatchString(strings.TrimRight(license, "*"))
+	}
+	return false
+}
+
+// garbageLicensePattern matches strings without a single letter, in any
+// script, and unexpanded template placeholders such as {{license}}, ${LICENSE}
+// or <%= license %>. Custom license names use all sorts of punctuation, so
+// nothing else is flagged.
+var garbageLicensePattern = regexp.MustCompile(`^\PL*$|\{\{|\$\{|<%`)
+
+// isLicenseExpression reports whether a license string combines licenses
+// with SPDX operators or groups them in parentheses
+func isLicenseExpression(license string) bool {
+	if strings.ContainsAny(license, "()") {
+		return true
+	}
+	for _, field := range strings.Fields(license) {
+		switch strings.ToUpper(field) {
+		case "OR", "AND", "WITH":
+			return true
+		}
+	}
+	return false
+}
+
 func normalizedLicense(license string) string {
 	license = strings.TrimSpace(license)
 	if license == "" {
 		return ""
 	}
 
+	// Stray decoration seen in the wild: "MIT*", "(MIT)"
+	license = strings.TrimSpace(strings.TrimRight(license, "*"))
+	if inner, ok := strings.CutPrefix(license, "("); ok && strings.HasSuffix(inner, ")") {
+		if inner = strings.TrimSuffix(inner, ")"); !strings.ContainsAny(inner, "() ") {
+			license = inner
+		}
+	}
+
+	// Expressions are left for the analyzer to parse, as hyphenating
+	// "MIT OR GPL-3.0" would turn it into a single GPL-3.0 look-alike
+	if isLicenseExpression(license) {
+		return license
+	}
+
 	// Common license normalizations
 	license = strings.ReplaceAll(license, " ", "-")
 
 	switch strings.ToLower(license) {
-	case "mit":
+	case "mit", "mit/x11":
 		return "MIT"
 	case "apache-2.0", "apache2", "apache-v2":
 		return "Apache-2.0"
@@ -1,8 +1,11 @@
 package detector
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
 	"strings"
 	"testing"
@@ -39,8 +42,8 @@ func (fs *MockFileSystem) Open(path string) (io.ReadCloser, error) {
 }
 
 func (fs *MockFileSystem) Stat(path string) (os.FileInfo, error) {
-	if _, exists := fs.files[path]; exists {
-		return &mockFileInfo{name: path, isDir: false}, nil
+	if content, exists := fs.files[path]; exists {
+		return &mockFileInfo{name: path, isDir: false, size: int64(len(content))}, nil
 	}
 	if _, exists := fs.dirs[path]; exists {
 		return &mockFileInfo{name: path, isDir: true}, nil
@@ -52,13 +55,25 @@ func (fs *MockFileSystem) Join(elem ...string) string {
 	return strings.Join(elem, "/")
 }
 
+// ReadDir lists the files directly in a directory
+func (fs *MockFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	var entries []os.DirEntry
+	for file := range fs.files {
+		if name, ok := strings.CutPrefix(file, path+"/"); ok && !strings.Contains(name, "/") {
+			entries = append(entries, iofs.FileInfoToDirEntry(&mockFileInfo{name: name}))
+		}
+	}
+	return entries, nil
+}
+
 type mockFileInfo struct {
 	name  string
 	isDir bool
+	size  int64
 }
 
 func (fi *mockFileInfo) Name() string       { return fi.name }
-func (fi *mockFileInfo) Size() int64        { return 0 }
+func (fi *mockFileInfo) Size() int64        { return fi.size }
 func (fi *mockFileInfo) Mode() os.FileMode  { return 0 }
 func (fi *mockFileInfo) ModTime() time.Time { return time.Time{} }
 func (fi *mockFileInfo) IsDir() bool        { return fi.isDir }
@@ -242,6 +257,12 @@ func TestNormalizedLicense(t *testing.T) {
 		{"ISC", "ISC"},
 		{"isc", "ISC"},
 		{"Custom License", "Custom-License"},
+		{"MIT/X11", "MIT"},
+		{"(MIT)", "MIT"},
+		{"MIT*", "MIT"},
+		{"MIT OR GPL-3.0", "MIT OR GPL-3.0"},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0", "GPL-2.0-or-later WITH Classpath-exception-2.0"},
 		{"", ""},
 	}
 
@@ -255,6 +276,348 @@ func TestNormalizedLicense(t *testing.T) {
 	}
 }
 
+func TestDetector_DetectLicense_MalformedLicenseField(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+	}{
+		{name: "boolean license", packageJSON: `{"license": false}`},
+		{name: "numeric license", packageJSON: `{"license": 0}`},
+		{name: "wildcard only", packageJSON: `{"license": "*"}`},
+		{name: "punctuation", packageJSON: `{"license": "???"}`},
+		{name: "template placeholder", packageJSON: `{"license": "{{license}}"}`},
+		{name: "shell placeholder", packageJSON: `{"license": "${LICENSE}"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/test/package/package.json", tt.packageJSON)
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if info.License != "Unknown" || info.Source != "package.json (malformed)" {
+				t.Errorf("expected malformed signal, got %+v", info)
+			}
+		})
+	}
+}
+
+func TestDetector_DetectLicense_CustomLicenseNames(t *testing.T) {
+	// Punctuation and non-ASCII letters are fine in custom license names
+	for _, license := range []string{"MIT; see LICENSE", "Copyright <Acme Corp>", "Licence propriétaire", "版权所有"} {
+		fs := NewMockFileSystem()
+		fs.AddFile("/test/package/package.json", fmt.Sprintf(`{"license": %q}`, license))
+
+		info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Source != "package.json" {
+			t.Errorf("expected %q to be read from package.json, got %+v", license, info)
+		}
+	}
+}
+
+func TestDetector_DetectLicense_MalformedFallsBackToLicenseFile(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package/package.json", `{"license": true}`)
+	fs.AddFile("/test/package/LICENSE", "MIT License\n\nPermission is hereby granted, free of charge")
+
+	info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.License != "MIT" || info.Source != "LICENSE file" {
+		t.Errorf("expected LICENSE file detection, got %+v", info)
+	}
+}
+
+func TestDetector_DetectLicense_FromBowerJSON(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/bower_components/jquery/bower.json", `{"name": "jquery", "license": ["MIT"]}`)
+
+	info, err := NewWithFileSystem(fs).DetectLicense("/test/bower_components/jquery")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.License != "MIT" || info.Source != "bower.json" || info.Confidence != 1.0 {
+		t.Errorf("expected MIT from bower.json, got %+v", info)
+	}
+}
+
+func TestDetector_DetectLicense_FromCargoToml(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"SPDX expression", "[package]\nname = \"serde\"\nlicense = \"MIT OR Apache-2.0\"\n", "MIT OR Apache-2.0"},
+		{"slash separated", "[package]\nname = \"old\"\nlicense = \"MIT/Apache-2.0\"\n", "MIT OR Apache-2.0"},
+		{"other tables ignored", "[package]\nname = \"x\"\n\n[dependencies]\nlicense = \"1.0\"\n", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/test/vendor/crate/Cargo.toml", tt.content)
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/vendor/crate")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.License != tt.expected {
+				t.Errorf("expected %s, got %+v", tt.expected, info)
+			}
+			if tt.expected != "Unknown" && info.Source != "Cargo.toml" {
+				t.Errorf("expected the Cargo.toml source, got %s", info.Source)
+			}
+		})
+	}
+}
+
+func TestDetector_DetectLicense_FromAlternateManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"JSON5 with comments and unquoted keys", "package.json5", "{\n  // published from a fork\n  name: 'json5-pkg',\n  license: 'ISC',\n}\n", "ISC"},
+		{"JSON5 license object", "package.json5", "{license: {type: \"Apache-2.0\"}}", "Apache-2.0"},
+		{"dot-prefixed JSON", ".package.json", `{"license": "MIT"}`, "MIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/test/package/"+tt.file, tt.content)
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.License != tt.expected || info.Source != "alternate manifest" || info.Confidence != AltManifestConfidence {
+				t.Errorf("expected %s from the alternate manifest, got %+v", tt.expected, info)
+			}
+		})
+	}
+
+	// package.json stays the primary manifest
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package/package.json", `{"license": "MIT"}`)
+	fs.AddFile("/test/package/package.json5", "{license: 'GPL-3.0'}")
+	d := NewWithFileSystem(fs)
+	if info, _ := d.DetectLicense("/test/package"); info.License != "MIT" || info.Source != "package.json" {
+		t.Errorf("expected MIT from package.json, got %+v", info)
+	}
+
+	// Without alternate manifests the license is not found
+	fs = NewMockFileSystem()
+	fs.AddFile("/test/package/package.json5", "{license: 'ISC'}")
+	d = NewWithFileSystem(fs)
+	d.SetAlternateManifests(nil)
+	if info, _ := d.DetectLicense("/test/package"); info.License != "Unknown" {
+		t.Errorf("expected Unknown with alternate manifests disabled, got %+v", info)
+	}
+}
+
+func TestDetector_DetectLicense_LicenseTextHash(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package/LICENSE", "MIT License\n")
+
+	info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sha256sum of "MIT License\n"
+	expected := "267f7a2e19dfa9df99af774520985a0e521925293ea5b7e767ab06969d06bf91"
+	if info.TextHash != expected {
+		t.Errorf("expected hash %q, got %q", expected, info.TextHash)
+	}
+
+	again, _ := NewWithFileSystem(fs).DetectLicense("/test/package")
+	if again.TextHash != info.TextHash {
+		t.Errorf("expected stable hash, got %q and %q", info.TextHash, again.TextHash)
+	}
+}
+
+func TestDetector_DetectLicense_OversizedLicenseFile(t *testing.T) {
+	fs := NewMockFileSystem()
+	content := "MIT License\n\nPermission is hereby granted, free of charge\n" + strings.Repeat("x", 4096)
+	fs.AddFile("/test/package/LICENSE", content)
+
+	detector := NewWithFileSystem(fs)
+	detector.SetMaxLicenseSize(1024)
+	info, err := detector.DetectLicense("/test/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.License != "MIT" || !info.Truncated {
+		t.Errorf("expected MIT detected from the head of a truncated file, got %+v", info)
+	}
+
+	// The evidence hash still covers the whole file
+	sum := sha256.Sum256([]byte(content))
+	if info.TextHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the hash of the full file, got %q", info.TextHash)
+	}
+
+	// Files within the default cap are not truncated
+	if info, _ := NewWithFileSystem(fs).DetectLicense("/test/package"); info.Truncated {
+		t.Error("expected a 4KB file to be read in full with the default cap")
+	}
+}
+
+func TestDetector_DetectLicense_ConfidenceCaps(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/bsd/LICENSE", "Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met")
+	fs.AddFile("/test/mit/LICENSE", "MIT License\n\nPermission is hereby granted, free of charge")
+	fs.AddFile("/test/declared/package.json", `{"license": "BSD-3-Clause"}`)
+
+	detector := NewWithFileSystem(fs)
+	detector.SetConfidenceCaps(map[string]float64{"BSD-3-Clause": 0.6})
+
+	if info, _ := detector.DetectLicense("/test/bsd"); info.License != "BSD-3-Clause" || info.Confidence != 0.6 {
+		t.Errorf("expected the BSD-3-Clause text match capped at 0.6, got %+v", info)
+	}
+	if info, _ := detector.DetectLicense("/test/mit"); info.License != "MIT" || info.Confidence != 0.9 {
+		t.Errorf("expected MIT text matches unaffected, got %+v", info)
+	}
+	// Declared licenses are not text matches
+	if info, _ := detector.DetectLicense("/test/declared"); info.Confidence != 1.0 {
+		t.Errorf("expected a declared BSD-3-Clause license to keep full confidence, got %+v", info)
+	}
+}
+
+func TestDetector_DetectLicense_FromBanner(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		expectedInfo *LicenseInfo
+	}{
+		{
+			name: "license banner in minified main entry",
+			files: map[string]string{
+				"/test/package/package.json":       `{"name": "bundle", "main": "./dist/bundle.min.js"}`,
+				"/test/package/dist/bundle.min.js": "/*! bundle v1.0.0 | @license ISC */!function(e){\"use strict\";e.x=1}(this);",
+			},
+			expectedInfo: &LicenseInfo{License: "ISC", Confidence: 0.3, Source: "license banner"},
+		},
+		{
+			name: "license banner in default index.js",
+			files: map[string]string{
+				"/test/package/index.js": "#!/usr/bin/env node\n/*! license: MIT */\nvar a=1;",
+			},
+			expectedInfo: &LicenseInfo{License: "MIT", Confidence: 0.3, Source: "license banner"},
+		},
+		{
+			name: "license text only later in the code",
+			files: map[string]string{
+				"/test/package/index.js": "var a=1;/* @license MIT */",
+			},
+			expectedInfo: &LicenseInfo{License: "Unknown", Confidence: 0.0, Source: "not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			for path, content := range tt.files {
+				fs.AddFile(path, content)
+			}
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *info != *tt.expectedInfo {
+				t.Errorf("expected %+v, got %+v", tt.expectedInfo, info)
+			}
+		})
+	}
+}
+
+func TestDetector_DetectLicense_FromSourceHeader(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		expectedInfo *LicenseInfo
+	}{
+		{
+			name: "SPDX tag in minified main entry",
+			files: map[string]string{
+				"/test/package/package.json":       `{"name": "bundle", "main": "./dist/bundle.min.js"}`,
+				"/test/package/dist/bundle.min.js": "/*! bundle v1.0.0 | SPDX-License-Identifier: Apache-2.0 */!function(e){\"use strict\";e.x=1}(this);",
+			},
+			expectedInfo: &LicenseInfo{License: "Apache-2.0", Confidence: 0.7, Source: "SPDX header"},
+		},
+		{
+			name: "SPDX tag after the shebang and use strict",
+			files: map[string]string{
+				"/test/package/index.js": "#!/usr/bin/env node\n'use strict';\n\n// SPDX-License-Identifier: MIT\nmodule.exports = 1;",
+			},
+			expectedInfo: &LicenseInfo{License: "MIT", Confidence: 0.7, Source: "SPDX header"},
+		},
+		{
+			name: "SPDX tag past the header",
+			files: map[string]string{
+				"/test/package/index.js": strings.Repeat("var a = 1;\n", 25) + "// SPDX-License-Identifier: MIT\n",
+			},
+			expectedInfo: &LicenseInfo{License: "Unknown", Confidence: 0.0, Source: "not found"},
+		},
+		{
+			name: "LICENSE file wins over the SPDX tag",
+			files: map[string]string{
+				"/test/package/LICENSE":  "ISC License\n\nPermission to use, copy, modify, and/or distribute this software",
+				"/test/package/index.js": "// SPDX-License-Identifier: MIT\n",
+			},
+			expectedInfo: &LicenseInfo{License: "ISC", Confidence: 0.8, Source: "LICENSE file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			for path, content := range tt.files {
+				fs.AddFile(path, content)
+			}
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			info.TextHash = ""
+			if *info != *tt.expectedInfo {
+				t.Errorf("expected %+v, got %+v", tt.expectedInfo, info)
+			}
+		})
+	}
+}
+
+func TestDetector_DetectLicense_LicenseObjectWithName(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package/package.json", `{"license": {"name": "MIT"}}`)
+
+	info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.License != "MIT" || info.Source != "package.json" {
+		t.Errorf("expected MIT from package.json, got %+v", info)
+	}
+}
+
 func TestExtractLicenseFromField(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -286,9 +649,29 @@ func TestExtractLicenseFromField(t *testing.T) {
 			input:    nil,
 			expected: "",
 		},
+		{
+			name:     "license object with name",
+			input:    map[string]interface{}{"name": "MIT"},
+			expected: "MIT",
+		},
+		{
+			name:     "type preferred over name",
+			input:    map[string]interface{}{"type": "ISC", "name": "MIT"},
+			expected: "ISC",
+		},
+		{
+			name:     "license object with url",
+			input:    map[string]interface{}{"url": "https://opensource.org/licenses/BSD-3-Clause"},
+			expected: "BSD-3-Clause",
+		},
+		{
+			name:     "license object with unrelated url",
+			input:    map[string]interface{}{"url": "https://github.com/owner/repo/blob/main/LICENSE"},
+			expected: "",
+		},
 		{
 			name:     "invalid object",
-			input:    map[string]interface{}{"name": "test"},
+			input:    map[string]interface{}{"text": "test"},
 			expected: "",
 		},
 	}
@@ -302,3 +685,119 @@ func TestExtractLicenseFromField(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkDetector_DetectLicense(b *testing.B) {
+	fs := NewMockFileSystem()
+	paths := make([]string, 1000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("/project/node_modules/pkg-%d", i)
+		switch i % 3 {
+		case 0:
+			fs.AddFile(paths[i]+"/package.json", `{"license": "MIT"}`)
+		case 1:
+			fs.AddFile(paths[i]+"/LICENSE", "Apache License\nVersion 2.0, January 2004\nLicensed under the Apache License")
+		default:
+			fs.AddFile(paths[i]+"/package.json", `{"name": "unlicensed"}`)
+		}
+	}
+	detector := NewWithFileSystem(fs)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			if _, err := detector.DetectLicense(path); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+}
+
+func TestDetector_DetectLicense_LicenseFileNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name:     "COPYING",
+			files:    map[string]string{"COPYING": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"},
+			expected: "GPL-3.0",
+		},
+		{
+			name:     "COPYING.md",
+			files:    map[string]string{"COPYING.md": "# MIT License\n\nPermission is hereby granted, free of charge"},
+			expected: "MIT",
+		},
+		{
+			// Common in npm packages, and file systems may be case-sensitive
+			name:     "lower-case license",
+			files:    map[string]string{"license": "MIT License\n\nPermission is hereby granted, free of charge"},
+			expected: "MIT",
+		},
+		{
+			name:     "lower-case license.md",
+			files:    map[string]string{"license.md": "ISC License"},
+			expected: "ISC",
+		},
+		{
+			name:     "single LICENSE-MIT",
+			files:    map[string]string{"LICENSE-MIT": "MIT License\n\nPermission is hereby granted, free of charge"},
+			expected: "MIT",
+		},
+		{
+			name: "dual license files",
+			files: map[string]string{
+				"LICENSE-MIT":    "MIT License\n\nPermission is hereby granted, free of charge",
+				"LICENSE-APACHE": "Apache License, Version 2.0, January 2004",
+			},
+			expected: "Apache-2.0 OR MIT",
+		},
+		{
+			name: "same license twice",
+			files: map[string]string{
+				"LICENSE":     "MIT License\n\nPermission is hereby granted, free of charge",
+				"LICENSE-MIT": "The MIT License (MIT)\n\nPermission is hereby granted, free of charge",
+			},
+			expected: "MIT",
+		},
+		{
+			name: "unrecognized file next to a recognized one",
+			files: map[string]string{
+				"LICENSE":            "All rights reserved by the authors",
+				"LICENSE-THIRDPARTY": "ISC License",
+			},
+			expected: "ISC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			for name, content := range tt.files {
+				fs.AddFile("/test/package/"+name, content)
+			}
+
+			info, err := NewWithFileSystem(fs).DetectLicense("/test/package")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.License != tt.expected || info.Source != "LICENSE file" {
+				t.Errorf("expected %s from LICENSE file, got %+v", tt.expected, info)
+			}
+		})
+	}
+}
+
+func TestDetector_DetectLicense_DualLicenseHash(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package/LICENSE-MIT", "MIT License\n\nPermission is hereby granted, free of charge")
+	fs.AddFile("/test/package/LICENSE-APACHE", "Apache License, Version 2.0, January 2004")
+	before, _ := NewWithFileSystem(fs).DetectLicense("/test/package")
+
+	fs.AddFile("/test/package/LICENSE-APACHE", "Apache License, Version 2.0, January 2004\nwith an added clause")
+	after, _ := NewWithFileSystem(fs).DetectLicense("/test/package")
+
+	if before.TextHash == "" || before.TextHash == after.TextHash {
+		t.Errorf("expected editing one of the license files to change the hash, got %q and %q", before.TextHash, after.TextHash)
+	}
+}
@@ -0,0 +1,131 @@
+package detector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// GoLicenseLookup resolves the license of a Go module online, e.g. from
+// pkg.go.dev
+type GoLicenseLookup interface {
+	License(module, version string) (string, error)
+}
+
+// GoModuleDetector reads licenses of Go modules from the extracted module
+// cache ($GOPATH/pkg/mod/<module>@<version>/)
+type GoModuleDetector struct {
+	detector *Detector
+	fs       FileSystem
+	modCache string
+	online   GoLicenseLookup
+}
+
+func NewGoModuleDetector(modCache string) *GoModuleDetector {
+	return NewGoModuleDetectorWithFileSystem(&RealFileSystem{}, modCache)
+}
+
+func NewGoModuleDetectorWithFileSystem(fs FileSystem, modCache string) *GoModuleDetector {
+	return &GoModuleDetector{detector: NewWithFileSystem(fs), fs: fs, modCache: modCache}
+}
+
+// SetOnlineLookup enables a fallback for modules missing from the cache or
+// without a recognizable license there
+func (d *GoModuleDetector) SetOnlineLookup(lookup GoLicenseLookup) {
+	d.online = lookup
+}
+
+// DefaultGoModCache returns the module cache location the go command uses:
+// $GOMODCACHE, else the first $GOPATH entry's pkg/mod, else ~/go/pkg/mod
+func DefaultGoModCache() string {
+	if modCache := os.Getenv("GOMODCACHE"); modCache != "" {
+		return modCache
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// ModuleDir returns where module@version is extracted in the module cache
+func (d *GoModuleDetector) ModuleDir(module, version string) string {
+	return d.fs.Join(d.modCache, escapeModulePath(module)+"@"+version)
+}
+
+// DetectLicense returns the license of module@version. A "// license:"
+// comment in the module's go.mod takes precedence over its LICENSE file.
+func (d *GoModuleDetector) DetectLicense(module, version string) (*LicenseInfo, error) {
+	moduleDir := d.ModuleDir(module, version)
+
+	if license := d.goModLicense(moduleDir); license != "" {
+		return &LicenseInfo{License: normalizedLicense(license), Confidence: 1.0, Source: constants.GoModSource}, nil
+	}
+
+	info := d.detector.detectFromLicenseFile(moduleDir)
+	if info != nil && info.License != constants.UnknownLicense {
+		return info, nil
+	}
+
+	if d.online != nil {
+		if license, err := d.online.License(module, version); err == nil && license != "" {
+			return &LicenseInfo{License: license, Confidence: 0.9, Source: constants.PkgGoDevSource}, nil
+		}
+	}
+
+	if info != nil {
+		return info, nil
+	}
+	return &LicenseInfo{
+		License:    constants.UnknownLicense,
+		Confidence: 0.0,
+		Source:     constants.NotFoundSource,
+	}, nil
+}
+
+// goModLicense returns the value of a "// license: <id>" comment in go.mod
+func (d *GoModuleDetector) goModLicense(moduleDir string) string {
+	file, err := d.fs.Open(d.fs.Join(moduleDir, constants.GoModFile))
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		comment, found := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "//")
+		if !found {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimSpace(comment), ":")
+		if found && strings.EqualFold(strings.TrimSpace(key), "license") {
+			if value = strings.TrimSpace(value); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// escapeModulePath applies the module cache's case encoding, where each
+// upper-case letter becomes "!" followed by its lower-case form
+func escapeModulePath(module string) string {
+	var escaped strings.Builder
+	for _, r := range module {
+		if unicode.IsUpper(r) {
+			escaped.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
@@ -0,0 +1,68 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+)
+
+type stubGoLookup map[string]string
+
+func (s stubGoLookup) License(module, version string) (string, error) {
+	if license, ok := s[module+"@"+version]; ok {
+		return license, nil
+	}
+	return "", fmt.Errorf("%s@%s not found", module, version)
+}
+
+func TestGoModuleDetector_DetectLicense(t *testing.T) {
+	fs := NewMockFileSystem()
+	// Upper-case letters are escaped in the module cache
+	fs.AddFile("/gopath/pkg/mod/github.com/!burnt!sushi/toml@v1.3.2/LICENSE", "The MIT License (MIT)\n\nPermission is hereby granted, free of charge")
+	fs.AddFile("/gopath/pkg/mod/example.com/annotated@v0.1.0/go.mod", "// license: Apache-2.0\nmodule example.com/annotated\n\ngo 1.21\n")
+	fs.AddFile("/gopath/pkg/mod/example.com/annotated@v0.1.0/LICENSE", "MIT License")
+	fs.AddFile("/gopath/pkg/mod/example.com/custom@v1.0.0/LICENSE", "All rights reserved by nobody in particular")
+
+	detector := NewGoModuleDetectorWithFileSystem(fs, "/gopath/pkg/mod")
+	detector.SetOnlineLookup(stubGoLookup{
+		"example.com/custom@v1.0.0":    "BSD-3-Clause",
+		"golang.org/x/text@v0.14.0":    "BSD-3-Clause",
+		"example.com/annotated@v0.1.0": "GPL-3.0",
+	})
+
+	tests := []struct {
+		module, version string
+		expected        LicenseInfo
+	}{
+		{"github.com/BurntSushi/toml", "v1.3.2", LicenseInfo{License: "MIT", Confidence: 0.9, Source: "LICENSE file"}},
+		{"example.com/annotated", "v0.1.0", LicenseInfo{License: "Apache-2.0", Confidence: 1.0, Source: "go.mod"}},
+		{"example.com/custom", "v1.0.0", LicenseInfo{License: "BSD-3-Clause", Confidence: 0.9, Source: "pkg.go.dev"}},
+		{"golang.org/x/text", "v0.14.0", LicenseInfo{License: "BSD-3-Clause", Confidence: 0.9, Source: "pkg.go.dev"}},
+		{"example.com/missing", "v1.0.0", LicenseInfo{License: "Unknown", Confidence: 0.0, Source: "not found"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.module, func(t *testing.T) {
+			info, err := detector.DetectLicense(tt.module, tt.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			info.TextHash = ""
+			if *info != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *info)
+			}
+		})
+	}
+}
+
+func TestGoModuleDetector_Offline(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/mod/example.com/custom@v1.0.0/LICENSE", "All rights reserved by nobody in particular")
+
+	info, err := NewGoModuleDetectorWithFileSystem(fs, "/mod").DetectLicense("example.com/custom", "v1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.License != "Unknown" || info.Confidence != 0.2 || info.Source != "LICENSE file" {
+		t.Errorf("expected an unrecognized LICENSE file without online lookup, got %+v", info)
+	}
+}
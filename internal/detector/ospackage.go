@@ -0,0 +1,156 @@
+package detector
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// debianLicenses maps DEP-5 short license names to SPDX identifiers
+var debianLicenses = map[string]string{
+	"expat":        "MIT",
+	"mit":          "MIT",
+	"isc":          "ISC",
+	"apache-2.0":   "Apache-2.0",
+	"bsd-2-clause": "BSD-2-Clause",
+	"bsd-3-clause": "BSD-3-Clause",
+	"mpl-2.0":      "MPL-2.0",
+	"gpl-2":        "GPL-2.0",
+	"gpl-2+":       "GPL-2.0-or-later",
+	"gpl-3":        "GPL-3.0",
+	"gpl-3+":       "GPL-3.0-or-later",
+	"lgpl-2.1":     "LGPL-2.1",
+	"lgpl-2.1+":    "LGPL-2.1-or-later",
+	"lgpl-3":       "LGPL-3.0",
+	"lgpl-3+":      "LGPL-3.0-or-later",
+	"agpl-3":       "AGPL-3.0",
+	"agpl-3+":      "AGPL-3.0-or-later",
+	"zlib":         "Zlib",
+}
+
+// rpmLicenseFiles are the names %license files are commonly installed under
+var rpmLicenseFiles = append([]string{"COPYING", "COPYING.txt"}, constants.LicenseFileVariants...)
+
+// OSPackageDetector reads licenses of Debian and RPM packages installed in a
+// root filesystem, e.g. an unpacked container image
+type OSPackageDetector struct {
+	fs     FileSystem
+	files  *Detector
+	rootfs string
+}
+
+func NewOSPackageDetector(rootfs string) *OSPackageDetector {
+	return NewOSPackageDetectorWithFileSystem(&RealFileSystem{}, rootfs)
+}
+
+func NewOSPackageDetectorWithFileSystem(fs FileSystem, rootfs string) *OSPackageDetector {
+	return &OSPackageDetector{fs: fs, files: &Detector{fs: fs}, rootfs: rootfs}
+}
+
+// DetectLicense returns the license of an installed OS package. Debian's
+// machine-readable copyright file takes precedence over the license files
+// RPM installs from %license declarations.
+func (d *OSPackageDetector) DetectLicense(name string) (*LicenseInfo, error) {
+	copyrightPath := d.fs.Join(d.rootfs, constants.DebianDocDir, name, constants.CopyrightFile)
+	if file, err := d.fs.Open(copyrightPath); err == nil {
+		info := parseDebianCopyright(bufio.NewScanner(file))
+		_ = file.Close() // Ignore close error as we already read the file
+		if info != nil {
+			return info, nil
+		}
+
+		// Not machine-readable, fall back to matching the license text
+		if info := d.files.analyzeLicenseFile(copyrightPath, constants.DebianCopyrightSource); info.License != constants.UnknownLicense {
+			return info, nil
+		}
+	}
+
+	for _, filename := range rpmLicenseFiles {
+		licensePath := d.fs.Join(d.rootfs, constants.RPMLicenseDir, name, filename)
+		if info, err := d.fs.Stat(licensePath); err == nil && !info.IsDir() {
+			return d.files.analyzeLicenseFile(licensePath, constants.RPMLicenseSource), nil
+		}
+	}
+
+	return &LicenseInfo{
+		License:    constants.UnknownLicense,
+		Confidence: 0.0,
+		Source:     constants.NotFoundSource,
+	}, nil
+}
+
+// parseDebianCopyright reads a DEP-5 copyright file. The license of the
+// "Files: *" paragraph is the package license; otherwise the header or first
+// Files paragraph is used. Returns nil if the file is not machine-readable.
+func parseDebianCopyright(scanner *bufio.Scanner) *LicenseInfo {
+	var paragraphs []map[string]string
+	paragraph := map[string]string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(paragraph) > 0 {
+				paragraphs = append(paragraphs, paragraph)
+				paragraph = map[string]string{}
+			}
+			continue
+		}
+
+		// Continuation lines hold the license text, only the first line matters
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		paragraph[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	if len(paragraph) > 0 {
+		paragraphs = append(paragraphs, paragraph)
+	}
+
+	if len(paragraphs) == 0 || paragraphs[0]["format"] == "" {
+		return nil
+	}
+
+	var firstFiles string
+	for _, p := range paragraphs[1:] {
+		files, isFiles := p["files"]
+		if !isFiles || p["license"] == "" {
+			continue
+		}
+		if files == "*" {
+			return &LicenseInfo{License: debianLicenseExpression(p["license"]), Confidence: 1.0, Source: constants.DebianCopyrightSource}
+		}
+		if firstFiles == "" {
+			firstFiles = p["license"]
+		}
+	}
+
+	switch {
+	case paragraphs[0]["license"] != "":
+		return &LicenseInfo{License: debianLicenseExpression(paragraphs[0]["license"]), Confidence: 0.9, Source: constants.DebianCopyrightSource}
+	case firstFiles != "":
+		return &LicenseInfo{License: debianLicenseExpression(firstFiles), Confidence: 0.8, Source: constants.DebianCopyrightSource}
+	}
+
+	return nil
+}
+
+// debianLicenseExpression converts a DEP-5 license short name such as
+// "GPL-2+ or Artistic" into an SPDX-style expression
+func debianLicenseExpression(license string) string {
+	tokens := strings.Fields(license)
+	for i, token := range tokens {
+		lower := strings.ToLower(token)
+		switch {
+		case lower == "or" || lower == "and":
+			tokens[i] = strings.ToUpper(lower)
+		case debianLicenses[lower] != "":
+			tokens[i] = debianLicenses[lower]
+		}
+	}
+	return strings.Join(tokens, " ")
+}
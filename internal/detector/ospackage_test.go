@@ -0,0 +1,94 @@
+package detector
+
+import (
+	"testing"
+)
+
+// debianCopyright is a DEP-5 debian/copyright fixture
+const debianCopyright = `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+Upstream-Name: curl
+Source: https://curl.se/download/
+
+Files: *
+Copyright: 1996-2023, Daniel Stenberg <daniel@haxx.se>
+License: curl
+
+Files: debian/*
+Copyright: 2020, Debian maintainers
+License: GPL-2+
+ This program is free software; you can redistribute it
+ .
+ License: not a field
+
+License: curl
+ Permission to use, copy, modify, and distribute this software for any
+ purpose with or without fee is hereby granted.
+`
+
+func TestOSPackageDetector_DetectLicense(t *testing.T) {
+	tests := []struct {
+		name         string
+		pkg          string
+		path         string
+		content      string
+		expectedInfo LicenseInfo
+	}{
+		{
+			name:         "Files: * paragraph",
+			pkg:          "curl",
+			path:         "/rootfs/usr/share/doc/curl/copyright",
+			content:      debianCopyright,
+			expectedInfo: LicenseInfo{License: "curl", Confidence: 1.0, Source: "debian/copyright"},
+		},
+		{
+			name:    "expression in header paragraph",
+			pkg:     "perl-base",
+			path:    "/rootfs/usr/share/doc/perl-base/copyright",
+			content: "Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/\nLicense: GPL-1+ or Artistic\n\nFiles: lib/*\nLicense: Expat\n",
+			expectedInfo: LicenseInfo{
+				License: "GPL-1+ OR Artistic", Confidence: 0.9, Source: "debian/copyright",
+			},
+		},
+		{
+			name:         "first Files paragraph",
+			pkg:          "libjq1",
+			path:         "/rootfs/usr/share/doc/libjq1/copyright",
+			content:      "Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/\n\nFiles: src/*\nLicense: Expat\n",
+			expectedInfo: LicenseInfo{License: "MIT", Confidence: 0.8, Source: "debian/copyright"},
+		},
+		{
+			name:    "RPM %license file",
+			pkg:     "zlib",
+			path:    "/rootfs/usr/share/licenses/zlib/COPYING",
+			content: "GNU General Public License, version 2\n",
+			expectedInfo: LicenseInfo{
+				License: "GPL-2.0", Confidence: 0.9, Source: "RPM %license",
+				TextHash: "f2a83551bddc0c0aa9c5001831c2ffb0b4aba49fb4a5ec34b6b5bf9511903824",
+			},
+		},
+		{
+			name:         "not installed",
+			pkg:          "missing",
+			path:         "/rootfs/usr/share/doc/other/copyright",
+			content:      debianCopyright,
+			expectedInfo: LicenseInfo{License: "Unknown", Confidence: 0.0, Source: "not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile(tt.path, tt.content)
+
+			detector := NewOSPackageDetectorWithFileSystem(fs, "/rootfs")
+			info, err := detector.DetectLicense(tt.pkg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *info != tt.expectedInfo {
+				t.Errorf("expected %+v, got %+v", tt.expectedInfo, *info)
+			}
+		})
+	}
+}
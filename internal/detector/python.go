@@ -0,0 +1,201 @@
+package detector
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// pythonClassifierLicenses maps trove license classifiers to SPDX identifiers
+var pythonClassifierLicenses = map[string]string{
+	"MIT License":             "MIT",
+	"ISC License (ISCL)":      "ISC",
+	"Apache Software License": "Apache-2.0",
+	// The BSD classifier does not say which clauses apply
+	"BSD License":                                   "BSD",
+	"Mozilla Public License 2.0 (MPL 2.0)":          "MPL-2.0",
+	"GNU General Public License v2 (GPLv2)":         "GPL-2.0",
+	"GNU General Public License v3 (GPLv3)":         "GPL-3.0",
+	"GNU Lesser General Public License v2 (LGPLv2)": "LGPL-2.1",
+	"GNU Lesser General Public License v3 (LGPLv3)": "LGPL-3.0",
+	"GNU Affero General Public License v3":          "AGPL-3.0",
+	"The Unlicense (Unlicense)":                     "Unlicense",
+	"Python Software Foundation License":            "PSF-2.0",
+}
+
+// pythonHeaderLicenses are the SPDX ids, in lower case, a free-text License
+// header is trusted to hold. Many distributions put copyright lines or the
+// whole license text there instead.
+var pythonHeaderLicenses = map[string]string{
+	"0bsd":              "0BSD",
+	"agpl-3.0":          "AGPL-3.0",
+	"agpl-3.0-only":     "AGPL-3.0-only",
+	"agpl-3.0-or-later": "AGPL-3.0-or-later",
+	"apache-2.0":        "Apache-2.0",
+	"bsd-2-clause":      "BSD-2-Clause",
+	"bsd-3-clause":      "BSD-3-Clause",
+	"bsl-1.0":           "BSL-1.0",
+	"cc0-1.0":           "CC0-1.0",
+	"epl-2.0":           "EPL-2.0",
+	"gpl-2.0":           "GPL-2.0",
+	"gpl-2.0-only":      "GPL-2.0-only",
+	"gpl-2.0-or-later":  "GPL-2.0-or-later",
+	"gpl-3.0":           "GPL-3.0",
+	"gpl-3.0-only":      "GPL-3.0-only",
+	"gpl-3.0-or-later":  "GPL-3.0-or-later",
+	"isc":               "ISC",
+	"lgpl-2.1":          "LGPL-2.1",
+	"lgpl-2.1-only":     "LGPL-2.1-only",
+	"lgpl-2.1-or-later": "LGPL-2.1-or-later",
+	"lgpl-3.0":          "LGPL-3.0",
+	"lgpl-3.0-only":     "LGPL-3.0-only",
+	"lgpl-3.0-or-later": "LGPL-3.0-or-later",
+	"mit":               "MIT",
+	"mpl-2.0":           "MPL-2.0",
+	"psf-2.0":           "PSF-2.0",
+	"python-2.0":        "Python-2.0",
+	"unlicense":         "Unlicense",
+	"zlib":              "Zlib",
+}
+
+// pythonHeaderLicense returns the SPDX id or expression of a License header,
+// or false if it is not made of known ids
+func pythonHeaderLicense(header string) (string, bool) {
+	if strings.Contains(header, " OR ") || strings.Contains(header, " AND ") {
+		for _, part := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(header)) {
+			if _, known := pythonHeaderLicenses[strings.ToLower(part)]; !known && part != "OR" && part != "AND" {
+				return "", false
+			}
+		}
+		return header, true
+	}
+	id, known := pythonHeaderLicenses[strings.ToLower(normalizedLicense(header))]
+	return id, known
+}
+
+// PythonInstalledDetector reads licenses from the *.dist-info/METADATA files of
+// distributions installed in a site-packages directory, which works offline
+type PythonInstalledDetector struct {
+	fs           FileSystem
+	sitePackages string
+}
+
+func NewPythonInstalledDetector(sitePackages string) *PythonInstalledDetector {
+	return &PythonInstalledDetector{fs: &RealFileSystem{}, sitePackages: sitePackages}
+}
+
+func NewPythonInstalledDetectorWithFileSystem(fs FileSystem, sitePackages string) *PythonInstalledDetector {
+	return &PythonInstalledDetector{fs: fs, sitePackages: sitePackages}
+}
+
+// DetectLicense returns the license of an installed distribution. The
+// License-Expression header takes precedence, then a License header holding
+// a known SPDX id, then the classifiers.
+func (d *PythonInstalledDetector) DetectLicense(name, version string) (*LicenseInfo, error) {
+	if distInfo := d.DistInfoDir(name, version); distInfo != "" {
+		if file, err := d.fs.Open(d.fs.Join(distInfo, "METADATA")); err == nil {
+			info := parsePythonMetadata(bufio.NewScanner(file))
+			_ = file.Close() // Ignore close error as we already read the file
+			if info != nil {
+				return info, nil
+			}
+		}
+	}
+
+	return &LicenseInfo{
+		License:    constants.UnknownLicense,
+		Confidence: 0.0,
+		Source:     constants.NotFoundSource,
+	}, nil
+}
+
+// DistInfoDir returns the *.dist-info directory of an installed distribution,
+// or "" if it is not installed. Lock files spell names in lower case while
+// installers keep the project's own spelling, e.g. PyYAML, so the directory
+// is looked up case-insensitively when it can be listed.
+func (d *PythonInstalledDetector) DistInfoDir(name, version string) string {
+	candidates := distInfoNames(name, version)
+	for _, candidate := range candidates {
+		distInfo := d.fs.Join(d.sitePackages, candidate)
+		if _, err := d.fs.Stat(d.fs.Join(distInfo, "METADATA")); err == nil {
+			return distInfo
+		}
+	}
+
+	lister, ok := d.fs.(dirReader)
+	if !ok {
+		return ""
+	}
+	entries, err := lister.ReadDir(d.sitePackages)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), candidates[0]) {
+			return d.fs.Join(d.sitePackages, entry.Name())
+		}
+	}
+	return ""
+}
+
+// distInfoNames lists the directory names a distribution may be installed
+// under; installers normalize dashes and dots to underscores
+func distInfoNames(name, version string) []string {
+	normalized := strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	names := []string{normalized + "-" + version + ".dist-info"}
+	if lower := strings.ToLower(normalized); lower != normalized {
+		names = append(names, lower+"-"+version+".dist-info")
+	}
+	if name != normalized {
+		names = append(names, name+"-"+version+".dist-info")
+	}
+	return names
+}
+
+// parsePythonMetadata reads the headers of a core metadata file, stopping at
+// the blank line that starts the long description
+func parsePythonMetadata(scanner *bufio.Scanner) *LicenseInfo {
+	var expression, license, classifier string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "License-Expression":
+			expression = value
+		case "License":
+			license = value
+		case "Classifier":
+			if classifier == "" && strings.HasPrefix(value, "License ::") {
+				parts := strings.Split(value, "::")
+				if spdx, ok := pythonClassifierLicenses[strings.TrimSpace(parts[len(parts)-1])]; ok {
+					classifier = spdx
+				}
+			}
+		}
+	}
+
+	headerLicense, knownHeader := pythonHeaderLicense(license)
+	switch {
+	case expression != "":
+		return &LicenseInfo{License: expression, Confidence: 1.0, Source: constants.PythonMetadataSource}
+	case knownHeader:
+		return &LicenseInfo{License: headerLicense, Confidence: 0.9, Source: constants.PythonMetadataSource}
+	case classifier != "":
+		return &LicenseInfo{License: classifier, Confidence: 0.8, Source: constants.PythonMetadataSource}
+	case license != "" && !strings.EqualFold(license, "UNKNOWN"):
+		// Free text is kept at low confidence so it gets reviewed
+		return &LicenseInfo{License: normalizedLicense(license), Confidence: 0.4, Source: constants.PythonMetadataSource}
+	}
+
+	return nil
+}
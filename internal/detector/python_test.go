@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"testing"
+)
+
+func TestPythonInstalledDetector_DetectLicense(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution string
+		version      string
+		metadataDir  string
+		metadata     string
+		expectedInfo LicenseInfo
+	}{
+		{
+			name:         "License header",
+			distribution: "requests",
+			version:      "2.31.0",
+			metadataDir:  "requests-2.31.0.dist-info",
+			metadata: `Metadata-Version: 2.1
+Name: requests
+Version: 2.31.0
+License: Apache 2.0
+Classifier: License :: OSI Approved :: Apache Software License
+
+Requests is an HTTP library.
+License: not a header
+`,
+			expectedInfo: LicenseInfo{License: "Apache-2.0", Confidence: 0.9, Source: "METADATA"},
+		},
+		{
+			name:         "classifier only",
+			distribution: "typing-extensions",
+			version:      "4.7.1",
+			metadataDir:  "typing_extensions-4.7.1.dist-info",
+			metadata: `Metadata-Version: 2.1
+Name: typing_extensions
+Version: 4.7.1
+License: UNKNOWN
+Classifier: Programming Language :: Python :: 3
+Classifier: License :: OSI Approved :: Python Software Foundation License
+`,
+			expectedInfo: LicenseInfo{License: "PSF-2.0", Confidence: 0.8, Source: "METADATA"},
+		},
+		{
+			name:         "License-Expression header",
+			distribution: "Flask",
+			version:      "3.0.0",
+			metadataDir:  "flask-3.0.0.dist-info",
+			metadata: `Metadata-Version: 2.4
+Name: Flask
+License-Expression: BSD-3-Clause
+`,
+			expectedInfo: LicenseInfo{License: "BSD-3-Clause", Confidence: 1.0, Source: "METADATA"},
+		},
+		{
+			name:         "copyright text in the License header",
+			distribution: "numpy",
+			version:      "1.26.0",
+			metadataDir:  "numpy-1.26.0.dist-info",
+			metadata: `Metadata-Version: 2.1
+Name: numpy
+License: Copyright (c) 2005-2023, NumPy Developers.
+Classifier: License :: OSI Approved :: BSD License
+`,
+			// The classifier does not say which BSD license
+			expectedInfo: LicenseInfo{License: "BSD", Confidence: 0.8, Source: "METADATA"},
+		},
+		{
+			name:         "SPDX expression in the License header",
+			distribution: "cryptography",
+			version:      "41.0.4",
+			metadataDir:  "cryptography-41.0.4.dist-info",
+			metadata:     "Name: cryptography\nLicense: Apache-2.0 OR BSD-3-Clause\n",
+			expectedInfo: LicenseInfo{License: "Apache-2.0 OR BSD-3-Clause", Confidence: 0.9, Source: "METADATA"},
+		},
+		{
+			name:         "free text without a classifier",
+			distribution: "internal-tool",
+			version:      "1.0.0",
+			metadataDir:  "internal_tool-1.0.0.dist-info",
+			metadata:     "Name: internal-tool\nLicense: Proprietary\n",
+			expectedInfo: LicenseInfo{License: "Proprietary", Confidence: 0.4, Source: "METADATA"},
+		},
+		{
+			name:         "not installed",
+			distribution: "missing",
+			version:      "1.0.0",
+			metadataDir:  "other-1.0.0.dist-info",
+			metadata:     "Name: other\n",
+			expectedInfo: LicenseInfo{License: "Unknown", Confidence: 0.0, Source: "not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/venv/site-packages/"+tt.metadataDir+"/METADATA", tt.metadata)
+
+			detector := NewPythonInstalledDetectorWithFileSystem(fs, "/venv/site-packages")
+			info, err := detector.DetectLicense(tt.distribution, tt.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *info != tt.expectedInfo {
+				t.Errorf("expected %+v, got %+v", tt.expectedInfo, *info)
+			}
+		})
+	}
+}
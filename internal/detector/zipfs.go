@@ -0,0 +1,223 @@
+package detector
+
+import (
+	"archive/zip"
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxOpenArchives bounds how many archives a zipFileSystem keeps open. A
+// package's files are read one after the other, so only the archives of the
+// packages being detected concurrently need to stay open.
+const maxOpenArchives = 64
+
+// zipFileSystem exposes the contents of zip archives through virtual paths such
+// as `.yarn/cache/lodash-npm-4.17.21-abc.zip/node_modules/lodash/package.json`,
+// the layout used by Yarn Berry's Plug'n'Play cache. Paths without a `.zip`
+// segment are passed through to the underlying file system.
+type zipFileSystem struct {
+	base FileSystem
+
+	// mu guards the open archives, not the reads from them
+	mu       sync.Mutex
+	archives map[string]*openArchive
+	// order holds the archive paths, most recently used first
+	order *list.List
+}
+
+// openArchive is a zip archive, read in place from its file unless file is
+// nil. It is closed once evicted and no longer in use.
+type openArchive struct {
+	reader  *zip.Reader
+	file    io.Closer
+	element *list.Element
+	refs    int
+	evicted bool
+}
+
+func newZipFileSystem(base FileSystem) *zipFileSystem {
+	return &zipFileSystem{base: base, archives: make(map[string]*openArchive), order: list.New()}
+}
+
+func (z *zipFileSystem) Open(name string) (io.ReadCloser, error) {
+	archivePath, inner, ok := splitZipPath(name)
+	if !ok {
+		return z.base.Open(name)
+	}
+
+	archive, err := z.acquire(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := archive.reader.Open(inner)
+	if err != nil {
+		z.release(archive)
+		return nil, err
+	}
+	// The archive stays open until the entry is closed
+	return &archiveEntry{File: file, release: func() { z.release(archive) }}, nil
+}
+
+func (z *zipFileSystem) Stat(name string) (os.FileInfo, error) {
+	archivePath, inner, ok := splitZipPath(name)
+	if !ok {
+		return z.base.Stat(name)
+	}
+
+	archive, err := z.acquire(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer z.release(archive)
+	return fs.Stat(archive.reader, inner)
+}
+
+func (z *zipFileSystem) Join(elem ...string) string {
+	return z.base.Join(elem...)
+}
+
+// ReadDir lists a directory of the underlying file system or inside an archive
+func (z *zipFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	archivePath, inner, ok := splitZipPath(name)
+	if !ok {
+		lister, ok := z.base.(dirReader)
+		if !ok {
+			return nil, fmt.Errorf("cannot list %s", name)
+		}
+		return lister.ReadDir(name)
+	}
+
+	archive, err := z.acquire(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer z.release(archive)
+	return fs.ReadDir(archive.reader, inner)
+}
+
+// acquire returns the open archive at archivePath, opening it if needed; the
+// caller must release it. Archives are opened outside the lock, so a slow
+// open does not hold up reads from other archives.
+func (z *zipFileSystem) acquire(archivePath string) (*openArchive, error) {
+	z.mu.Lock()
+	if archive, ok := z.archives[archivePath]; ok {
+		archive.refs++
+		z.order.MoveToFront(archive.element)
+		z.mu.Unlock()
+		return archive, nil
+	}
+	z.mu.Unlock()
+
+	opened, err := z.open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	// Another reader may have opened the same archive meanwhile
+	if archive, ok := z.archives[archivePath]; ok {
+		opened.close()
+		archive.refs++
+		z.order.MoveToFront(archive.element)
+		return archive, nil
+	}
+
+	opened.refs = 1
+	opened.element = z.order.PushFront(archivePath)
+	z.archives[archivePath] = opened
+	for z.order.Len() > maxOpenArchives {
+		oldest := z.order.Back()
+		z.order.Remove(oldest)
+		evicted := z.archives[oldest.Value.(string)]
+		delete(z.archives, oldest.Value.(string))
+		evicted.evicted = true
+		if evicted.refs == 0 {
+			evicted.close()
+		}
+	}
+	return opened, nil
+}
+
+// release ends a use of an archive, closing it if it was evicted meanwhile
+func (z *zipFileSystem) release(archive *openArchive) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	archive.refs--
+	if archive.evicted && archive.refs == 0 {
+		archive.close()
+	}
+}
+
+func (a *openArchive) close() {
+	if a.file != nil {
+		_ = a.file.Close() // Ignore close error as the archive is only read
+	}
+}
+
+// open reads the zip directory of the archive at archivePath. Files that
+// support random access, like those of the real file system, are read in
+// place; others are read into memory.
+func (z *zipFileSystem) open(archivePath string) (*openArchive, error) {
+	file, err := z.base.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		if info, err := z.base.Stat(archivePath); err == nil {
+			reader, err := zip.NewReader(readerAt, info.Size())
+			if err != nil {
+				_ = file.Close() // Ignore close error as the archive is invalid
+				return nil, err
+			}
+			return &openArchive{reader: reader, file: file}, nil
+		}
+	}
+
+	data, err := io.ReadAll(file)
+	_ = file.Close() // Ignore close error as we already read the file
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return &openArchive{reader: reader}, nil
+}
+
+// archiveEntry is a file inside an archive, releasing the archive on Close
+type archiveEntry struct {
+	fs.File
+	release func()
+	once    sync.Once
+}
+
+func (e *archiveEntry) Close() error {
+	err := e.File.Close()
+	e.once.Do(e.release)
+	return err
+}
+
+// splitZipPath splits a virtual path into the archive path and the slash
+// separated path of the entry inside it
+func splitZipPath(name string) (string, string, bool) {
+	normalized := filepath.ToSlash(name)
+	index := strings.Index(normalized, ".zip/")
+	if index < 0 {
+		return "", "", false
+	}
+
+	archivePath := name[:index+len(".zip")]
+	inner := path.Clean(normalized[index+len(".zip/"):])
+	return archivePath, inner, true
+}
@@ -0,0 +1,84 @@
+package detector
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestZipFileSystem_BoundedArchives(t *testing.T) {
+	// Archives on the real file system are read in place
+	dir := t.TempDir()
+	for i := range maxOpenArchives + 8 {
+		archive := buildZip(t, map[string]string{"node_modules/pkg/package.json": fmt.Sprintf(`{"version": "%d.0.0"}`, i)})
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("pkg-%d.zip", i)), archive, 0o644); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+	}
+	z := newZipFileSystem(&RealFileSystem{})
+
+	// An entry opened before its archive is evicted stays readable
+	first, err := z.Open(filepath.Join(dir, "pkg-0.zip", "node_modules", "pkg", "package.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 1; i < maxOpenArchives+8; i++ {
+		if _, err := z.Stat(filepath.Join(dir, fmt.Sprintf("pkg-%d.zip", i), "node_modules", "pkg", "package.json")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(z.archives) != maxOpenArchives || z.order.Len() != maxOpenArchives {
+		t.Errorf("expected %d open archives, got %d", maxOpenArchives, len(z.archives))
+	}
+
+	data, err := io.ReadAll(first)
+	if err != nil || string(data) != `{"version": "0.0.0"}` {
+		t.Errorf("expected the evicted archive's entry to be readable, got %q (%v)", data, err)
+	}
+	if err := first.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+
+	// Evicted archives are opened again on demand
+	entries, err := z.ReadDir(filepath.Join(dir, "pkg-0.zip", "node_modules", "pkg"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected the reopened archive to list package.json, got %v (%v)", entries, err)
+	}
+}
+
+func TestZipFileSystem_InMemory(t *testing.T) {
+	// The mock file system has no random access, so archives are read into memory
+	fs := NewMockFileSystem()
+	fs.AddFile("cache/pkg.zip", string(buildZip(t, map[string]string{"node_modules/pkg/LICENSE": "ISC License"})))
+
+	info, err := NewWithFileSystem(fs).DetectLicense("cache/pkg.zip/node_modules/pkg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.License != "ISC" {
+		t.Errorf("expected ISC from the zipped LICENSE, got %+v", info)
+	}
+}
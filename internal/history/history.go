@@ -0,0 +1,165 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// uncommittedSHA is reported by git blame for lines not committed yet
+const uncommittedSHA = "0000000000000000000000000000000000000000"
+
+// Introduction is the commit that added a dependency to the lock file
+type Introduction struct {
+	Commit string `json:"commit"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
+}
+
+// Blame attributes every line of a file to the commit that last changed it
+type Blame struct {
+	lines   []string
+	commits []Introduction
+}
+
+// BlameFile runs git blame on a file inside a git work tree
+func BlameFile(path string) (*Blame, error) {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame failed for %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return parsePorcelain(output)
+}
+
+// parsePorcelain reads git blame --line-porcelain output, where every line
+// of the file is preceded by the full header of its commit
+func parsePorcelain(output []byte) (*Blame, error) {
+	blame := &Blame{}
+	var current Introduction
+	expectHeader := true
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if expectHeader {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected git blame header: %q", line)
+			}
+			current = Introduction{Commit: fields[0]}
+			expectHeader = false
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			blame.lines = append(blame.lines, line[1:])
+			blame.commits = append(blame.commits, current)
+			expectHeader = true
+		case strings.HasPrefix(line, "author "):
+			current.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				current.Date = time.Unix(seconds, 0).UTC().Format("2006-01-02")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read git blame output: %w", err)
+	}
+
+	return blame, nil
+}
+
+// Introduced returns the commit that added the lock file entry declaring
+// name@version. The entry's key line is used, so later version bumps within
+// the same entry do not move the attribution.
+func (b *Blame) Introduced(packageManager, name, version string) (Introduction, bool) {
+	line := declarationLine(b.lines, packageManager, name, version)
+	if line < 0 || b.commits[line].Commit == uncommittedSHA {
+		return Introduction{}, false
+	}
+	return b.commits[line], true
+}
+
+// declarationLine finds the line declaring a dependency in a lock file, or -1
+func declarationLine(lines []string, packageManager, name, version string) int {
+	switch packageManager {
+	case constants.PackageManagerYarn:
+		return yarnDeclarationLine(lines, name, version)
+	case constants.PackageManagerPnpm:
+		for i, line := range lines {
+			key := strings.Trim(strings.TrimSpace(line), `'"`)
+			key = strings.TrimPrefix(key, "/")
+			for _, prefix := range []string{name + "@" + version, name + "/" + version} {
+				if strings.HasPrefix(key, prefix) && len(key) > len(prefix) && strings.ContainsRune(":(_'\"", rune(key[len(prefix)])) {
+					return i
+				}
+			}
+		}
+	case constants.PackageManagerBower:
+		return firstLineWithPrefix(lines, `"`+name+`":`)
+	default:
+		// Prefer the hoisted copy, then nested copies, then lockfile v1 entries
+		if i := firstLineWithPrefix(lines, `"node_modules/`+name+`":`); i >= 0 {
+			return i
+		}
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), `"`) && strings.Contains(line, `/node_modules/`+name+`":`) {
+				return i
+			}
+		}
+		for i, line := range lines {
+			if strings.TrimSpace(line) == `"`+name+`": {` {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// yarnDeclarationLine returns the header of the yarn.lock block for
+// name@version; a package has one block per resolved version
+func yarnDeclarationLine(lines []string, name, version string) int {
+	header := -1
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			header = -1
+			entry := strings.TrimPrefix(line, `"`)
+			if strings.HasPrefix(entry, name+"@") {
+				header = i
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if header >= 0 && (trimmed == `version "`+version+`"` || trimmed == `version: `+version) {
+			return header
+		}
+	}
+	return -1
+}
+
+func firstLineWithPrefix(lines []string, prefix string) int {
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+			return i
+		}
+	}
+	return -1
+}
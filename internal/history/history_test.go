@@ -0,0 +1,129 @@
+package history
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// commit writes a file and commits it with a fixed author and date
+func commit(t *testing.T, dir, name, content, author, date string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	for _, args := range [][]string{
+		{"add", name},
+		{"commit", "-q", "-m", "update " + name},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL=dev@example.com", "GIT_AUTHOR_DATE="+date,
+			"GIT_COMMITTER_NAME="+author, "GIT_COMMITTER_EMAIL=dev@example.com", "GIT_COMMITTER_DATE="+date,
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+}
+
+func TestBlameFile_Introduced(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	init := exec.Command("git", "init", "-q")
+	init.Dir = dir
+	if output, err := init.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, output)
+	}
+
+	commit(t, dir, "package-lock.json", `{
+  "packages": {
+    "node_modules/react": {
+      "version": "18.2.0"
+    }
+  }
+}
+`, "Alice", "2023-01-10T12:00:00Z")
+
+	commit(t, dir, "package-lock.json", `{
+  "packages": {
+    "node_modules/react": {
+      "version": "18.3.0"
+    },
+    "node_modules/gpl-package": {
+      "version": "1.0.0"
+    }
+  }
+}
+`, "Bob", "2024-05-20T12:00:00Z")
+
+	blame, err := BlameFile(filepath.Join(dir, "package-lock.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gpl, ok := blame.Introduced("npm", "gpl-package", "1.0.0")
+	if !ok || gpl.Author != "Bob" || gpl.Date != "2024-05-20" || len(gpl.Commit) != 40 {
+		t.Errorf("expected gpl-package introduced by Bob on 2024-05-20, got %+v", gpl)
+	}
+
+	// The version bump does not move the introduction of react
+	react, ok := blame.Introduced("npm", "react", "18.3.0")
+	if !ok || react.Author != "Alice" || react.Date != "2023-01-10" {
+		t.Errorf("expected react introduced by Alice on 2023-01-10, got %+v", react)
+	}
+
+	if _, ok := blame.Introduced("npm", "missing", "1.0.0"); ok {
+		t.Error("expected no introduction for a package not in the lock file")
+	}
+}
+
+func TestDeclarationLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		manager  string
+		lines    []string
+		pkg      string
+		version  string
+		expected int
+	}{
+		{
+			name:    "yarn block matching the version",
+			manager: "yarn",
+			lines: []string{
+				`lodash@^3.0.0:`, `  version "3.10.1"`, ``,
+				`"lodash@^4.0.0", lodash@^4.17.0:`, `  version "4.17.21"`,
+			},
+			pkg: "lodash", version: "4.17.21", expected: 3,
+		},
+		{
+			name:     "pnpm key with peer suffix",
+			manager:  "pnpm",
+			lines:    []string{`packages:`, `  /react-dom@18.2.0(react@18.2.0):`, `  /react@18.2.0:`},
+			pkg:      "react",
+			version:  "18.2.0",
+			expected: 2,
+		},
+		{
+			name:     "npm nested copy",
+			manager:  "npm",
+			lines:    []string{`{`, `  "packages": {`, `    "node_modules/express/node_modules/debug": {`},
+			pkg:      "debug",
+			version:  "2.6.9",
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if line := declarationLine(tt.lines, tt.manager, tt.pkg, tt.version); line != tt.expected {
+				t.Errorf("expected line %d, got %d", tt.expected, line)
+			}
+		})
+	}
+}
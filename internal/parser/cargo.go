@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CargoParser implements parsing for Cargo.lock files of Rust projects
+type CargoParser struct {
+	fs FileSystem
+}
+
+func NewCargoParser() *CargoParser {
+	return &CargoParser{fs: &RealFileSystem{}}
+}
+
+func NewCargoParserWithFS(fs FileSystem) *CargoParser {
+	return &CargoParser{fs: fs}
+}
+
+// cargoPackage is a [[package]] entry of Cargo.lock
+type cargoPackage struct {
+	name         string
+	version      string
+	source       string
+	dependencies []string
+}
+
+// Parse reads the [[package]] entries of a Cargo.lock. Crates without a
+// source are the project's own workspace and path crates: they are left
+// out, and what they depend on is direct.
+func (p *CargoParser) Parse(lockFilePath string) ([]Dependency, error) {
+	file, err := p.fs.Open(lockFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Cargo.lock: %w", err)
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	var packages []cargoPackage
+	var current *cargoPackage
+	inDependencies := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Any other table, such as the [metadata] of old lock files, ends
+		// the current package
+		if strings.HasPrefix(line, "[") && !inDependencies {
+			current = nil
+			if line == "[[package]]" {
+				packages = append(packages, cargoPackage{})
+				current = &packages[len(packages)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if inDependencies {
+			for _, item := range strings.Split(strings.TrimSuffix(line, "]"), ",") {
+				if spec := unquoteTOML(item); spec != "" {
+					// Entries are "name", "name version" or "name version (source)"
+					current.dependencies = mergeUnique(current.dependencies, []string{strings.Fields(spec)[0]})
+				}
+			}
+			inDependencies = !strings.HasSuffix(line, "]")
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "name":
+			current.name = unquoteTOML(value)
+		case "version":
+			current.version = unquoteTOML(value)
+		case "source":
+			current.source = unquoteTOML(value)
+		case "dependencies":
+			inDependencies = strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]")
+			for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if spec := unquoteTOML(item); spec != "" {
+					current.dependencies = mergeUnique(current.dependencies, []string{strings.Fields(spec)[0]})
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Cargo.lock: %w", err)
+	}
+
+	var dependencies []Dependency
+	var direct []string
+	for _, pkg := range packages {
+		if pkg.name == "" {
+			continue
+		}
+		if pkg.source == "" {
+			direct = mergeUnique(direct, pkg.dependencies)
+			continue
+		}
+		dependencies = append(dependencies, Dependency{
+			Name:         pkg.name,
+			Version:      pkg.version,
+			Dependencies: pkg.dependencies,
+		})
+	}
+	assignDepths(dependencies, direct)
+
+	return dependencies, nil
+}
+
+// unquoteTOML returns the value of a basic TOML string, or the trimmed input
+// if it is not quoted
+func unquoteTOML(value string) string {
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return value
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCargoParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/Cargo.lock", `# This file is automatically @generated by Cargo.
+# It is not intended for manual editing.
+version = 3
+
+[[package]]
+name = "myapp"
+version = "0.1.0"
+dependencies = [
+ "anyhow",
+ "serde 1.0.188",
+ "utils",
+]
+
+[[package]]
+name = "anyhow"
+version = "1.0.75"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "a4668cab20f66d8d020e1fbc0ebe47217433c1b6c8f2040faf858554e394ace6"
+
+[[package]]
+name = "serde"
+version = "1.0.188"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = ["serde_derive 1.0.188 (registry+https://github.com/rust-lang/crates.io-index)"]
+
+[[package]]
+name = "serde_derive"
+version = "1.0.188"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "utils"
+version = "0.2.0"
+
+[metadata]
+"checksum anyhow 1.0.75" = "a4668cab"
+`)
+
+	deps, err := NewCargoParserWithFS(fs).Parse("/project/Cargo.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The project's own crates are left out
+	expected := []Dependency{
+		{Name: "anyhow", Version: "1.0.75", Depth: 1},
+		{Name: "serde", Version: "1.0.188", Dependencies: []string{"serde_derive"}, Depth: 1},
+		{Name: "serde_derive", Version: "1.0.188", Depth: 2},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestCargoParser_Parse_Missing(t *testing.T) {
+	if _, err := NewCargoParserWithFS(NewMockFileSystem()).Parse("/project/Cargo.lock"); err == nil {
+		t.Error("expected an error for a missing Cargo.lock")
+	}
+}
+
+func TestDetectLockFile_Cargo(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/Cargo.lock", "version = 3\n")
+
+	path, manager, err := DetectLockFile(fs, "/project")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/project/Cargo.lock" || manager != "cargo" {
+		t.Errorf("expected Cargo.lock managed by cargo, got %s (%s)", path, manager)
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GoModParser implements parsing for the go.mod files of Go modules. Since
+// Go 1.17 go.mod requires every module in the build, so go.sum adds nothing
+// but checksums.
+type GoModParser struct {
+	fs FileSystem
+}
+
+func NewGoModParser() *GoModParser {
+	return &GoModParser{fs: &RealFileSystem{}}
+}
+
+func NewGoModParserWithFS(fs FileSystem) *GoModParser {
+	return &GoModParser{fs: fs}
+}
+
+// Parse reads the require directives of a go.mod, single line or blocks.
+// Modules marked "// indirect" are transitive, the others direct.
+func (p *GoModParser) Parse(goModPath string) ([]Dependency, error) {
+	file, err := p.fs.Open(goModPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open go.mod: %w", err)
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	var dependencies []Dependency
+	inRequire := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+
+		var spec string
+		switch {
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case inRequire:
+			spec = line
+		case line == "require (":
+			inRequire = true
+			continue
+		default:
+			rest, found := strings.CutPrefix(line, "require ")
+			if !found {
+				continue
+			}
+			spec = rest
+		}
+
+		fields := strings.Fields(spec)
+		if len(fields) != 2 {
+			continue
+		}
+		module := fields[0]
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+		indirect := strings.HasPrefix(strings.TrimSpace(comment), "indirect")
+		dep := Dependency{
+			Name:    module,
+			Version: fields[1],
+			Depth:   1,
+			Direct:  !indirect,
+		}
+		// go.mod does not record the graph; indirect modules are at least a
+		// level further down
+		if indirect {
+			dep.Depth = 2
+		}
+		dependencies = append(dependencies, dep)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	return dependencies, nil
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoModParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/go.mod", `module example.com/app
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	golang.org/x/text v0.14.0 // indirect
+	"example.com/quoted" v0.1.0 // indirect; pulled in by cobra
+)
+
+exclude golang.org/x/net v0.1.0
+
+replace example.com/local => ../local
+`)
+
+	deps, err := NewGoModParserWithFS(fs).Parse("/project/go.mod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Dependency{
+		{Name: "github.com/spf13/cobra", Version: "v1.8.0", Depth: 1, Direct: true},
+		{Name: "github.com/BurntSushi/toml", Version: "v1.3.2", Depth: 1, Direct: true},
+		{Name: "golang.org/x/text", Version: "v0.14.0", Depth: 2},
+		{Name: "example.com/quoted", Version: "v0.1.0", Depth: 2},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestDetectLockFile_GoMod(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/go.mod", "module example.com/app\n")
+	fs.AddFile("/project/go.sum", "")
+
+	path, manager, err := DetectLockFile(fs, "/project")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/project/go.mod" || manager != "go" {
+		t.Errorf("expected go.mod managed by go, got %s (%s)", path, manager)
+	}
+}
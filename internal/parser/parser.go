@@ -2,24 +2,55 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/StefanoA1/license-scanner/internal/constants"
 	"gopkg.in/yaml.v3"
 )
 
 type Dependency struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	License string `json:"license,omitempty"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	License      string   `json:"license,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"` // Names of direct dependencies (graph edges)
+	Ranges       []string `json:"ranges,omitempty"`       // Version ranges resolved to this entry
+	Depth        int      `json:"depth,omitempty"`        // 1 for direct dependencies, 2 for theirs, etc.
+	Path         string   `json:"path,omitempty"`         // Install path relative to the project root, when known
+	Local        string   `json:"local,omitempty"`        // Referenced path of a file: or link: dependency, relative to the project root
+	Dev          bool     `json:"dev,omitempty"`          // Only installed for development, not shipped
+	Alias        string   `json:"alias,omitempty"`        // Real name of a package installed under an npm: alias
+	Unresolved   bool     `json:"unresolved,omitempty"`   // Version is a package.json range as no lock file resolved it
+	Direct       bool     `json:"direct,omitempty"`       // Listed in the root package.json, see ParseDirect
 }
 
+// localSpecPrefixes mark dependencies installed from the local file system
+var localSpecPrefixes = []string{"file:", "link:"}
+
+// localPath returns the path referenced by a file: or link: specifier
+func localPath(spec string) (string, bool) {
+	for _, prefix := range localSpecPrefixes {
+		if path, found := strings.CutPrefix(spec, prefix); found {
+			// Yarn Berry appends the workspace that declared the link
+			path, _, _ = strings.Cut(path, "::")
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// FileSystem abstracts file access; implementations must be safe for
+// concurrent use, as the scanner detects licenses in parallel
 type FileSystem interface {
 	Open(path string) (io.ReadCloser, error)
 	Stat(path string) (os.FileInfo, error)
@@ -40,6 +71,15 @@ func (fs *RealFileSystem) Join(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+func (fs *RealFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+// DirReader is implemented by file systems that can list directories
+type DirReader interface {
+	ReadDir(path string) ([]os.DirEntry, error)
+}
+
 type LockFileParser interface {
 	Parse(lockFilePath string) ([]Dependency, error)
 }
@@ -52,8 +92,15 @@ func DetectLockFile(fs FileSystem, rootPath string) (string, string, error) {
 		packageManager string
 	}{
 		{constants.PackageLockJSON, constants.PackageManagerNPM},
+		{constants.ShrinkwrapJSON, constants.PackageManagerNPM}, // Same format, published with the package
 		{constants.YarnLock, constants.PackageManagerYarn},
 		{constants.PnpmLockYAML, constants.PackageManagerPnpm},
+		{constants.CargoLock, constants.PackageManagerCargo},
+		{constants.GoModFile, constants.PackageManagerGo}, // go.sum only holds checksums
+		{constants.PoetryLock, constants.PackageManagerPoetry},
+		{constants.PipfileLock, constants.PackageManagerPipenv},
+		{constants.RequirementsTxt, constants.PackageManagerPip},
+		{constants.BowerJSONFile, constants.PackageManagerBower}, // Manifest only, lowest precedence
 	}
 
 	for _, lockFile := range lockFiles {
@@ -70,7 +117,7 @@ func DetectLockFileDefault(rootPath string) (string, string, error) {
 	return DetectLockFile(&RealFileSystem{}, rootPath)
 }
 
-// NPMParser implements parsing for package-lock.json files
+// NPMParser implements parsing for package-lock.json and npm-shrinkwrap.json files
 type NPMParser struct {
 	fs FileSystem
 }
@@ -86,7 +133,7 @@ func NewNPMParserWithFS(fs FileSystem) *NPMParser {
 func (p *NPMParser) Parse(lockFilePath string) ([]Dependency, error) {
 	file, err := p.fs.Open(lockFilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open package-lock.json: %w", err)
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(lockFilePath), err)
 	}
 	defer func() {
 		_ = file.Close() // Ignore close error as we already read the file
@@ -94,20 +141,27 @@ func (p *NPMParser) Parse(lockFilePath string) ([]Dependency, error) {
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read package-lock.json: %w", err)
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(lockFilePath), err)
 	}
 
 	var lockFile NPMLockFile
 	if err := json.Unmarshal(data, &lockFile); err != nil {
-		return nil, fmt.Errorf("failed to parse package-lock.json: %w", err)
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(lockFilePath), err)
 	}
 
 	var dependencies []Dependency
-
-	// Parse dependencies from the packages section (npm v2+ format)
-	for packagePath, pkg := range lockFile.Packages {
-		// Skip the root package (empty path)
+	var direct []string
+
+	// Parse dependencies from the packages section (npm v2+ format), sorted
+	// by install path as npm writes them, so map iteration cannot reorder
+	// reports between runs
+	for _, packagePath := range sortedKeys(lockFile.Packages) {
+		pkg := lockFile.Packages[packagePath]
+		// The root package (empty path) declares the direct dependencies
 		if packagePath == "" {
+			direct = append(direct, sortedKeys(pkg.Dependencies)...)
+			direct = append(direct, sortedKeys(pkg.DevDependencies)...)
+			direct = append(direct, sortedKeys(pkg.OptionalDependencies)...)
 			continue
 		}
 
@@ -117,11 +171,30 @@ func (p *NPMParser) Parse(lockFilePath string) ([]Dependency, error) {
 			continue
 		}
 
-		dependencies = append(dependencies, Dependency{
-			Name:    name,
-			Version: pkg.Version,
-			License: pkg.License,
-		})
+		dep := Dependency{
+			Name:         name,
+			Version:      pkg.Version,
+			License:      pkg.License,
+			Dependencies: sortedKeys(pkg.Dependencies),
+			Path:         packagePath,
+			Dev:          pkg.Dev,
+		}
+		// Links point at the package's own entry, keyed by its location;
+		// file: tarballs are copied into node_modules
+		if pkg.Link {
+			dep.Local = pkg.Resolved
+			if target, ok := lockFile.Packages[pkg.Resolved]; ok {
+				dep.Version = target.Version
+				dep.License = target.License
+				dep.Dependencies = sortedKeys(target.Dependencies)
+			}
+		} else if local, ok := localPath(pkg.Resolved); ok {
+			dep.Local = local
+		}
+		if pkg.Name != "" && pkg.Name != name {
+			dep.Alias = pkg.Name
+		}
+		dependencies = append(dependencies, dep)
 	}
 
 	// Fallback to legacy dependencies format if packages section is empty
@@ -129,6 +202,8 @@ func (p *NPMParser) Parse(lockFilePath string) ([]Dependency, error) {
 		dependencies = parseLegacyDependencies(lockFile.Dependencies)
 	}
 
+	assignDepths(dependencies, direct)
+
 	return dependencies, nil
 }
 
@@ -141,12 +216,21 @@ type NPMLockFile struct {
 }
 
 type NPMPackage struct {
-	Version string `json:"version"`
-	License string `json:"license"`
+	Name                 string            `json:"name"` // Set for aliased packages
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Link                 bool              `json:"link"`
+	Dev                  bool              `json:"dev"`
+	License              string            `json:"license"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
 }
 
 type NPMDependency struct {
 	Version      string                   `json:"version"`
+	Dev          bool                     `json:"dev"`
+	Requires     map[string]string        `json:"requires"`
 	Dependencies map[string]NPMDependency `json:"dependencies"`
 }
 
@@ -159,6 +243,11 @@ func extractPackageName(packagePath string) string {
 
 	name := packagePath[len(prefix):]
 
+	// Nested (non-hoisted) copies live under their parent: use the innermost package
+	if nested := strings.LastIndex(name, "/"+prefix); nested >= 0 {
+		name = name[nested+len(prefix)+1:]
+	}
+
 	// Handle scoped packages (@scope/package)
 	if len(name) > 0 && name[0] == '@' {
 		parts := strings.Split(name, "/")
@@ -176,25 +265,104 @@ func extractPackageName(packagePath string) string {
 	return name
 }
 
+// legacyParallelThreshold is the number of top-level entries above which legacy
+// trees are traversed by several workers
+const legacyParallelThreshold = 64
+
+// parseLegacyDependencies flattens a lockfile v1 dependency tree. The traversal
+// is iterative to avoid deep recursion, and shared subtrees (diamonds) are
+// visited once so each name@version appears only once.
 func parseLegacyDependencies(deps map[string]NPMDependency) []Dependency {
+	var mu sync.Mutex
+	visited := make(map[string]bool)
 	var dependencies []Dependency
 
-	for name, dep := range deps {
+	// markVisited reports whether name@version was not seen before
+	markVisited := func(name string, dep NPMDependency) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		key := name + "@" + dep.Version
+		if visited[key] {
+			return false
+		}
+		visited[key] = true
+		local, _ := localPath(dep.Version)
 		dependencies = append(dependencies, Dependency{
-			Name:    name,
-			Version: dep.Version,
+			Name:         name,
+			Version:      dep.Version,
+			Dependencies: sortedKeys(dep.Requires),
+			Local:        local,
+			Dev:          dep.Dev,
 		})
+		return true
+	}
 
-		// Recursively parse nested dependencies
-		if dep.Dependencies != nil {
-			nested := parseLegacyDependencies(dep.Dependencies)
-			dependencies = append(dependencies, nested...)
+	type entry struct {
+		name string
+		dep  NPMDependency
+	}
+	walk := func(root entry) {
+		stack := []entry{root}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !markVisited(current.name, current.dep) {
+				continue
+			}
+			for name, nested := range current.dep.Dependencies {
+				stack = append(stack, entry{name: name, dep: nested})
+			}
 		}
 	}
 
+	roots := make([]entry, 0, len(deps))
+	for _, name := range sortedDependencyNames(deps) {
+		roots = append(roots, entry{name: name, dep: deps[name]})
+	}
+
+	if len(roots) < legacyParallelThreshold {
+		for _, root := range roots {
+			walk(root)
+		}
+	} else {
+		work := make(chan entry)
+		var wg sync.WaitGroup
+		for i := 0; i < runtime.NumCPU(); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for root := range work {
+					walk(root)
+				}
+			}()
+		}
+		for _, root := range roots {
+			work <- root
+		}
+		close(work)
+		wg.Wait()
+	}
+
+	// Workers finish in any order, so sort for deterministic output
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].Name != dependencies[j].Name {
+			return dependencies[i].Name < dependencies[j].Name
+		}
+		return dependencies[i].Version < dependencies[j].Version
+	})
+
 	return dependencies
 }
 
+func sortedDependencyNames(deps map[string]NPMDependency) []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // PnpmParser implements parsing for pnpm-lock.yaml files
 type PnpmParser struct {
 	fs FileSystem
@@ -228,33 +396,105 @@ func (p *PnpmParser) Parse(lockFilePath string) ([]Dependency, error) {
 	}
 
 	var dependencies []Dependency
+	index := make(map[string]int)
 
-	// Parse packages from the packages section
-	for packageKey := range lockFile.Packages {
+	// Parse packages from the packages section, sorted by key so reports
+	// are stable between runs
+	for _, packageKey := range sortedKeys(lockFile.Packages) {
+		pkg := lockFile.Packages[packageKey]
 		name, version := extractPnpmPackageInfo(packageKey)
+		// Local directories and tarballs are keyed by their file: path
+		local, isLocal := localPath(version)
+		if spec := strings.TrimPrefix(packageKey, "/"); strings.HasPrefix(spec, "file:") {
+			name, version = pkg.Name, pkg.Version
+			local, isLocal = localPath(spec)
+		}
 		if name == "" {
 			continue
 		}
 
-		dependencies = append(dependencies, Dependency{
-			Name:    name,
-			Version: version,
-			License: "", // License info not typically in pnpm lock file
-		})
+		// Peer dependency variants of a package are the same installed version
+		if i, exists := index[name+"@"+version]; exists {
+			dependencies[i].Dependencies = mergeUnique(dependencies[i].Dependencies, sortedKeys(pkg.Dependencies))
+			dependencies[i].Dev = dependencies[i].Dev && pkg.Dev
+			if dependencies[i].License == "" {
+				dependencies[i].License = pkg.License
+			}
+			continue
+		}
+		index[name+"@"+version] = len(dependencies)
+
+		dep := Dependency{
+			Name:         name,
+			Version:      version,
+			License:      pkg.License,
+			Dependencies: sortedKeys(pkg.Dependencies),
+			Dev:          pkg.Dev,
+		}
+		if isLocal {
+			dep.Local = local
+		}
+		dependencies = append(dependencies, dep)
+	}
+
+	// Workspaces declare the dependencies of every member as an importer,
+	// keyed by the member directory
+	importers := map[string]PnpmImporter{".": {Dependencies: lockFile.Dependencies, DevDependencies: lockFile.DevDependencies}}
+	for importerPath, importer := range lockFile.Importers {
+		importers[importerPath] = importer
+	}
+
+	// link: dependencies are symlinked without a packages entry; their path
+	// is relative to the importer
+	var direct []string
+	linked := make(map[string]bool)
+	for _, importerPath := range sortedImporterPaths(importers) {
+		importer := importers[importerPath]
+		for i, declared := range []map[string]string{importer.Dependencies, importer.DevDependencies} {
+			direct = mergeUnique(direct, sortedKeys(declared))
+			for _, name := range sortedKeys(declared) {
+				local, found := strings.CutPrefix(declared[name], "link:")
+				if !found || linked[name] {
+					continue
+				}
+				linked[name] = true
+				dependencies = append(dependencies, Dependency{Name: name, Version: declared[name], Local: path.Join(importerPath, local), Dev: i == 1})
+			}
+		}
 	}
+	assignDepths(dependencies, direct)
 
 	return dependencies, nil
 }
 
 // PnpmLockFile represents the structure of pnpm-lock.yaml
 type PnpmLockFile struct {
-	LockfileVersion string                 `yaml:"lockfileVersion"`
-	Dependencies    map[string]string      `yaml:"dependencies"`
-	DevDependencies map[string]string      `yaml:"devDependencies"`
-	Packages        map[string]PnpmPackage `yaml:"packages"`
+	LockfileVersion string                  `yaml:"lockfileVersion"`
+	Dependencies    map[string]string       `yaml:"dependencies"`
+	DevDependencies map[string]string       `yaml:"devDependencies"`
+	Importers       map[string]PnpmImporter `yaml:"importers"`
+	Packages        map[string]PnpmPackage  `yaml:"packages"`
+}
+
+// PnpmImporter holds the direct dependencies of a workspace member
+type PnpmImporter struct {
+	Dependencies    map[string]string `yaml:"dependencies"`
+	DevDependencies map[string]string `yaml:"devDependencies"`
+}
+
+func sortedImporterPaths(importers map[string]PnpmImporter) []string {
+	paths := make([]string, 0, len(importers))
+	for importerPath := range importers {
+		paths = append(paths, importerPath)
+	}
+	sort.Strings(paths)
+	return paths
 }
 
 type PnpmPackage struct {
+	Name         string            `yaml:"name"`    // Set for file: packages
+	Version      string            `yaml:"version"` // Set for file: packages
+	License      string            `yaml:"license"`
 	Resolution   PnpmResolution    `yaml:"resolution"`
 	Dependencies map[string]string `yaml:"dependencies"`
 	Dev          bool              `yaml:"dev"`
@@ -265,17 +505,25 @@ type PnpmResolution struct {
 	Tarball   string `yaml:"tarball"`
 }
 
+// extractPnpmPackageInfo splits a pnpm package key into the package name and
+// its version without peer suffix. Lockfile v6 and later key packages as
+// "/name@version", lockfile v5 as "/name/version".
 func extractPnpmPackageInfo(packageKey string) (name, version string) {
 	// pnpm package keys are in format like "/package-name@1.0.0" or "/@scope/package@1.0.0"
 	// Remove leading slash if present
 	key := strings.TrimPrefix(packageKey, "/")
 
+	// Lockfile v5 separates the version with a slash: "/@scope/package/1.0.0"
+	if matches := pnpmV5KeyPattern.FindStringSubmatch(key); matches != nil {
+		return matches[1], stripPnpmPeerSuffix(matches[2])
+	}
+
 	// Handle scoped packages first
 	if strings.HasPrefix(key, "@") {
 		re := regexp.MustCompile(`^(@[^/]+/[^@]+)@(.+)$`)
 		matches := re.FindStringSubmatch(key)
 		if len(matches) == 3 {
-			return matches[1], matches[2]
+			return matches[1], stripPnpmPeerSuffix(matches[2])
 		}
 	}
 
@@ -283,12 +531,24 @@ func extractPnpmPackageInfo(packageKey string) (name, version string) {
 	re := regexp.MustCompile(`^([^@]+)@(.+)$`)
 	matches := re.FindStringSubmatch(key)
 	if len(matches) == 3 {
-		return matches[1], matches[2]
+		return matches[1], stripPnpmPeerSuffix(matches[2])
 	}
 
 	return "", ""
 }
 
+// pnpmV5KeyPattern matches lockfile v5 package keys, name then version
+var pnpmV5KeyPattern = regexp.MustCompile(`^((?:@[^/]+/)?[^/@]+)/(\d[^/]*)$`)
+
+// stripPnpmPeerSuffix removes the peer dependency suffix pnpm appends to
+// versions, either "1.0.0_bar@2.0.0" (lockfile v5/v6) or "1.0.0(react@18.2.0)"
+func stripPnpmPeerSuffix(version string) string {
+	if index := strings.IndexAny(version, "_("); index > 0 {
+		return version[:index]
+	}
+	return version
+}
+
 // YarnParser implements parsing for yarn.lock files
 type YarnParser struct {
 	fs FileSystem
@@ -311,14 +571,70 @@ func (p *YarnParser) Parse(lockFilePath string) ([]Dependency, error) {
 		_ = file.Close() // Ignore close error as we already read the file
 	}()
 
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read yarn.lock: %w", err)
+	}
+
 	var dependencies []Dependency
-	scanner := bufio.NewScanner(file)
+	if isYarnBerryLock(data) {
+		dependencies, err = parseYarnBerry(data)
+	} else {
+		dependencies, err = parseYarnClassic(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// yarn.lock does not record the root manifest, so roots are inferred
+	assignDepths(dependencies, nil)
+
+	// Nor which packages are dev only: that follows from the package.json
+	// next to it, when there is one
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := readJSON(p.fs, p.fs.Join(filepath.Dir(lockFilePath), constants.PackageJSONFile), &manifest); err == nil {
+		prod := append(sortedKeys(manifest.Dependencies), sortedKeys(manifest.OptionalDependencies)...)
+		markDev(dependencies, prod, sortedKeys(manifest.DevDependencies))
+	}
+
+	return dependencies, nil
+}
+
+// parseYarnClassic parses the custom format of Yarn 1 lock files
+func parseYarnClassic(data []byte) ([]Dependency, error) {
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 
 	// Regular expressions for parsing yarn.lock format
-	packageRe := regexp.MustCompile(`^"?([^@\s"]+|@[^/]+/[^@\s"]+)@([^"]*)"?:$`)
+	packageRe := regexp.MustCompile(`^"?([^@\s"]+|@[^/]+/[^@\s"]+)@(.*?)"?:$`)
 	versionRe := regexp.MustCompile(`^\s+version\s+"([^"]+)"$`)
+	licenseRe := regexp.MustCompile(`^\s+license\s+"?([^"]+?)"?$`)
+	dependenciesRe := regexp.MustCompile(`^\s+dependencies:$`)
+	dependencyRe := regexp.MustCompile(`^\s{4}"?([^"\s]+)"?\s+`)
 
 	var currentPackage *Dependency
+	inDependencies := false
+
+	// The same name@version can be resolved several times (e.g. different peer
+	// contexts), so entries are merged instead of emitted twice
+	seen := make(map[string]int)
+	flush := func() {
+		if currentPackage == nil {
+			return
+		}
+		key := currentPackage.Name + "@" + currentPackage.Version
+		if i, exists := seen[key]; exists {
+			dependencies[i].Ranges = mergeUnique(dependencies[i].Ranges, currentPackage.Ranges)
+			dependencies[i].Dependencies = mergeUnique(dependencies[i].Dependencies, currentPackage.Dependencies)
+			return
+		}
+		seen[key] = len(dependencies)
+		dependencies = append(dependencies, *currentPackage)
+	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -326,27 +642,43 @@ func (p *YarnParser) Parse(lockFilePath string) ([]Dependency, error) {
 		// Check for package declaration line
 		if matches := packageRe.FindStringSubmatch(line); matches != nil {
 			// Save previous package if exists
-			if currentPackage != nil {
-				dependencies = append(dependencies, *currentPackage)
-			}
+			flush()
 
 			// Start new package
 			currentPackage = &Dependency{
 				Name:    matches[1],
-				License: "", // License info not typically in yarn.lock
+				License: "", // Set below when the entry records one
+				Ranges:  parseYarnRanges(strings.TrimSuffix(line, ":")),
 			}
+			for _, spec := range currentPackage.Ranges {
+				if local, ok := localPath(spec); ok {
+					currentPackage.Local = local
+				}
+			}
+			inDependencies = false
 		} else if currentPackage != nil {
+			// Collect entries of a dependencies block (indented one level deeper)
+			if inDependencies {
+				if matches := dependencyRe.FindStringSubmatch(line); matches != nil {
+					currentPackage.Dependencies = append(currentPackage.Dependencies, matches[1])
+					continue
+				}
+				inDependencies = false
+			}
+
 			// Check for version line
 			if matches := versionRe.FindStringSubmatch(line); matches != nil {
 				currentPackage.Version = matches[1]
+			} else if matches := licenseRe.FindStringSubmatch(line); matches != nil {
+				currentPackage.License = matches[1]
+			} else if dependenciesRe.MatchString(line) {
+				inDependencies = true
 			}
 		}
 	}
 
 	// Don't forget the last package
-	if currentPackage != nil {
-		dependencies = append(dependencies, *currentPackage)
-	}
+	flush()
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading yarn.lock: %w", err)
@@ -354,3 +686,522 @@ func (p *YarnParser) Parse(lockFilePath string) ([]Dependency, error) {
 
 	return dependencies, nil
 }
+
+// yarnBerryMetadataRe matches the __metadata block Yarn 2+ writes at the top
+// of its lock files
+var yarnBerryMetadataRe = regexp.MustCompile(`(?m)^"?__metadata"?:\s*$`)
+
+// isYarnBerryLock reports whether a yarn.lock was written by Yarn 2+, whose
+// lock files are YAML with a __metadata block instead of the v1 header
+func isYarnBerryLock(data []byte) bool {
+	return yarnBerryMetadataRe.Match(data)
+}
+
+// YarnBerryEntry is a package entry of a Yarn 2+ lock file
+type YarnBerryEntry struct {
+	Version      string            `yaml:"version"`
+	License      string            `yaml:"license"`
+	Resolution   string            `yaml:"resolution"`
+	Dependencies map[string]string `yaml:"dependencies"`
+}
+
+// parseYarnBerry parses a Yarn 2+ lock file. Entries are keyed by one or
+// more comma-separated descriptors such as "lodash@npm:^4.17.21"; the
+// resolution records the package that was actually installed.
+func parseYarnBerry(data []byte) ([]Dependency, error) {
+	var entries map[string]YarnBerryEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse yarn.lock: %w", err)
+	}
+
+	// Map order is random, the output should not be
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		if key != "__metadata" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var dependencies []Dependency
+	seen := make(map[string]int)
+	for _, key := range keys {
+		entry := entries[key]
+
+		var dep Dependency
+		root := false
+		for _, descriptor := range strings.Split(key, ",") {
+			descriptor = strings.TrimSpace(descriptor)
+			name := berryDescriptorName(descriptor)
+			if name == "" {
+				continue
+			}
+			if dep.Name == "" {
+				dep.Name = name
+			}
+
+			spec := strings.TrimPrefix(descriptor[len(name)+1:], "npm:")
+			switch {
+			case spec == "workspace:.":
+				root = true // The project itself
+			case strings.HasPrefix(spec, "workspace:"):
+				dep.Local = strings.TrimPrefix(spec, "workspace:")
+			default:
+				if local, ok := localPath(spec); ok {
+					dep.Local = local
+				}
+			}
+			dep.Ranges = mergeUnique(dep.Ranges, []string{spec})
+		}
+		if root || dep.Name == "" {
+			continue
+		}
+
+		dep.Version = entry.Version
+		dep.License = entry.License
+		if name := berryDescriptorName(entry.Resolution); name != "" && name != dep.Name {
+			dep.Alias = name
+		}
+		dep.Dependencies = sortedKeys(entry.Dependencies)
+
+		id := dep.Name + "@" + dep.Version
+		if i, exists := seen[id]; exists {
+			dependencies[i].Ranges = mergeUnique(dependencies[i].Ranges, dep.Ranges)
+			dependencies[i].Dependencies = mergeUnique(dependencies[i].Dependencies, dep.Dependencies)
+			continue
+		}
+		seen[id] = len(dependencies)
+		dependencies = append(dependencies, dep)
+	}
+
+	return dependencies, nil
+}
+
+// berryDescriptorName returns the package name of a descriptor or locator
+// such as "@babel/core@npm:^7.22.0", skipping the leading @ of scopes
+func berryDescriptorName(descriptor string) string {
+	descriptor = strings.TrimSpace(descriptor)
+	if len(descriptor) < 2 {
+		return ""
+	}
+	at := strings.Index(descriptor[1:], "@")
+	if at < 0 {
+		return ""
+	}
+	return descriptor[:at+1]
+}
+
+// markDev flags the dependencies reachable from the dev roots but not from
+// the production ones, which npm would leave out of a production install
+func markDev(dependencies []Dependency, prod, dev []string) {
+	byName := make(map[string][]int)
+	for i, dep := range dependencies {
+		byName[dep.Name] = append(byName[dep.Name], i)
+	}
+	reachable := func(roots []string) map[string]bool {
+		seen := make(map[string]bool)
+		queue := append([]string{}, roots...)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			for _, i := range byName[name] {
+				queue = append(queue, dependencies[i].Dependencies...)
+			}
+		}
+		return seen
+	}
+
+	fromProd := reachable(prod)
+	fromDev := reachable(dev)
+	for i := range dependencies {
+		dependencies[i].Dev = fromDev[dependencies[i].Name] && !fromProd[dependencies[i].Name]
+	}
+}
+
+// assignDepths sets the depth of every dependency by walking the graph
+// breadth-first from the direct dependencies. When the lock file does not
+// record them, packages that nothing else depends on are treated as direct.
+func assignDepths(dependencies []Dependency, direct []string) {
+	byName := make(map[string][]int)
+	required := make(map[string]bool)
+	for i, dep := range dependencies {
+		byName[dep.Name] = append(byName[dep.Name], i)
+		for _, child := range dep.Dependencies {
+			required[child] = true
+		}
+	}
+
+	if len(direct) == 0 {
+		for _, dep := range dependencies {
+			if !required[dep.Name] {
+				direct = append(direct, dep.Name)
+			}
+		}
+	}
+
+	depths := make(map[string]int)
+	queue := []string{}
+	for _, name := range direct {
+		if _, seen := depths[name]; !seen {
+			depths[name] = 1
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, i := range byName[name] {
+			for _, child := range dependencies[i].Dependencies {
+				if _, seen := depths[child]; !seen {
+					depths[child] = depths[name] + 1
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+
+	for i := range dependencies {
+		dependencies[i].Depth = depths[dependencies[i].Name]
+	}
+}
+
+// parseYarnRanges extracts the version ranges from a yarn.lock entry header
+// such as `"lodash@^4.17.0", "lodash@^4.17.21"`
+func parseYarnRanges(header string) []string {
+	var ranges []string
+	for _, descriptor := range strings.Split(header, ",") {
+		descriptor = strings.Trim(strings.TrimSpace(descriptor), `"`)
+		// Skip the leading @ of scoped packages when locating the separator
+		if at := strings.LastIndex(descriptor, "@"); at > 0 {
+			ranges = mergeUnique(ranges, []string{descriptor[at+1:]})
+		}
+	}
+	return ranges
+}
+
+// mergeUnique appends the values of extra missing from base, preserving order
+func mergeUnique(base, extra []string) []string {
+	for _, value := range extra {
+		found := false
+		for _, existing := range base {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			base = append(base, value)
+		}
+	}
+	return base
+}
+
+// BowerParser implements parsing for bower.json manifests
+type BowerParser struct {
+	fs FileSystem
+}
+
+func NewBowerParser() *BowerParser {
+	return &BowerParser{fs: &RealFileSystem{}}
+}
+
+func NewBowerParserWithFS(fs FileSystem) *BowerParser {
+	return &BowerParser{fs: fs}
+}
+
+// BowerManifest represents the structure of bower.json
+type BowerManifest struct {
+	Name            string            `json:"name"`
+	Version         string            `json:"version"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func (p *BowerParser) Parse(manifestPath string) ([]Dependency, error) {
+	var manifest BowerManifest
+	if err := readJSON(p.fs, manifestPath, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bower.json: %w", err)
+	}
+
+	componentsDir := BowerComponentsDir(p.fs, filepath.Dir(manifestPath))
+
+	var dependencies []Dependency
+	for i, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+		for _, name := range sortedKeys(deps) {
+			// bower.json only holds ranges; the installed .bower.json has the resolved version
+			version := deps[name]
+			var installed BowerManifest
+			if err := readJSON(p.fs, p.fs.Join(componentsDir, name, ".bower.json"), &installed); err == nil && installed.Version != "" {
+				version = installed.Version
+			}
+
+			dependencies = append(dependencies, Dependency{
+				Name:    name,
+				Version: version,
+				Depth:   1,
+				Dev:     i == 1,
+			})
+		}
+	}
+
+	return dependencies, nil
+}
+
+// PackageJSONParser reads the dependencies declared in package.json, for
+// projects that commit no lock file. Versions are the declared ranges.
+type PackageJSONParser struct {
+	fs FileSystem
+}
+
+func NewPackageJSONParser() *PackageJSONParser {
+	return &PackageJSONParser{fs: &RealFileSystem{}}
+}
+
+func NewPackageJSONParserWithFS(fs FileSystem) *PackageJSONParser {
+	return &PackageJSONParser{fs: fs}
+}
+
+func (p *PackageJSONParser) Parse(packageJSONPath string) ([]Dependency, error) {
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := readJSON(p.fs, packageJSONPath, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	var dependencies []Dependency
+	for i, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+		for _, name := range sortedKeys(deps) {
+			dep := Dependency{
+				Name:       name,
+				Version:    deps[name],
+				Depth:      1,
+				Dev:        i == 1,
+				Unresolved: true,
+				Direct:     true,
+			}
+			if path, ok := localPath(deps[name]); ok {
+				dep.Local = path
+			}
+			dependencies = append(dependencies, dep)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// BowerComponentsDir returns the directory bower installs components into,
+// honoring the "directory" setting of .bowerrc
+func BowerComponentsDir(fs FileSystem, rootPath string) string {
+	var rc struct {
+		Directory string `json:"directory"`
+	}
+	if err := readJSON(fs, fs.Join(rootPath, constants.BowerRCFile), &rc); err == nil && rc.Directory != "" {
+		return fs.Join(rootPath, rc.Directory)
+	}
+	return fs.Join(rootPath, constants.BowerDir)
+}
+
+// readJSON decodes a JSON file into v
+func readJSON(fs FileSystem, path string, v interface{}) error {
+	file, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// sortedKeys returns the keys of a dependency map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exactVersionPattern matches a pinned version, as opposed to a range or alias
+var exactVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]+)?$`)
+
+// Override is a version forced by package.json overrides or resolutions
+type Override struct {
+	// Spec is the declared value, e.g. "1.2.3" or "^1.2.0"
+	Spec string
+	// Version is the pinned version, empty when Spec is a range
+	Version string
+}
+
+// ParseOverrides reads npm "overrides", yarn "resolutions" and pnpm
+// "pnpm.overrides" from a package.json, keyed by package name. Overrides
+// scoped under a parent package are skipped: the same package may be pinned
+// differently under several parents, and the lock file already records
+// where each version is installed.
+func ParseOverrides(fs FileSystem, packageJSONPath string) (map[string]Override, error) {
+	var manifest struct {
+		Overrides   map[string]interface{} `json:"overrides"`
+		Resolutions map[string]string      `json:"resolutions"`
+		Pnpm        struct {
+			Overrides map[string]string `json:"overrides"`
+		} `json:"pnpm"`
+	}
+	if err := readJSON(fs, packageJSONPath, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	overrides := make(map[string]Override)
+	add := func(selector, spec string) {
+		if scopedSelector(selector) {
+			return
+		}
+		// "$name" references the version of a direct dependency, which is not an override
+		if name := overrideTarget(selector); name != "" && spec != "" && !strings.HasPrefix(spec, "$") {
+			override := Override{Spec: spec}
+			if exactVersionPattern.MatchString(spec) {
+				override.Version = strings.TrimPrefix(spec, "v")
+			}
+			overrides[name] = override
+		}
+	}
+
+	var addNPM func(name string, value interface{})
+	addNPM = func(name string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			add(name, v)
+		case map[string]interface{}:
+			// Only "." overrides the package itself, the others its dependencies
+			if nested, ok := v["."]; ok {
+				addNPM(name, nested)
+			}
+		}
+	}
+	for name, value := range manifest.Overrides {
+		addNPM(name, value)
+	}
+	for selector, spec := range manifest.Resolutions {
+		add(selector, spec)
+	}
+	for selector, spec := range manifest.Pnpm.Overrides {
+		add(selector, spec)
+	}
+
+	return overrides, nil
+}
+
+// ParseDirect maps the names a package.json depends on directly, in any of
+// its dependency fields, to their version spec; everything else in the lock
+// file is transitive
+func ParseDirect(fs FileSystem, packageJSONPath string) (map[string]string, error) {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+	}
+	if err := readJSON(fs, packageJSONPath, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	direct := make(map[string]string)
+	for _, deps := range []map[string]string{
+		manifest.PeerDependencies, manifest.OptionalDependencies, manifest.DevDependencies, manifest.Dependencies,
+	} {
+		for name, spec := range deps {
+			direct[name] = spec
+		}
+	}
+	return direct, nil
+}
+
+// ParseBundled reads "bundleDependencies" (or "bundledDependencies") from a
+// package.json. These packages ship inside the package tarball; true bundles
+// every entry of "dependencies".
+func ParseBundled(fs FileSystem, packageJSONPath string) (map[string]bool, error) {
+	var manifest struct {
+		Dependencies        map[string]string `json:"dependencies"`
+		BundleDependencies  interface{}       `json:"bundleDependencies"`
+		BundledDependencies interface{}       `json:"bundledDependencies"`
+	}
+	if err := readJSON(fs, packageJSONPath, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	bundle := manifest.BundleDependencies
+	if bundle == nil {
+		bundle = manifest.BundledDependencies
+	}
+
+	bundled := make(map[string]bool)
+	switch v := bundle.(type) {
+	case bool:
+		if v {
+			for name := range manifest.Dependencies {
+				bundled[name] = true
+			}
+		}
+	case []interface{}:
+		for _, name := range v {
+			if s, ok := name.(string); ok && s != "" {
+				bundled[s] = true
+			}
+		}
+	}
+
+	return bundled, nil
+}
+
+// scopedSelector reports whether an override selector applies under a parent
+// package only, e.g. "webpack/@babel/core" or "foo@1>bar", rather than
+// everywhere like "lodash" or "**/lodash"
+func scopedSelector(selector string) bool {
+	if strings.Contains(selector, ">") {
+		return true
+	}
+	packages := 0
+	for _, segment := range strings.Split(strings.Trim(selector, "/"), "/") {
+		// A scope and the name after it make up one package
+		if segment != "**" && !strings.HasPrefix(segment, "@") {
+			packages++
+		}
+	}
+	return packages > 1
+}
+
+// overrideTarget returns the package an override selector applies to, e.g.
+// "**/lodash", "webpack/@babel/core" or "foo@1>bar@^2" name lodash,
+// @babel/core and bar
+func overrideTarget(selector string) string {
+	if gt := strings.LastIndex(selector, ">"); gt >= 0 {
+		selector = selector[gt+1:]
+	}
+	segments := strings.Split(strings.Trim(selector, "/"), "/")
+	name := segments[len(segments)-1]
+	if len(segments) > 1 && strings.HasPrefix(segments[len(segments)-2], "@") {
+		name = segments[len(segments)-2] + "/" + name
+	}
+	// Drop a version qualifier such as "lodash@<4" while keeping the scope's @
+	if at := strings.LastIndex(name, "@"); at > 0 {
+		name = name[:at]
+	}
+	if name == "*" || name == "**" {
+		return ""
+	}
+	return name
+}
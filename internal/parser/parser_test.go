@@ -3,7 +3,9 @@ package parser
 import (
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -52,6 +54,35 @@ func (fs *MockFileSystem) Join(elem ...string) string {
 	return strings.Join(elem, "/")
 }
 
+// ReadDir lists the direct children of a directory
+func (fs *MockFileSystem) ReadDir(dir string) ([]os.DirEntry, error) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	seen := make(map[string]bool)
+	var entries []os.DirEntry
+	add := func(p string, isDir bool) {
+		rest, found := strings.CutPrefix(p, prefix)
+		if !found || rest == "" {
+			return
+		}
+		name, _, nested := strings.Cut(rest, "/")
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		entries = append(entries, iofs.FileInfoToDirEntry(&mockFileInfo{name: name, isDir: isDir || nested}))
+	}
+	for p := range fs.files {
+		add(p, false)
+	}
+	for p := range fs.dirs {
+		add(p, true)
+	}
+	if len(entries) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
 type mockFileInfo struct {
 	name  string
 	isDir bool
@@ -105,6 +136,40 @@ func TestDetectLockFile(t *testing.T) {
 			expectedPath:    "/test/package-lock.json",
 			expectedManager: "npm",
 		},
+		{
+			name: "npm shrinkwrap file",
+			files: map[string]string{
+				"/test/npm-shrinkwrap.json": "{}",
+			},
+			expectedPath:    "/test/npm-shrinkwrap.json",
+			expectedManager: "npm",
+		},
+		{
+			name: "package-lock.json takes precedence over npm-shrinkwrap.json",
+			files: map[string]string{
+				"/test/package-lock.json":   "{}",
+				"/test/npm-shrinkwrap.json": "{}",
+			},
+			expectedPath:    "/test/package-lock.json",
+			expectedManager: "npm",
+		},
+		{
+			name: "bower manifest",
+			files: map[string]string{
+				"/test/bower.json": "{}",
+			},
+			expectedPath:    "/test/bower.json",
+			expectedManager: "bower",
+		},
+		{
+			name: "bower manifest has lowest precedence",
+			files: map[string]string{
+				"/test/bower.json":     "{}",
+				"/test/pnpm-lock.yaml": "lockfileVersion: 5.4",
+			},
+			expectedPath:    "/test/pnpm-lock.yaml",
+			expectedManager: "pnpm",
+		},
 		{
 			name:          "no lock files",
 			files:         map[string]string{},
@@ -256,6 +321,163 @@ func TestNPMParser_Parse(t *testing.T) {
 	}
 }
 
+func TestNPMParser_Parse_Shrinkwrap(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/npm-shrinkwrap.json", `{
+		"name": "published-cli",
+		"version": "1.0.0",
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"name": "published-cli", "version": "1.0.0"},
+			"node_modules/commander": {"version": "11.1.0", "license": "MIT"}
+		}
+	}`)
+
+	lockFilePath, manager, err := DetectLockFile(fs, "/test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manager != "npm" {
+		t.Fatalf("expected the npm manager for npm-shrinkwrap.json, got %q", manager)
+	}
+
+	deps, err := NewNPMParserWithFS(fs).Parse(lockFilePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps) != 1 || deps[0].Name != "commander" || deps[0].Version != "11.1.0" || deps[0].License != "MIT" {
+		t.Errorf("expected commander@11.1.0 (MIT), got %+v", deps)
+	}
+}
+
+func TestParse_SortedPackages(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		parser   func(FileSystem) LockFileParser
+		expected []string
+	}{
+		{
+			name: "npm",
+			file: "package-lock.json",
+			content: `{"lockfileVersion": 3, "packages": {
+				"node_modules/zod": {"version": "3.22.4"},
+				"node_modules/axios/node_modules/form-data": {"version": "4.0.0"},
+				"node_modules/axios": {"version": "1.6.2"}
+			}}`,
+			parser:   func(fs FileSystem) LockFileParser { return NewNPMParserWithFS(fs) },
+			expected: []string{"axios@1.6.2", "form-data@4.0.0", "zod@3.22.4"},
+		},
+		{
+			name: "pnpm",
+			file: "pnpm-lock.yaml",
+			content: `lockfileVersion: '6.0'
+
+packages:
+  /zod@3.22.4:
+    dev: false
+  /form-data@4.0.0:
+    dev: false
+  /axios@1.6.2:
+    dev: false
+`,
+			parser:   func(fs FileSystem) LockFileParser { return NewPnpmParserWithFS(fs) },
+			expected: []string{"axios@1.6.2", "form-data@4.0.0", "zod@3.22.4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/test/"+tt.file, tt.content)
+
+			// Packages are a map, so several runs would catch a random order
+			for range 5 {
+				deps, err := tt.parser(fs).Parse("/test/" + tt.file)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				var got []string
+				for _, dep := range deps {
+					got = append(got, dep.Name+"@"+dep.Version)
+				}
+				if !reflect.DeepEqual(got, tt.expected) {
+					t.Fatalf("expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNPMParser_Parse_LegacyDiamond(t *testing.T) {
+	// app-a and app-b both bundle the same shared@1.0.0 subtree
+	lockContent := `{
+		"lockfileVersion": 1,
+		"dependencies": {
+			"app-a": {
+				"version": "1.0.0",
+				"requires": {"shared": "1.0.0"},
+				"dependencies": {
+					"shared": {
+						"version": "1.0.0",
+						"dependencies": {"leaf": {"version": "2.0.0"}}
+					}
+				}
+			},
+			"app-b": {
+				"version": "1.0.0",
+				"dependencies": {
+					"shared": {
+						"version": "1.0.0",
+						"dependencies": {"leaf": {"version": "2.0.0"}}
+					}
+				}
+			},
+			"leaf": {"version": "1.0.0"}
+		}
+	}`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package-lock.json", lockContent)
+
+	deps, err := NewNPMParserWithFS(fs).Parse("/test/package-lock.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"app-a@1.0.0", "app-b@1.0.0", "leaf@1.0.0", "leaf@2.0.0", "shared@1.0.0"}
+	if len(deps) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %d: %v", len(expected), len(deps), deps)
+	}
+	for i, key := range expected {
+		if got := deps[i].Name + "@" + deps[i].Version; got != key {
+			t.Errorf("expected %s at %d, got %s", key, i, got)
+		}
+	}
+}
+
+func TestParseLegacyDependencies_Parallel(t *testing.T) {
+	deps := make(map[string]NPMDependency)
+	for i := 0; i < legacyParallelThreshold*2; i++ {
+		deps[fmt.Sprintf("pkg-%03d", i)] = NPMDependency{
+			Version: "1.0.0",
+			Dependencies: map[string]NPMDependency{
+				"shared": {Version: "3.0.0"},
+			},
+		}
+	}
+
+	result := parseLegacyDependencies(deps)
+
+	if len(result) != legacyParallelThreshold*2+1 {
+		t.Errorf("expected %d unique dependencies, got %d", legacyParallelThreshold*2+1, len(result))
+	}
+	if result[0].Name != "pkg-000" || result[len(result)-1].Name != "shared" {
+		t.Errorf("expected sorted output, got first %s and last %s", result[0].Name, result[len(result)-1].Name)
+	}
+}
+
 func TestPnpmParser_Parse(t *testing.T) {
 	lockContent := `lockfileVersion: 5.4
 
@@ -320,6 +542,130 @@ packages:
 	}
 }
 
+func TestPnpmParser_Parse_V5Keys(t *testing.T) {
+	lockContent := `lockfileVersion: 5.4
+
+specifiers:
+  '@scope/util': ^1.2.0
+  express: ^4.18.2
+
+dependencies:
+  '@scope/util': 1.2.0
+  express: 4.18.2
+
+packages:
+
+  /@scope/util/1.2.0:
+    resolution: {integrity: sha512-abc}
+    dev: false
+
+  /cookie/0.5.0:
+    resolution: {integrity: sha512-def}
+    dev: false
+
+  /express/4.18.2_supports-color@7.2.0:
+    resolution: {integrity: sha512-ghi}
+    dependencies:
+      cookie: 0.5.0
+    dev: false
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/pnpm-lock.yaml", lockContent)
+
+	deps, err := NewPnpmParserWithFS(fs).Parse("/test/pnpm-lock.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]bool{"@scope/util@1.2.0": true, "cookie@0.5.0": true, "express@4.18.2": true}
+	if len(deps) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %+v", len(expected), deps)
+	}
+	for _, dep := range deps {
+		if !expected[dep.Name+"@"+dep.Version] {
+			t.Errorf("unexpected dependency %s@%s", dep.Name, dep.Version)
+		}
+	}
+}
+
+func TestPnpmParser_Parse_PeerVariants(t *testing.T) {
+	lockContent := `lockfileVersion: '6.0'
+
+packages:
+  /react-redux@8.1.0(react@18.2.0):
+    dependencies:
+      react: 18.2.0
+  /react-redux@8.1.0(react@17.0.2):
+    dependencies:
+      '@babel/runtime': 7.22.0
+  /@testing-library/react@14.0.0_react-dom@18.2.0:
+    dev: true
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/pnpm-lock.yaml", lockContent)
+
+	deps, err := NewPnpmParserWithFS(fs).Parse("/test/pnpm-lock.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected peer variants to collapse into 2 dependencies, got %+v", deps)
+	}
+
+	for _, dep := range deps {
+		switch dep.Name {
+		case "react-redux":
+			if dep.Version != "8.1.0" || len(dep.Dependencies) != 2 {
+				t.Errorf("expected react-redux@8.1.0 with merged dependencies, got %+v", dep)
+			}
+		case "@testing-library/react":
+			if dep.Version != "14.0.0" {
+				t.Errorf("expected version 14.0.0, got %q", dep.Version)
+			}
+		default:
+			t.Errorf("unexpected dependency %+v", dep)
+		}
+	}
+}
+
+func TestPnpmParser_Parse_License(t *testing.T) {
+	lockContent := `lockfileVersion: '6.0'
+
+packages:
+  /lodash@4.17.21:
+    resolution: {integrity: sha512-v2kDEe57lecTulaDIuNTPy3Ry4gLGJ6Z1O3vE1krgXZNrsQ+LFTGHVxVjcXPs+cA6SoVHLIkD1k6qPy5f8d9cw==}
+    license: MIT
+  /react-redux@8.1.0(react@18.2.0):
+    dependencies:
+      react: 18.2.0
+  /react-redux@8.1.0(react@17.0.2):
+    license: MIT
+  /react@18.2.0:
+    resolution: {integrity: sha512-/3IjMdb2L9QbBdWiW5e3P2/npwMBaU9mHCSCUzNln0ZCYbcfTsGbTJrU/kGemdH2IWmB2ioZ+zkxtmq6g09fGQ==}
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/pnpm-lock.yaml", lockContent)
+
+	deps, err := NewPnpmParserWithFS(fs).Parse("/test/pnpm-lock.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	licenses := make(map[string]string)
+	for _, dep := range deps {
+		licenses[dep.Name] = dep.License
+	}
+	// Entries without a license are left to detection
+	expected := map[string]string{"lodash": "MIT", "react-redux": "MIT", "react": ""}
+	if !reflect.DeepEqual(licenses, expected) {
+		t.Errorf("expected licenses %v, got %v", expected, licenses)
+	}
+}
+
 func TestYarnParser_Parse(t *testing.T) {
 	lockContent := `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
 # yarn lockfile v1
@@ -380,6 +726,396 @@ express@4.18.0:
 	}
 }
 
+func TestYarnParser_Parse_Berry(t *testing.T) {
+	lockContent := `# This file is generated by running "yarn install" inside your project.
+# Manual changes might be lost - proceed with caution!
+
+__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"@babel/core@npm:^7.0.0, @babel/core@npm:^7.22.0":
+  version: 7.22.0
+  resolution: "@babel/core@npm:7.22.0"
+  dependencies:
+    debug: "npm:^4.1.0"
+  checksum: 10c0/0123456789
+  languageName: node
+  linkType: hard
+
+"debug@npm:^4.1.0":
+  version: 4.3.4
+  resolution: "debug@npm:4.3.4"
+  languageName: node
+  linkType: hard
+
+"lodash@npm:^4.17.21":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  languageName: node
+  linkType: hard
+
+"lodash@npm:~4.17.0":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  languageName: node
+  linkType: hard
+
+"my-app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "my-app@workspace:."
+  dependencies:
+    "@babel/core": "npm:^7.22.0"
+    lodash: "npm:^4.17.21"
+    shared: "workspace:packages/shared"
+    string-pad: "npm:left-pad@^1.3.0"
+  languageName: unknown
+  linkType: soft
+
+"shared@workspace:packages/shared":
+  version: 0.0.0-use.local
+  resolution: "shared@workspace:packages/shared"
+  languageName: unknown
+  linkType: soft
+
+"string-pad@npm:left-pad@^1.3.0":
+  version: 1.3.0
+  resolution: "left-pad@npm:1.3.0"
+  languageName: node
+  linkType: hard
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/yarn.lock", lockContent)
+
+	deps, err := NewYarnParserWithFS(fs).Parse("/test/yarn.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Dependency{
+		{Name: "@babel/core", Version: "7.22.0", Ranges: []string{"^7.0.0", "^7.22.0"}, Dependencies: []string{"debug"}, Depth: 1},
+		{Name: "debug", Version: "4.3.4", Ranges: []string{"^4.1.0"}, Depth: 2},
+		{Name: "lodash", Version: "4.17.21", Ranges: []string{"^4.17.21", "~4.17.0"}, Depth: 1},
+		{Name: "shared", Version: "0.0.0-use.local", Ranges: []string{"workspace:packages/shared"}, Local: "packages/shared", Depth: 1},
+		{Name: "string-pad", Version: "1.3.0", Ranges: []string{"left-pad@^1.3.0"}, Alias: "left-pad", Depth: 1},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestYarnParser_Parse_License(t *testing.T) {
+	tests := []struct {
+		name        string
+		lockContent string
+	}{
+		{
+			name: "v1",
+			lockContent: `# yarn lockfile v1
+
+lodash@^4.17.21:
+  version "4.17.21"
+  license "MIT"
+
+react@^18.2.0:
+  version "18.2.0"
+`,
+		},
+		{
+			name: "berry",
+			lockContent: `__metadata:
+  version: 8
+
+"lodash@npm:^4.17.21":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  license: MIT
+  languageName: node
+  linkType: hard
+
+"react@npm:^18.2.0":
+  version: 18.2.0
+  resolution: "react@npm:18.2.0"
+  languageName: node
+  linkType: hard
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			fs.AddFile("/test/yarn.lock", tt.lockContent)
+
+			deps, err := NewYarnParserWithFS(fs).Parse("/test/yarn.lock")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			licenses := make(map[string]string)
+			for _, dep := range deps {
+				licenses[dep.Name] = dep.License
+			}
+			expected := map[string]string{"lodash": "MIT", "react": ""}
+			if !reflect.DeepEqual(licenses, expected) {
+				t.Errorf("expected licenses %v, got %v", expected, licenses)
+			}
+		})
+	}
+}
+
+func TestIsYarnBerryLock(t *testing.T) {
+	classic := "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n\nlodash@^4.17.21:\n  version \"4.17.21\"\n"
+	berry := "__metadata:\n  version: 6\n\n\"lodash@npm:^4.17.21\":\n  version: 4.17.21\n"
+	if isYarnBerryLock([]byte(classic)) {
+		t.Error("expected a v1 lock file to use the classic parser")
+	}
+	if !isYarnBerryLock([]byte(berry)) {
+		t.Error("expected a lock file with __metadata to use the Berry parser")
+	}
+}
+
+func TestYarnParser_Parse_DuplicateResolutions(t *testing.T) {
+	lockContent := `"react-dom@^18.0.0":
+  version "18.2.0"
+  dependencies:
+    scheduler "^0.23.0"
+
+"react-dom@^18.2.0", "react-dom@~18.2.0":
+  version "18.2.0"
+  dependencies:
+    loose-envify "^1.1.0"
+
+"@babel/core@^7.0.0":
+  version "7.22.0"
+
+"@babel/core@^7.22.0":
+  version "7.22.0"
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/yarn.lock", lockContent)
+
+	deps, err := NewYarnParserWithFS(fs).Parse("/test/yarn.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 deduplicated dependencies, got %d: %v", len(deps), deps)
+	}
+
+	reactDOM := deps[0]
+	if reactDOM.Name != "react-dom" || reactDOM.Version != "18.2.0" {
+		t.Errorf("unexpected first dependency: %+v", reactDOM)
+	}
+	if strings.Join(reactDOM.Ranges, ",") != "^18.0.0,^18.2.0,~18.2.0" {
+		t.Errorf("expected merged ranges, got %v", reactDOM.Ranges)
+	}
+	if strings.Join(reactDOM.Dependencies, ",") != "scheduler,loose-envify" {
+		t.Errorf("expected merged dependencies, got %v", reactDOM.Dependencies)
+	}
+
+	// The last entry is a duplicate and must be merged when flushed at EOF
+	babel := deps[1]
+	if babel.Name != "@babel/core" || strings.Join(babel.Ranges, ",") != "^7.0.0,^7.22.0" {
+		t.Errorf("expected last entry to be merged into @babel/core, got %+v", babel)
+	}
+}
+
+func TestPackageJSONParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package.json", `{
+		"name": "my-lib",
+		"dependencies": {
+			"react": "^18.2.0",
+			"utils": "file:../utils"
+		},
+		"devDependencies": {
+			"jest": "~29.7.0"
+		}
+	}`)
+
+	deps, err := NewPackageJSONParserWithFS(fs).Parse("/test/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Dependency{
+		{Name: "react", Version: "^18.2.0", Depth: 1, Unresolved: true, Direct: true},
+		{Name: "utils", Version: "file:../utils", Depth: 1, Local: "../utils", Unresolved: true, Direct: true},
+		{Name: "jest", Version: "~29.7.0", Depth: 1, Dev: true, Unresolved: true, Direct: true},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+
+	if _, err := NewPackageJSONParserWithFS(fs).Parse("/missing/package.json"); err == nil {
+		t.Error("expected an error for a missing package.json")
+	}
+}
+
+func TestBowerParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/bower.json", `{
+		"name": "legacy-app",
+		"dependencies": {
+			"jquery": "~2.1.4",
+			"angular": "1.4.x"
+		},
+		"devDependencies": {
+			"jasmine": "^2.3.0"
+		}
+	}`)
+	fs.AddFile("/test/.bowerrc", `{"directory": "vendor/components"}`)
+	fs.AddFile("/test/vendor/components/jquery/.bower.json", `{"name": "jquery", "version": "2.1.4"}`)
+
+	deps, err := NewBowerParserWithFS(fs).Parse("/test/bower.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedDeps := []Dependency{
+		{Name: "angular", Version: "1.4.x"},
+		{Name: "jquery", Version: "2.1.4"},
+		{Name: "jasmine", Version: "^2.3.0"},
+	}
+
+	if len(deps) != len(expectedDeps) {
+		t.Fatalf("expected %d dependencies, got %d", len(expectedDeps), len(deps))
+	}
+
+	for i, expected := range expectedDeps {
+		if deps[i].Name != expected.Name || deps[i].Version != expected.Version {
+			t.Errorf("expected %s@%s, got %s@%s", expected.Name, expected.Version, deps[i].Name, deps[i].Version)
+		}
+	}
+
+	if dir := BowerComponentsDir(fs, "/test"); dir != "/test/vendor/components" {
+		t.Errorf("expected .bowerrc directory, got %q", dir)
+	}
+}
+
+func TestParsers_DependencyEdges(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package-lock.json", `{
+		"packages": {
+			"": {"name": "test-project"},
+			"node_modules/express": {
+				"version": "4.18.0",
+				"dependencies": {"body-parser": "1.20.0", "accepts": "~1.3.8"}
+			},
+			"node_modules/accepts": {"version": "1.3.8"}
+		}
+	}`)
+	fs.AddFile("/test/yarn.lock", `express@4.18.0:
+  version "4.18.0"
+  dependencies:
+    accepts "~1.3.8"
+    "@types/body-parser" "1.20.0"
+  optionalDependencies:
+    fsevents "2.3.2"
+
+accepts@~1.3.8:
+  version "1.3.8"
+`)
+
+	npmDeps, err := NewNPMParserWithFS(fs).Parse("/test/package-lock.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	yarnDeps, err := NewYarnParserWithFS(fs).Parse("/test/yarn.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"npm":  {"accepts", "body-parser"},
+		"yarn": {"accepts", "@types/body-parser"},
+	}
+	for manager, deps := range map[string][]Dependency{"npm": npmDeps, "yarn": yarnDeps} {
+		for _, dep := range deps {
+			if dep.Name != "express" {
+				if len(dep.Dependencies) != 0 {
+					t.Errorf("%s: expected %q to have no dependencies, got %v", manager, dep.Name, dep.Dependencies)
+				}
+				continue
+			}
+			if strings.Join(dep.Dependencies, ",") != strings.Join(expected[manager], ",") {
+				t.Errorf("%s: expected express dependencies %v, got %v", manager, expected[manager], dep.Dependencies)
+			}
+		}
+	}
+}
+
+func TestNPMParser_Parse_Depths(t *testing.T) {
+	lockContent := `{
+		"packages": {
+			"": {
+				"name": "test-project",
+				"dependencies": {"express": "^4.18.0"},
+				"devDependencies": {"jest": "^29.0.0"}
+			},
+			"node_modules/express": {"version": "4.18.0", "dependencies": {"body-parser": "1.20.0"}},
+			"node_modules/body-parser": {"version": "1.20.0", "dependencies": {"bytes": "3.1.2"}},
+			"node_modules/bytes": {"version": "3.1.2"},
+			"node_modules/jest": {"version": "29.0.0", "dependencies": {"bytes": "3.1.2"}}
+		}
+	}`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package-lock.json", lockContent)
+
+	deps, err := NewNPMParserWithFS(fs).Parse("/test/package-lock.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"express": 1, "jest": 1, "body-parser": 2, "bytes": 2}
+	depthCounts := make(map[int]int)
+	for _, dep := range deps {
+		if dep.Depth != expected[dep.Name] {
+			t.Errorf("dependency %q: expected depth %d, got %d", dep.Name, expected[dep.Name], dep.Depth)
+		}
+		depthCounts[dep.Depth]++
+	}
+
+	if depthCounts[1] != 2 || depthCounts[2] != 2 || len(depthCounts) != 2 {
+		t.Errorf("unexpected depth counts: %v", depthCounts)
+	}
+}
+
+func TestYarnParser_Parse_InferredDepths(t *testing.T) {
+	lockContent := `express@^4.18.0:
+  version "4.18.0"
+  dependencies:
+    body-parser "1.20.0"
+
+body-parser@1.20.0:
+  version "1.20.0"
+  dependencies:
+    bytes "3.1.2"
+
+bytes@3.1.2:
+  version "3.1.2"
+`
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/yarn.lock", lockContent)
+
+	deps, err := NewYarnParserWithFS(fs).Parse("/test/yarn.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"express": 1, "body-parser": 2, "bytes": 3}
+	for _, dep := range deps {
+		if dep.Depth != expected[dep.Name] {
+			t.Errorf("dependency %q: expected depth %d, got %d", dep.Name, expected[dep.Name], dep.Depth)
+		}
+	}
+}
+
 func TestExtractPackageName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -390,6 +1126,9 @@ func TestExtractPackageName(t *testing.T) {
 		{"node_modules/@babel/core", "@babel/core"},
 		{"node_modules/lodash/lib/index.js", "lodash"},
 		{"node_modules/@types/node/lib/index.d.ts", "@types/node"},
+		{"node_modules/express/node_modules/debug", "debug"},
+		{"node_modules/a/node_modules/@scope/b/node_modules/c", "c"},
+		{"node_modules/a/node_modules/@scope/b", "@scope/b"},
 		{"invalid/path", ""},
 		{"", ""},
 	}
@@ -414,6 +1153,13 @@ func TestExtractPnpmPackageInfo(t *testing.T) {
 		{"/@types/node@18.0.0", "@types/node", "18.0.0"},
 		{"/@babel/core@7.20.0", "@babel/core", "7.20.0"},
 		{"/express@4.18.0", "express", "4.18.0"},
+		{"/foo@1.0.0_bar@2.0.0", "foo", "1.0.0"},
+		{"/@scope/foo@1.0.0_react@18.2.0+react-dom@18.2.0", "@scope/foo", "1.0.0"},
+		{"/@scope/foo@1.0.0(react@18.2.0)", "@scope/foo", "1.0.0"},
+		{"/foo@1.0.0-beta.1(react@18.2.0)(react-dom@18.2.0)", "foo", "1.0.0-beta.1"},
+		{"/cookie/0.5.0", "cookie", "0.5.0"},
+		{"/@scope/util/1.2.0", "@scope/util", "1.2.0"},
+		{"/foo/1.0.0_react@18.2.0", "foo", "1.0.0"},
 		{"invalid-format", "", ""},
 		{"", "", ""},
 	}
@@ -430,3 +1176,301 @@ func TestExtractPnpmPackageInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestParseOverrides(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/package.json", `{
+		"dependencies": {"react": "^18.2.0"},
+		"overrides": {
+			"semver": "7.5.4",
+			"webpack": {".": "5.88.0", "@babel/core": "^7.22.0"},
+			"react-dom": "$react"
+		},
+		"resolutions": {
+			"**/minimist": "1.2.8",
+			"jest/@types/node": "20.4.0"
+		},
+		"pnpm": {"overrides": {"foo@1>bar": "2.0.0", "@scope/pkg@<2": "2.1.0"}}
+	}`)
+
+	overrides, err := ParseOverrides(fs, "/project/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Overrides scoped under webpack, jest and foo are skipped
+	expected := map[string]Override{
+		"semver":     {Spec: "7.5.4", Version: "7.5.4"},
+		"webpack":    {Spec: "5.88.0", Version: "5.88.0"},
+		"minimist":   {Spec: "1.2.8", Version: "1.2.8"},
+		"@scope/pkg": {Spec: "2.1.0", Version: "2.1.0"},
+	}
+	if len(overrides) != len(expected) {
+		t.Errorf("expected %d overrides, got %v", len(expected), overrides)
+	}
+	for name, override := range expected {
+		if overrides[name] != override {
+			t.Errorf("override %s: expected %+v, got %+v", name, override, overrides[name])
+		}
+	}
+}
+
+func TestParseOverrides_ScopedUnderSeveralParents(t *testing.T) {
+	// bar is pinned differently under two parents, so neither pin applies
+	// to bar everywhere
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/package.json", `{
+		"overrides": {"foo": {"bar": "1.0.0"}, "baz": {"bar": "2.0.0"}},
+		"resolutions": {"foo/bar": "1.0.0", "baz/bar": "2.0.0"}
+	}`)
+
+	overrides, err := ParseOverrides(fs, "/project/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(overrides) != 0 {
+		t.Errorf("expected no global overrides, got %v", overrides)
+	}
+}
+
+func TestParseDirect(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/package.json", `{
+		"dependencies": {"react": "^18.2.0"},
+		"devDependencies": {"jest": "^29.7.0"},
+		"optionalDependencies": {"fsevents": "^2.3.3"},
+		"peerDependencies": {"react-dom": "^18.0.0"},
+		"overrides": {"semver": "7.5.4"}
+	}`)
+
+	direct, err := ParseDirect(fs, "/project/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"react": "^18.2.0", "jest": "^29.7.0", "fsevents": "^2.3.3", "react-dom": "^18.0.0"}
+	if !reflect.DeepEqual(direct, expected) {
+		t.Errorf("expected %v, got %v", expected, direct)
+	}
+
+	if _, err := ParseDirect(fs, "/missing/package.json"); err == nil {
+		t.Error("expected an error for a missing package.json")
+	}
+}
+
+func TestParseBundled(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/package.json", `{
+		"dependencies": {"gpl-lib": "^1.0.0", "react": "^18.2.0"},
+		"bundleDependencies": ["gpl-lib"]
+	}`)
+	fs.AddFile("/all/package.json", `{
+		"dependencies": {"gpl-lib": "^1.0.0", "react": "^18.2.0"},
+		"bundledDependencies": true
+	}`)
+
+	bundled, err := ParseBundled(fs, "/project/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bundled) != 1 || !bundled["gpl-lib"] {
+		t.Errorf("expected gpl-lib to be bundled, got %v", bundled)
+	}
+
+	bundled, err = ParseBundled(fs, "/all/package.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bundled["gpl-lib"] || !bundled["react"] {
+		t.Errorf("expected every dependency to be bundled, got %v", bundled)
+	}
+}
+
+func BenchmarkNPMParser_Parse(b *testing.B) {
+	var packages strings.Builder
+	packages.WriteString(`{"lockfileVersion": 3, "packages": {"": {"dependencies": {"pkg-0": "^1.0.0"}}`)
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&packages, `, "node_modules/pkg-%d": {"version": "1.0.%d", "license": "MIT", "dependencies": {"pkg-%d": "^1.0.0"}}`, i, i, (i+1)%5000)
+	}
+	packages.WriteString(`}}`)
+
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/package-lock.json", packages.String())
+	parser := NewNPMParserWithFS(fs)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.Parse("/project/package-lock.json"); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestParsers_LocalDependencies(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/test/package-lock.json", `{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"dependencies": {"shared": "file:../shared", "vendored": "file:vendor/vendored-1.0.0.tgz", "lodash": "^4.17.21"}},
+			"node_modules/shared": {"resolved": "../shared", "link": true},
+			"../shared": {"name": "shared", "version": "2.0.0", "license": "MIT"},
+			"node_modules/vendored": {"version": "1.0.0", "resolved": "file:vendor/vendored-1.0.0.tgz"},
+			"node_modules/lodash": {"version": "4.17.21", "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz"}
+		}
+	}`)
+	fs.AddFile("/legacy/package-lock.json", `{
+		"lockfileVersion": 1,
+		"dependencies": {"shared": {"version": "file:../shared"}, "lodash": {"version": "4.17.21"}}
+	}`)
+	fs.AddFile("/yarn/yarn.lock", `# yarn lockfile v1
+
+"shared@file:../shared":
+  version "2.0.0"
+
+lodash@^4.17.21:
+  version "4.17.21"
+`)
+	fs.AddFile("/pnpm/pnpm-lock.yaml", `lockfileVersion: 5.4
+
+dependencies:
+  lodash: 4.17.21
+  shared: link:../shared
+  vendored: file:vendor/vendored
+
+packages:
+  /lodash@4.17.21:
+    resolution: {integrity: sha512-abc}
+  file:vendor/vendored:
+    resolution: {directory: vendor/vendored, type: directory}
+    name: vendored
+    version: 1.0.0
+`)
+
+	tests := []struct {
+		name     string
+		parse    func() ([]Dependency, error)
+		expected map[string]string
+	}{
+		{"npm", func() ([]Dependency, error) { return NewNPMParserWithFS(fs).Parse("/test/package-lock.json") },
+			map[string]string{"shared": "../shared", "vendored": "vendor/vendored-1.0.0.tgz", "lodash": ""}},
+		{"npm legacy", func() ([]Dependency, error) { return NewNPMParserWithFS(fs).Parse("/legacy/package-lock.json") },
+			map[string]string{"shared": "../shared", "lodash": ""}},
+		{"yarn", func() ([]Dependency, error) { return NewYarnParserWithFS(fs).Parse("/yarn/yarn.lock") },
+			map[string]string{"shared": "../shared", "lodash": ""}},
+		{"pnpm", func() ([]Dependency, error) { return NewPnpmParserWithFS(fs).Parse("/pnpm/pnpm-lock.yaml") },
+			map[string]string{"shared": "../shared", "vendored": "vendor/vendored", "lodash": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := tt.parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			local := make(map[string]string)
+			for _, dep := range deps {
+				local[dep.Name] = dep.Local
+			}
+			for name, expected := range tt.expected {
+				if actual, ok := local[name]; !ok || actual != expected {
+					t.Errorf("expected %s to reference %q, got %q (found: %v)", name, expected, actual, ok)
+				}
+			}
+		})
+	}
+
+	// Linked packages take their metadata from the entry of the link target
+	deps, _ := NewNPMParserWithFS(fs).Parse("/test/package-lock.json")
+	for _, dep := range deps {
+		if dep.Name == "shared" && (dep.Version != "2.0.0" || dep.License != "MIT" || dep.Depth != 1) {
+			t.Errorf("expected shared@2.0.0 (MIT) as a direct dependency, got %+v", dep)
+		}
+	}
+}
+
+func TestParsers_DevDependencies(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/npm/package-lock.json", `{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"dependencies": {"express": "^4.18.0"}, "devDependencies": {"jest": "^29.0.0"}},
+			"node_modules/express": {"version": "4.18.0"},
+			"node_modules/jest": {"version": "29.0.0", "dev": true}
+		}
+	}`)
+	fs.AddFile("/legacy/package-lock.json", `{
+		"lockfileVersion": 1,
+		"dependencies": {"express": {"version": "4.18.0"}, "jest": {"version": "29.0.0", "dev": true}}
+	}`)
+	fs.AddFile("/pnpm/pnpm-lock.yaml", `lockfileVersion: 5.4
+
+dependencies:
+  express: 4.18.0
+
+devDependencies:
+  jest: 29.0.0
+
+packages:
+  /express@4.18.0:
+    resolution: {integrity: sha512-abc}
+    dev: false
+  /jest@29.0.0:
+    resolution: {integrity: sha512-def}
+    dev: true
+`)
+	// yarn.lock does not record dev status: jest and its own dependency are
+	// dev only, while debug is also needed by express
+	fs.AddFile("/yarn/yarn.lock", `# yarn lockfile v1
+
+express@^4.18.0:
+  version "4.18.0"
+  dependencies:
+    debug "2.6.9"
+
+jest@^29.0.0:
+  version "29.0.0"
+  dependencies:
+    debug "2.6.9"
+    expect "29.0.0"
+
+debug@2.6.9:
+  version "2.6.9"
+
+expect@29.0.0:
+  version "29.0.0"
+`)
+	fs.AddFile("/yarn/package.json", `{"dependencies": {"express": "^4.18.0"}, "devDependencies": {"jest": "^29.0.0"}}`)
+	fs.AddFile("/bower/bower.json", `{"dependencies": {"jquery": "3.7.1"}, "devDependencies": {"qunit": "2.19.0"}}`)
+
+	tests := []struct {
+		name     string
+		parse    func() ([]Dependency, error)
+		expected map[string]bool
+	}{
+		{"npm", func() ([]Dependency, error) { return NewNPMParserWithFS(fs).Parse("/npm/package-lock.json") },
+			map[string]bool{"express": false, "jest": true}},
+		{"npm legacy", func() ([]Dependency, error) { return NewNPMParserWithFS(fs).Parse("/legacy/package-lock.json") },
+			map[string]bool{"express": false, "jest": true}},
+		{"pnpm", func() ([]Dependency, error) { return NewPnpmParserWithFS(fs).Parse("/pnpm/pnpm-lock.yaml") },
+			map[string]bool{"express": false, "jest": true}},
+		{"yarn", func() ([]Dependency, error) { return NewYarnParserWithFS(fs).Parse("/yarn/yarn.lock") },
+			map[string]bool{"express": false, "jest": true, "debug": false, "expect": true}},
+		{"bower", func() ([]Dependency, error) { return NewBowerParserWithFS(fs).Parse("/bower/bower.json") },
+			map[string]bool{"jquery": false, "qunit": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := tt.parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dev := make(map[string]bool)
+			for _, dep := range deps {
+				dev[dep.Name] = dep.Dev
+			}
+			if !reflect.DeepEqual(dev, tt.expected) {
+				t.Errorf("expected dev flags %v, got %v", tt.expected, dev)
+			}
+		})
+	}
+}
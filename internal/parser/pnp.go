@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// pnpData is the subset of .pnp.data.json used to locate packages. Its
+// packageRegistryData is a list of [name, [[reference, info], ...]] pairs.
+type pnpData struct {
+	PackageRegistryData []pnpRegistryEntry `json:"packageRegistryData"`
+}
+
+type pnpRegistryEntry struct {
+	Name      *string
+	Instances []pnpInstance
+}
+
+type pnpInstance struct {
+	Reference *string
+	Info      struct {
+		PackageLocation string `json:"packageLocation"`
+	}
+}
+
+func (e *pnpRegistryEntry) UnmarshalJSON(data []byte) error {
+	tuple := []interface{}{&e.Name, &e.Instances}
+	return json.Unmarshal(data, &tuple)
+}
+
+func (i *pnpInstance) UnmarshalJSON(data []byte) error {
+	tuple := []interface{}{&i.Reference, &i.Info}
+	return json.Unmarshal(data, &tuple)
+}
+
+// ParsePnpData reads the Yarn Plug'n'Play map of a project and returns the
+// location of every package, keyed by name@version and relative to the
+// project root. Locations usually point into a zip archive of .yarn/cache,
+// e.g. ".yarn/cache/lodash-npm-4.17.21-6382451519-eb835a2e51.zip/node_modules/lodash".
+func ParsePnpData(fs FileSystem, rootPath string) (map[string]string, error) {
+	var data pnpData
+	if err := readJSON(fs, fs.Join(rootPath, constants.PnpDataJSON), &data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", constants.PnpDataJSON, err)
+	}
+
+	locations := make(map[string]string)
+	for _, entry := range data.PackageRegistryData {
+		// The null entry is the top-level workspace
+		if entry.Name == nil {
+			continue
+		}
+		for _, instance := range entry.Instances {
+			if instance.Reference == nil || instance.Info.PackageLocation == "" {
+				continue
+			}
+			version, ok := pnpVersion(*instance.Reference)
+			if !ok {
+				continue
+			}
+			location := path.Clean(instance.Info.PackageLocation)
+			// Virtual instances of the same package share one location
+			if _, exists := locations[*entry.Name+"@"+version]; !exists {
+				locations[*entry.Name+"@"+version] = location
+			}
+		}
+	}
+
+	return locations, nil
+}
+
+// pnpVersion extracts the version from a registry package reference such as
+// "npm:4.17.21" or "virtual:<hash>#npm:4.17.21"
+func pnpVersion(reference string) (string, bool) {
+	if index := strings.LastIndex(reference, "#"); index >= 0 {
+		reference = reference[index+1:]
+	}
+	return strings.CutPrefix(reference, "npm:")
+}
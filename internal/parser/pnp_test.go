@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePnpData(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/repo/.pnp.data.json", `{
+		"__info": ["This file is automatically generated. Do not touch it."],
+		"dependencyTreeRoots": [{"name": "my-app", "reference": "workspace:."}],
+		"packageRegistryData": [
+			[null, [
+				[null, {"packageLocation": "./", "packageDependencies": [["lodash", "npm:4.17.21"]], "linkType": "SOFT"}]
+			]],
+			["my-app", [
+				["workspace:.", {"packageLocation": "./", "packageDependencies": [], "linkType": "SOFT"}]
+			]],
+			["lodash", [
+				["npm:4.17.21", {"packageLocation": "./.yarn/cache/lodash-npm-4.17.21-6382451519-eb835a2e51.zip/node_modules/lodash/", "packageDependencies": [["lodash", "npm:4.17.21"]], "linkType": "HARD"}]
+			]],
+			["@babel/core", [
+				["npm:7.22.0", {"packageLocation": "./.yarn/cache/@babel-core-npm-7.22.0-2a5b1c3d4e-0123456789.zip/node_modules/@babel/core/", "linkType": "HARD"}],
+				["virtual:0123abcd#npm:7.22.0", {"packageLocation": "./.yarn/__virtual__/@babel-core-virtual-0123abcd/0/cache/@babel-core-npm-7.22.0-2a5b1c3d4e-0123456789.zip/node_modules/@babel/core/", "linkType": "HARD"}]
+			]],
+			["esbuild", [
+				["npm:0.19.0", {"packageLocation": "./.yarn/unplugged/esbuild-npm-0.19.0-0123456789/node_modules/esbuild/", "linkType": "HARD"}]
+			]]
+		]
+	}`)
+
+	locations, err := ParsePnpData(fs, "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"lodash@4.17.21":     ".yarn/cache/lodash-npm-4.17.21-6382451519-eb835a2e51.zip/node_modules/lodash",
+		"@babel/core@7.22.0": ".yarn/cache/@babel-core-npm-7.22.0-2a5b1c3d4e-0123456789.zip/node_modules/@babel/core",
+		"esbuild@0.19.0":     ".yarn/unplugged/esbuild-npm-0.19.0-0123456789/node_modules/esbuild",
+	}
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("expected %v, got %v", expected, locations)
+	}
+
+	if _, err := ParsePnpData(fs, "/missing"); err == nil {
+		t.Error("expected an error without a .pnp.data.json")
+	}
+}
@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// pythonNamePattern matches a valid Python distribution name
+var pythonNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// pythonNameSeparators are the runs of characters PEP 503 treats as one dash
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePythonName returns the PEP 503 form of a distribution name, under
+// which Requests, requests and python_requests-style spellings match
+func NormalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// PoetryParser implements parsing for poetry.lock files
+type PoetryParser struct {
+	fs FileSystem
+}
+
+func NewPoetryParser() *PoetryParser {
+	return &PoetryParser{fs: &RealFileSystem{}}
+}
+
+func NewPoetryParserWithFS(fs FileSystem) *PoetryParser {
+	return &PoetryParser{fs: fs}
+}
+
+// Parse reads the [[package]] entries of a poetry.lock and the graph from
+// their [package.dependencies] tables. The pyproject.toml next to it says
+// which packages are direct and which are only in dev groups.
+func (p *PoetryParser) Parse(lockFilePath string) ([]Dependency, error) {
+	file, err := p.fs.Open(lockFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open poetry.lock: %w", err)
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	tables, err := readTOMLTables(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read poetry.lock: %w", err)
+	}
+
+	var dependencies []Dependency
+	// Old lock files put dev packages in the "dev" category
+	var categoryProd, categoryDev []string
+	for _, table := range tables {
+		switch table.name {
+		case "[[package]]":
+			dependencies = append(dependencies, Dependency{
+				Name:    NormalizePythonName(table.values["name"]),
+				Version: table.values["version"],
+			})
+			switch table.values["category"] {
+			case "main":
+				categoryProd = append(categoryProd, NormalizePythonName(table.values["name"]))
+			case "dev":
+				categoryDev = append(categoryDev, NormalizePythonName(table.values["name"]))
+			}
+		case "[package.dependencies]":
+			if len(dependencies) == 0 {
+				continue
+			}
+			last := &dependencies[len(dependencies)-1]
+			for _, name := range table.keys {
+				last.Dependencies = mergeUnique(last.Dependencies, []string{NormalizePythonName(name)})
+			}
+		}
+	}
+
+	prod, dev := p.pyprojectDependencies(filepath.Dir(lockFilePath))
+	assignDepths(dependencies, append(append([]string{}, prod...), dev...))
+	switch {
+	case len(prod)+len(dev) > 0:
+		markDev(dependencies, prod, dev)
+	case len(categoryDev) > 0:
+		for i, dep := range dependencies {
+			dependencies[i].Dev = !slices.Contains(categoryProd, dep.Name)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// pyprojectDependencies returns the normalized names of the main and the
+// dev group dependencies of a pyproject.toml; python itself is not a package
+func (p *PoetryParser) pyprojectDependencies(dir string) (prod, dev []string) {
+	file, err := p.fs.Open(p.fs.Join(dir, constants.PyprojectTOMLFile))
+	if err != nil {
+		return nil, nil
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	tables, err := readTOMLTables(file)
+	if err != nil {
+		return nil, nil
+	}
+	for _, table := range tables {
+		group, isGroup := strings.CutPrefix(table.name, "[tool.poetry.group.")
+		for _, name := range table.keys {
+			if strings.EqualFold(name, "python") {
+				continue
+			}
+			switch {
+			case table.name == "[tool.poetry.dependencies]", isGroup && group == "main.dependencies]":
+				prod = append(prod, NormalizePythonName(name))
+			case table.name == "[tool.poetry.dev-dependencies]", isGroup && strings.HasSuffix(group, ".dependencies]"):
+				dev = append(dev, NormalizePythonName(name))
+			}
+		}
+	}
+	return prod, dev
+}
+
+// PipenvParser implements parsing for Pipfile.lock files
+type PipenvParser struct {
+	fs FileSystem
+}
+
+func NewPipenvParser() *PipenvParser {
+	return &PipenvParser{fs: &RealFileSystem{}}
+}
+
+func NewPipenvParserWithFS(fs FileSystem) *PipenvParser {
+	return &PipenvParser{fs: fs}
+}
+
+// Parse reads the default and develop sections of a Pipfile.lock; packages
+// only in develop are dev dependencies. The lock file is flat, so the
+// packages the Pipfile lists are direct; without a Pipfile all of them are.
+func (p *PipenvParser) Parse(lockFilePath string) ([]Dependency, error) {
+	var lockFile struct {
+		Default map[string]struct {
+			Version string `json:"version"`
+		} `json:"default"`
+		Develop map[string]struct {
+			Version string `json:"version"`
+		} `json:"develop"`
+	}
+	if err := readJSON(p.fs, lockFilePath, &lockFile); err != nil {
+		return nil, fmt.Errorf("failed to parse Pipfile.lock: %w", err)
+	}
+
+	direct := make(map[string]bool)
+	if file, err := p.fs.Open(p.fs.Join(filepath.Dir(lockFilePath), constants.PipfileFile)); err == nil {
+		if tables, err := readTOMLTables(file); err == nil {
+			for _, table := range tables {
+				if table.name == "[packages]" || table.name == "[dev-packages]" {
+					for _, name := range table.keys {
+						direct[NormalizePythonName(name)] = true
+					}
+				}
+			}
+		}
+		_ = file.Close() // Ignore close error as we already read the file
+	}
+
+	var dependencies []Dependency
+	seen := make(map[string]bool)
+	add := func(name, version string, dev bool) {
+		dep := Dependency{
+			Name:    NormalizePythonName(name),
+			Version: strings.TrimPrefix(version, "=="),
+			Dev:     dev,
+		}
+		if seen[dep.Name+"@"+dep.Version] {
+			return
+		}
+		seen[dep.Name+"@"+dep.Version] = true
+		if len(direct) == 0 || direct[dep.Name] {
+			dep.Depth, dep.Direct = 1, true
+		}
+		dependencies = append(dependencies, dep)
+	}
+	for _, name := range sortedKeys(lockFile.Default) {
+		add(name, lockFile.Default[name].Version, false)
+	}
+	for _, name := range sortedKeys(lockFile.Develop) {
+		add(name, lockFile.Develop[name].Version, true)
+	}
+
+	return dependencies, nil
+}
+
+// RequirementsParser implements parsing for pip requirements.txt files
+type RequirementsParser struct {
+	fs FileSystem
+}
+
+func NewRequirementsParser() *RequirementsParser {
+	return &RequirementsParser{fs: &RealFileSystem{}}
+}
+
+func NewRequirementsParserWithFS(fs FileSystem) *RequirementsParser {
+	return &RequirementsParser{fs: fs}
+}
+
+// requirementPattern matches a requirement line: the name, optional extras
+// and the version specifiers up to an environment marker
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*([^;]*)`)
+
+// Parse reads the requirements of a requirements.txt, which are all top
+// level. Pinned name==version lines are resolved; other specifiers are kept
+// as unresolved versions, like package.json ranges. Options, includes and
+// URLs are skipped.
+func (p *RequirementsParser) Parse(requirementsPath string) ([]Dependency, error) {
+	file, err := p.fs.Open(requirementsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open requirements.txt: %w", err)
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	var dependencies []Dependency
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if hash := strings.Index(line, " #"); hash >= 0 {
+			line = line[:hash]
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+
+		match := requirementPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		specifier := strings.Join(strings.Fields(match[2]), "")
+		dep := Dependency{Name: NormalizePythonName(match[1]), Depth: 1, Direct: true}
+		if version, pinned := strings.CutPrefix(specifier, "=="); pinned && !strings.ContainsAny(version, ",*") {
+			dep.Version = version
+		} else {
+			dep.Version, dep.Unresolved = specifier, true
+		}
+		dependencies = append(dependencies, dep)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+
+	return dependencies, nil
+}
+
+// tomlTable is a table of a TOML file with its top level keys and their
+// string values
+type tomlTable struct {
+	name   string
+	keys   []string
+	values map[string]string
+}
+
+// readTOMLTables reads the tables of the simple TOML files lock files are:
+// one key per line, headers on their own lines. Values spanning several
+// lines are skipped.
+func readTOMLTables(r io.Reader) ([]tomlTable, error) {
+	tables := []tomlTable{{values: make(map[string]string)}}
+	depth := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if depth > 0 {
+			depth += strings.Count(line, "[") + strings.Count(line, "{") - strings.Count(line, "]") - strings.Count(line, "}")
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			tables = append(tables, tomlTable{name: line, values: make(map[string]string)})
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key, value = unquoteTOML(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			depth = strings.Count(value, "[") + strings.Count(value, "{") - strings.Count(value, "]") - strings.Count(value, "}")
+		}
+		if !pythonNamePattern.MatchString(key) {
+			continue
+		}
+		table := &tables[len(tables)-1]
+		table.keys = append(table.keys, key)
+		table.values[key] = unquoteTOML(value)
+	}
+	return tables, scanner.Err()
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePythonName(t *testing.T) {
+	tests := map[string]string{
+		"requests":          "requests",
+		"PyYAML":            "pyyaml",
+		"typing_extensions": "typing-extensions",
+		"zope.interface":    "zope-interface",
+		"Foo__Bar-.baz":     "foo-bar-baz",
+	}
+	for name, expected := range tests {
+		if got := NormalizePythonName(name); got != expected {
+			t.Errorf("NormalizePythonName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestPoetryParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/poetry.lock", `# This file is automatically @generated by Poetry 1.8.2 and should not be changed by hand.
+
+[[package]]
+name = "certifi"
+version = "2023.7.22"
+description = "Python package for providing Mozilla's CA Bundle."
+optional = false
+python-versions = ">=3.6"
+files = [
+    {file = "certifi-2023.7.22-py3-none-any.whl", hash = "sha256:92d6037539857d8206b8f6ae472e8b77db8058fec5937a1ef3f54304089edbb9"},
+    {file = "certifi-2023.7.22.tar.gz", hash = "sha256:539cc1d13202e33ca466e88b2807e29f4c13049d6d87031a3c110744495cb082"},
+]
+
+[[package]]
+name = "pytest"
+version = "7.4.2"
+description = "pytest: simple powerful testing with Python"
+optional = false
+python-versions = ">=3.7"
+
+[package.dependencies]
+pluggy = ">=0.12,<2.0"
+
+[package.extras]
+testing = ["argcomplete", "hypothesis (>=3.56)"]
+
+[[package]]
+name = "pluggy"
+version = "1.3.0"
+description = "plugin and hook calling mechanisms for python"
+optional = false
+python-versions = ">=3.8"
+
+[[package]]
+name = "requests"
+version = "2.31.0"
+description = "Python HTTP for Humans."
+optional = false
+python-versions = ">=3.7"
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+"charset-normalizer" = ">=2,<4"
+
+[[package]]
+name = "charset-normalizer"
+version = "3.3.0"
+description = "The Real First Universal Charset Detector."
+optional = false
+python-versions = ">=3.7.0"
+
+[metadata]
+lock-version = "2.0"
+python-versions = "^3.11"
+content-hash = "7e6c5c5a0e5b3f0e2a1c"
+`)
+	fs.AddFile("/project/pyproject.toml", `[tool.poetry]
+name = "myapp"
+version = "0.1.0"
+authors = [
+    "Jane Doe <jane@example.com>",
+]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+Requests = "^2.31"
+
+[tool.poetry.group.dev.dependencies]
+pytest = { version = "^7.4", optional = false }
+`)
+
+	deps, err := NewPoetryParserWithFS(fs).Parse("/project/poetry.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Dependency{
+		{Name: "certifi", Version: "2023.7.22", Depth: 2},
+		{Name: "pytest", Version: "7.4.2", Dependencies: []string{"pluggy"}, Depth: 1, Dev: true},
+		{Name: "pluggy", Version: "1.3.0", Depth: 2, Dev: true},
+		{Name: "requests", Version: "2.31.0", Dependencies: []string{"certifi", "charset-normalizer"}, Depth: 1},
+		{Name: "charset-normalizer", Version: "3.3.0", Depth: 2},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestPoetryParser_Parse_Category(t *testing.T) {
+	// Lock files of Poetry before 1.2 record dev packages by category
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/poetry.lock", `[[package]]
+name = "black"
+version = "23.9.1"
+category = "dev"
+
+[[package]]
+name = "click"
+version = "8.1.7"
+category = "main"
+`)
+
+	deps, err := NewPoetryParserWithFS(fs).Parse("/project/poetry.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps) != 2 || !deps[0].Dev || deps[1].Dev {
+		t.Errorf("expected black dev and click prod, got %+v", deps)
+	}
+}
+
+func TestPipenvParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/Pipfile.lock", `{
+    "_meta": {
+        "hash": {"sha256": "b8c2"},
+        "pipfile-spec": 6,
+        "requires": {"python_version": "3.11"}
+    },
+    "default": {
+        "certifi": {"hashes": ["sha256:92d6"], "version": "==2023.7.22"},
+        "requests": {"hashes": ["sha256:58cd"], "index": "pypi", "version": "==2.31.0"}
+    },
+    "develop": {
+        "pytest": {"hashes": ["sha256:1d88"], "index": "pypi", "version": "==7.4.2"},
+        "requests": {"hashes": ["sha256:58cd"], "version": "==2.31.0"}
+    }
+}`)
+	fs.AddFile("/project/Pipfile", `[[source]]
+url = "https://pypi.org/simple"
+verify_ssl = true
+name = "pypi"
+
+[packages]
+requests = "*"
+
+[dev-packages]
+pytest = "*"
+`)
+
+	deps, err := NewPipenvParserWithFS(fs).Parse("/project/Pipfile.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Packages in both sections are needed in production
+	expected := []Dependency{
+		{Name: "certifi", Version: "2023.7.22"},
+		{Name: "requests", Version: "2.31.0", Depth: 1, Direct: true},
+		{Name: "pytest", Version: "7.4.2", Depth: 1, Direct: true, Dev: true},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestPipenvParser_Parse_NoPipfile(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/Pipfile.lock", `{"default": {"requests": {"version": "==2.31.0"}}, "develop": {}}`)
+
+	deps, err := NewPipenvParserWithFS(fs).Parse("/project/Pipfile.lock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without a Pipfile nothing says what is transitive
+	expected := []Dependency{{Name: "requests", Version: "2.31.0", Depth: 1, Direct: true}}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestRequirementsParser_Parse(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/project/requirements.txt", `# Pinned with pip freeze
+--index-url https://pypi.org/simple
+-r base.txt
+
+Django==4.2.5
+PyYAML == 6.0.1  # config files
+requests[socks]==2.31.0 ; python_version >= "3.7"
+urllib3>=1.21.1,<3
+git+https://github.com/psf/black.git#egg=black
+typing_extensions==4.*
+`)
+
+	deps, err := NewRequirementsParserWithFS(fs).Parse("/project/requirements.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Dependency{
+		{Name: "django", Version: "4.2.5", Depth: 1, Direct: true},
+		{Name: "pyyaml", Version: "6.0.1", Depth: 1, Direct: true},
+		{Name: "requests", Version: "2.31.0", Depth: 1, Direct: true},
+		{Name: "urllib3", Version: ">=1.21.1,<3", Depth: 1, Direct: true, Unresolved: true},
+		{Name: "typing-extensions", Version: "==4.*", Depth: 1, Direct: true, Unresolved: true},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, deps)
+	}
+}
+
+func TestDetectLockFile_Python(t *testing.T) {
+	tests := []struct {
+		file    string
+		manager string
+	}{
+		{"poetry.lock", "poetry"},
+		{"Pipfile.lock", "pipenv"},
+		{"requirements.txt", "pip"},
+	}
+	for _, tt := range tests {
+		fs := NewMockFileSystem()
+		fs.AddFile("/project/"+tt.file, "")
+
+		path, manager, err := DetectLockFile(fs, "/project")
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.file, err)
+		}
+		if path != "/project/"+tt.file || manager != tt.manager {
+			t.Errorf("expected %s for %s, got %s at %s", tt.manager, tt.file, manager, path)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// WorkspaceMember is a package of a workspace, found through its manifest
+type WorkspaceMember struct {
+	// Path is the member directory relative to the workspace root
+	Path    string
+	Name    string
+	Version string
+	License string
+	// Dependencies are the names the member declares, dev ones included
+	Dependencies []string
+}
+
+// ParsePnpmWorkspace enumerates the members of a pnpm workspace by resolving
+// the "packages" globs of its pnpm-workspace.yaml
+func ParsePnpmWorkspace(fs FileSystem, rootPath string) ([]WorkspaceMember, error) {
+	file, err := fs.Open(fs.Join(rootPath, constants.PnpmWorkspaceYAML))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pnpm-workspace.yaml: %w", err)
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pnpm-workspace.yaml: %w", err)
+	}
+	var workspace struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &workspace); err != nil {
+		return nil, fmt.Errorf("failed to parse pnpm-workspace.yaml: %w", err)
+	}
+
+	return resolveWorkspaceMembers(fs, rootPath, workspace.Packages)
+}
+
+// ParseNPMWorkspaces enumerates the members of an npm or Yarn workspace from
+// the "workspaces" globs of the root package.json, given either as a list or
+// as {"packages": [...]}
+func ParseNPMWorkspaces(fs FileSystem, rootPath string) ([]WorkspaceMember, error) {
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := readJSON(fs, fs.Join(rootPath, constants.PackageJSONFile), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	if len(manifest.Workspaces) == 0 {
+		return nil, fmt.Errorf("package.json declares no workspaces")
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(manifest.Workspaces, &patterns); err != nil {
+		var nested struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(manifest.Workspaces, &nested); err != nil {
+			return nil, fmt.Errorf("failed to parse package.json workspaces: %w", err)
+		}
+		patterns = nested.Packages
+	}
+	return resolveWorkspaceMembers(fs, rootPath, patterns)
+}
+
+// resolveWorkspaceMembers resolves workspace globs to the member packages.
+// Patterns starting with "!" exclude directories, "**" matches any depth and
+// node_modules is never searched. Directories without a package.json are
+// not members.
+func resolveWorkspaceMembers(fs FileSystem, rootPath string, patterns []string) ([]WorkspaceMember, error) {
+	lister, ok := fs.(DirReader)
+	if !ok {
+		return nil, fmt.Errorf("file system cannot list directories")
+	}
+
+	var include, exclude []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+		if negated, found := strings.CutPrefix(pattern, "!"); found {
+			exclude = append(exclude, strings.TrimPrefix(negated, "./"))
+		} else if pattern != "" {
+			include = append(include, pattern)
+		}
+	}
+
+	var dirs []string
+	for _, pattern := range include {
+		dirs = mergeUnique(dirs, expandGlob(fs, lister, rootPath, ".", strings.Split(strings.Trim(pattern, "/"), "/")))
+	}
+	sort.Strings(dirs)
+
+	var members []WorkspaceMember
+	for _, dir := range dirs {
+		if matchesAny(dir, exclude) {
+			continue
+		}
+		var manifest struct {
+			Name            string            `json:"name"`
+			Version         string            `json:"version"`
+			License         interface{}       `json:"license"`
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if err := readJSON(fs, fs.Join(rootPath, dir, constants.PackageJSONFile), &manifest); err != nil {
+			continue
+		}
+		license, _ := manifest.License.(string)
+		member := WorkspaceMember{Path: dir, Name: manifest.Name, Version: manifest.Version, License: license}
+		for _, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies} {
+			member.Dependencies = append(member.Dependencies, sortedKeys(deps)...)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// expandGlob returns the directories below dir (relative to rootPath)
+// matching the remaining pattern segments
+func expandGlob(fs FileSystem, lister DirReader, rootPath, dir string, segments []string) []string {
+	if len(segments) == 0 {
+		return []string{dir}
+	}
+
+	segment := segments[0]
+	if segment == "**" {
+		// Match zero directories, or descend one and keep the **
+		matches := expandGlob(fs, lister, rootPath, dir, segments[1:])
+		for _, child := range childDirs(fs, lister, rootPath, dir) {
+			matches = append(matches, expandGlob(fs, lister, rootPath, child, segments)...)
+		}
+		return matches
+	}
+
+	var matches []string
+	for _, child := range childDirs(fs, lister, rootPath, dir) {
+		if matched, _ := path.Match(segment, path.Base(child)); matched {
+			matches = append(matches, expandGlob(fs, lister, rootPath, child, segments[1:])...)
+		}
+	}
+	return matches
+}
+
+// childDirs lists the subdirectories of dir, skipping node_modules and hidden ones
+func childDirs(fs FileSystem, lister DirReader, rootPath, dir string) []string {
+	full := rootPath
+	if dir != "." {
+		full = fs.Join(rootPath, dir)
+	}
+	entries, err := lister.ReadDir(full)
+	if err != nil {
+		return nil
+	}
+	var children []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == constants.NodeModulesDir || strings.HasPrefix(name, ".") {
+			continue
+		}
+		children = append(children, path.Join(dir, name))
+	}
+	return children
+}
+
+// matchesAny reports whether dir matches one of the glob patterns
+func matchesAny(dir string, patterns []string) bool {
+	for _, pattern := range patterns {
+		segments := strings.Split(strings.Trim(pattern, "/"), "/")
+		if matchSegments(strings.Split(dir, "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(parts, segments []string) bool {
+	if len(segments) == 0 {
+		return len(parts) == 0
+	}
+	if segments[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(parts[i:], segments[1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	matched, _ := path.Match(segments[0], parts[0])
+	return matched && matchSegments(parts[1:], segments[1:])
+}
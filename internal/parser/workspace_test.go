@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePnpmWorkspace(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/repo/pnpm-workspace.yaml", `packages:
+  - "packages/*"
+  - "apps/**"
+  - "!**/fixtures/**"
+`)
+	fs.AddFile("/repo/packages/ui/package.json", `{"name": "@acme/ui", "version": "1.2.0", "license": "MIT"}`)
+	fs.AddFile("/repo/packages/utils/package.json", `{"name": "@acme/utils", "version": "0.3.0"}`)
+	fs.AddFile("/repo/packages/utils/node_modules/lodash/package.json", `{"name": "lodash", "version": "4.17.21"}`)
+	fs.AddFile("/repo/packages/docs/README.md", "no manifest, not a member")
+	fs.AddFile("/repo/apps/web/package.json", `{"name": "web", "version": "0.0.1", "private": true}`)
+	fs.AddFile("/repo/apps/web/fixtures/app/package.json", `{"name": "fixture"}`)
+	fs.AddFile("/repo/tools/package.json", `{"name": "tools"}`)
+
+	members, err := ParsePnpmWorkspace(fs, "/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []WorkspaceMember{
+		{Path: "apps/web", Name: "web", Version: "0.0.1"},
+		{Path: "packages/ui", Name: "@acme/ui", Version: "1.2.0", License: "MIT"},
+		{Path: "packages/utils", Name: "@acme/utils", Version: "0.3.0"},
+	}
+	if !reflect.DeepEqual(members, expected) {
+		t.Errorf("expected %+v, got %+v", expected, members)
+	}
+
+	if _, err := ParsePnpmWorkspace(fs, "/missing"); err == nil {
+		t.Error("expected an error without pnpm-workspace.yaml")
+	}
+}
+
+func TestParseNPMWorkspaces(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/repo/packages/api/package.json", `{"name": "api", "dependencies": {"ms": "^2.1.3"}, "devDependencies": {"jest": "^29.0.0"}}`)
+	fs.AddFile("/repo/packages/web/package.json", `{"name": "web", "version": "1.0.0"}`)
+	fs.AddFile("/repo/packages/legacy/package.json", `{"name": "legacy"}`)
+	expected := []WorkspaceMember{
+		{Path: "packages/api", Name: "api", Dependencies: []string{"ms", "jest"}},
+		{Path: "packages/web", Name: "web", Version: "1.0.0"},
+	}
+
+	// npm lists the globs, Yarn classic may nest them under "packages"
+	for _, manifest := range []string{
+		`{"workspaces": ["packages/*", "!packages/legacy"]}`,
+		`{"workspaces": {"packages": ["packages/*", "!packages/legacy"], "nohoist": ["**/jest"]}}`,
+	} {
+		fs.AddFile("/repo/package.json", manifest)
+		members, err := ParseNPMWorkspaces(fs, "/repo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(members, expected) {
+			t.Errorf("%s: expected %+v, got %+v", manifest, expected, members)
+		}
+	}
+
+	fs.AddFile("/repo/package.json", `{"name": "single"}`)
+	if _, err := ParseNPMWorkspaces(fs, "/repo"); err == nil {
+		t.Error("expected an error without workspaces")
+	}
+}
+
+func TestPnpmParser_Parse_Importers(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile("/repo/pnpm-lock.yaml", `lockfileVersion: 5.4
+
+importers:
+  .:
+    devDependencies:
+      typescript: 5.0.0
+  apps/web:
+    dependencies:
+      '@acme/ui': link:../../packages/ui
+      lodash: 4.17.21
+  packages/ui:
+    dependencies:
+      lodash: 4.17.21
+
+packages:
+  /lodash@4.17.21:
+    resolution: {integrity: sha512-abc}
+  /typescript@5.0.0:
+    resolution: {integrity: sha512-def}
+    dev: true
+`)
+
+	deps, err := NewPnpmParserWithFS(fs).Parse("/repo/pnpm-lock.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := make(map[string]Dependency)
+	for _, dep := range deps {
+		byName[dep.Name] = dep
+	}
+	if ui := byName["@acme/ui"]; ui.Local != "packages/ui" || ui.Depth != 1 {
+		t.Errorf("expected the linked member resolved relative to its importer, got %+v", ui)
+	}
+	if lodash := byName["lodash"]; lodash.Depth != 1 {
+		t.Errorf("expected importer dependencies to be direct, got %+v", lodash)
+	}
+	if len(deps) != 3 {
+		t.Errorf("expected 3 dependencies, got %+v", deps)
+	}
+}
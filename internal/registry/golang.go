@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultPkgGoDevURL is the Go package discovery site
+const DefaultPkgGoDevURL = "https://pkg.go.dev"
+
+// pkgGoDevLicensePattern matches the license type headings of the licenses
+// tab, e.g. <div id="#lic-0">BSD-3-Clause</div>
+var pkgGoDevLicensePattern = regexp.MustCompile(`id="#?lic-\d+"[^>]*>([^<]+)<`)
+
+// GoClient looks up the licenses pkg.go.dev detected for Go modules
+type GoClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGoClient creates a client for pkg.go.dev
+func NewGoClient() *GoClient {
+	return NewGoClientWithURL(DefaultPkgGoDevURL)
+}
+
+// NewGoClientWithURL creates a client for a pkg.go.dev compatible site
+func NewGoClientWithURL(baseURL string) *GoClient {
+	return &GoClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// License returns the licenses of module@version, joined with AND when the
+// module ships several
+func (c *GoClient) License(module, version string) (string, error) {
+	endpoint := c.baseURL + "/" + module + "@" + version + "?tab=licenses"
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("pkg.go.dev request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore close error as we already read the body
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("pkg.go.dev returned %s for %s@%s", resp.Status, module, version)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLicenseText))
+	if err != nil {
+		return "", fmt.Errorf("failed to read pkg.go.dev response: %w", err)
+	}
+
+	var licenses []string
+	seen := make(map[string]bool)
+	for _, match := range pkgGoDevLicensePattern.FindAllStringSubmatch(string(data), -1) {
+		for _, license := range strings.Split(html.UnescapeString(match[1]), ",") {
+			license = strings.TrimSpace(license)
+			if license != "" && !seen[license] {
+				seen[license] = true
+				licenses = append(licenses, license)
+			}
+		}
+	}
+	return strings.Join(licenses, " AND "), nil
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoClient_License(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/golang.org/x/text@v0.14.0":
+			if r.URL.Query().Get("tab") != "licenses" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, `<section class="License"><h2><div id="#lic-0">BSD-3-Clause</div></h2><pre>...</pre></section>`)
+		case "/example.com/dual@v1.0.0":
+			fmt.Fprint(w, `<h2><div id="#lic-0">Apache-2.0, MIT</div></h2><h2><div id="#lic-1">MIT</div></h2>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewGoClientWithURL(server.URL)
+
+	license, err := client.License("golang.org/x/text", "v0.14.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if license != "BSD-3-Clause" {
+		t.Errorf("expected BSD-3-Clause, got %q", license)
+	}
+
+	if license, _ := client.License("example.com/dual", "v1.0.0"); license != "Apache-2.0 AND MIT" {
+		t.Errorf("expected the licenses joined with AND, got %q", license)
+	}
+
+	if _, err := client.License("example.com/missing", "v1.0.0"); err == nil {
+		t.Error("expected an error for an unknown module")
+	}
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the public npm registry
+const DefaultURL = "https://registry.npmjs.org"
+
+// DefaultCacheTTL is how long cached registry responses are reused when the
+// registry does not send a Cache-Control max-age
+const DefaultCacheTTL = 24 * time.Hour
+
+// Client looks up package metadata in an npm-compatible registry
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	cache      *Cache
+}
+
+// New creates a client for the public npm registry
+func New() *Client {
+	return NewWithURL(DefaultURL)
+}
+
+// NewWithURL creates a client for a custom registry
+func NewWithURL(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewWithCache creates a client that persists responses in the given cache
+func NewWithCache(baseURL string, cache *Cache) *Client {
+	client := NewWithURL(baseURL)
+	client.cache = cache
+	return client
+}
+
+// versionMetadata is the subset of the registry's package version document we use
+type versionMetadata struct {
+	License interface{} `json:"license"`
+}
+
+// License returns the license declared in the registry for name@version
+func (c *Client) License(name, version string) (string, error) {
+	key := c.baseURL + "/" + name + "@" + version
+	if c.cache != nil {
+		if license, ok := c.cache.Get(key); ok {
+			return license, nil
+		}
+	}
+
+	endpoint := c.baseURL + "/" + url.PathEscape(name) + "/" + url.PathEscape(version)
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("registry request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore close error as we already read the body
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s@%s", resp.Status, name, version)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read registry response: %w", err)
+	}
+
+	var metadata versionMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("failed to parse registry response: %w", err)
+	}
+
+	license := licenseString(metadata.License)
+	if c.cache != nil {
+		if maxAge, _, cacheable := parseCacheControl(resp.Header.Get("Cache-Control")); cacheable {
+			_ = c.cache.Set(key, license, maxAge) // A failed cache write only costs a future lookup
+		}
+	}
+
+	return license, nil
+}
+
+// licenseString extracts the license id from the string or legacy object form
+func licenseString(field interface{}) string {
+	switch v := field.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if typeVal, ok := v["type"].(string); ok {
+			return typeVal
+		}
+	}
+	return ""
+}
+
+// parseCacheControl returns the max-age of a Cache-Control header, whether
+// the header has one and whether the response may be stored at all. A
+// max-age of zero forbids reuse, so such responses are not stored.
+func parseCacheControl(header string) (maxAge time.Duration, hasMaxAge, cacheable bool) {
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0, false, false
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				maxAge, hasMaxAge = time.Duration(seconds)*time.Second, true
+			}
+		}
+	}
+	return maxAge, hasMaxAge, !hasMaxAge || maxAge > 0
+}
+
+// Cache persists registry responses on disk, one file per registry+name+version
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	License   string    `json:"license"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewCache creates an on-disk cache in dir with the given default TTL
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Get returns the cached license for key if present and not expired
+func (c *Cache) Get(key string) (string, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false
+	}
+	if !c.now().Before(entry.ExpiresAt) {
+		return "", false
+	}
+
+	return entry.License, true
+}
+
+// Set stores the license for key. A positive maxAge overrides the default TTL.
+func (c *Cache) Set(key, license string, maxAge time.Duration) error {
+	ttl := c.ttl
+	if maxAge > 0 {
+		ttl = maxAge
+	}
+
+	data, err := json.Marshal(cacheEntry{License: license, ExpiresAt: c.now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create registry cache: %w", err)
+	}
+	// Write and rename so concurrent lookups never read a partial entry
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write registry cache: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write registry cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
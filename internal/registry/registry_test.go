@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRegistry(t *testing.T, cacheControl string) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		switch r.URL.Path {
+		case "/lodash/4.17.21":
+			fmt.Fprint(w, `{"name": "lodash", "version": "4.17.21", "license": "MIT"}`)
+		case "/@types/node/18.0.0":
+			fmt.Fprint(w, `{"name": "@types/node", "version": "18.0.0", "license": {"type": "MIT"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestClient_License(t *testing.T) {
+	server, _ := newTestRegistry(t, "")
+	client := NewWithURL(server.URL)
+
+	tests := []struct {
+		name, version, expected string
+	}{
+		{"lodash", "4.17.21", "MIT"},
+		{"@types/node", "18.0.0", "MIT"},
+	}
+	for _, tt := range tests {
+		license, err := client.License(tt.name, tt.version)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if license != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, license)
+		}
+	}
+
+	if _, err := client.License("missing", "1.0.0"); err == nil {
+		t.Error("expected error for missing package")
+	}
+}
+
+func TestClient_CacheHit(t *testing.T) {
+	server, hits := newTestRegistry(t, "")
+	cache := NewCache(t.TempDir(), time.Hour)
+
+	first := NewWithCache(server.URL, cache)
+	if _, err := first.License("lodash", "4.17.21"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new client sharing the cache directory simulates a later run
+	second := NewWithCache(server.URL, NewCache(cache.dir, time.Hour))
+	license, err := second.License("lodash", "4.17.21")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if license != "MIT" {
+		t.Errorf("expected cached license MIT, got %q", license)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Errorf("expected 1 registry request, got %d", got)
+	}
+}
+
+func TestClient_CacheExpiry(t *testing.T) {
+	server, hits := newTestRegistry(t, "public, max-age=60")
+	cache := NewCache(t.TempDir(), time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	client := NewWithCache(server.URL, cache)
+	if _, err := client.License("lodash", "4.17.21"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// max-age=60 overrides the one hour default TTL
+	now = now.Add(2 * time.Minute)
+	if _, err := client.License("lodash", "4.17.21"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(hits); got != 2 {
+		t.Errorf("expected expired entry to trigger a second request, got %d requests", got)
+	}
+}
+
+func TestClient_NoStore(t *testing.T) {
+	// max-age=0 forbids reuse as no-store does, rather than falling back to
+	// the default TTL
+	for _, header := range []string{"no-store", "max-age=0", "public, max-age=0"} {
+		server, hits := newTestRegistry(t, header)
+		client := NewWithCache(server.URL, NewCache(t.TempDir(), time.Hour))
+
+		for i := 0; i < 2; i++ {
+			if _, err := client.License("lodash", "4.17.21"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if got := atomic.LoadInt32(hits); got != 2 {
+			t.Errorf("expected %q responses to bypass the cache, got %d requests", header, got)
+		}
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	tests := []struct {
+		header    string
+		maxAge    time.Duration
+		hasMaxAge bool
+		cacheable bool
+	}{
+		{"", 0, false, true},
+		{"public", 0, false, true},
+		{"public, max-age=300", 5 * time.Minute, true, true},
+		{"max-age=0", 0, true, false},
+		{"no-cache, max-age=300", 0, false, false},
+	}
+	for _, tt := range tests {
+		maxAge, hasMaxAge, cacheable := parseCacheControl(tt.header)
+		if maxAge != tt.maxAge || hasMaxAge != tt.hasMaxAge || cacheable != tt.cacheable {
+			t.Errorf("parseCacheControl(%q) = %v, %v, %v, expected %v, %v, %v",
+				tt.header, maxAge, hasMaxAge, cacheable, tt.maxAge, tt.hasMaxAge, tt.cacheable)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// DefaultRawURL serves GitHub repository files at any commit
+const DefaultRawURL = "https://raw.githubusercontent.com"
+
+// maxLicenseText bounds how much of a fetched LICENSE is read
+const maxLicenseText = 1 << 20
+
+// Repository is a GitHub repository pinned to a commit-ish
+type Repository struct {
+	Owner string
+	Name  string
+	Ref   string
+}
+
+// githubRepositoryPattern matches the owner/name of GitHub urls and the
+// "github:owner/name" and bare "owner/name" shorthands
+var githubRepositoryPattern = regexp.MustCompile(`^(?:(?:git\+)?(?:https?|ssh|git)://(?:[^@/]+@)?github\.com[/:]|git@github\.com:|github:)?([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// ParseRepository reads the package.json "repository" field (string or
+// {type, url} object) and returns it pinned to the commit-ish in its url
+// fragment, or to gitHead, which npm records when publishing. Repositories
+// without a pin are not returned, as their default branch may have moved on.
+func ParseRepository(field interface{}, gitHead string) (Repository, bool) {
+	var raw string
+	switch v := field.(type) {
+	case string:
+		raw = v
+	case map[string]interface{}:
+		if repoType, ok := v["type"].(string); ok && repoType != "git" {
+			return Repository{}, false
+		}
+		raw, _ = v["url"].(string)
+	}
+
+	raw, ref, _ := strings.Cut(strings.TrimSpace(raw), "#")
+	if ref == "" {
+		ref = gitHead
+	}
+	match := githubRepositoryPattern.FindStringSubmatch(raw)
+	if match == nil || ref == "" {
+		return Repository{}, false
+	}
+
+	return Repository{Owner: match[1], Name: match[2], Ref: ref}, true
+}
+
+// RawClient fetches repository files at a given commit
+type RawClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewRawClient creates a client for GitHub's raw content host
+func NewRawClient() *RawClient {
+	return NewRawClientWithURL(DefaultRawURL)
+}
+
+// NewRawClientWithURL creates a client for a custom raw content host
+func NewRawClientWithURL(baseURL string) *RawClient {
+	return &RawClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// LicenseText returns the first LICENSE file variant found in the
+// repository at its pinned ref
+func (c *RawClient) LicenseText(repo Repository) (string, error) {
+	for _, filename := range constants.LicenseFileVariants {
+		endpoint := strings.Join([]string{
+			c.baseURL, url.PathEscape(repo.Owner), url.PathEscape(repo.Name), url.PathEscape(repo.Ref), filename,
+		}, "/")
+		text, found, err := c.fetch(endpoint)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("no license file in %s/%s at %s", repo.Owner, repo.Name, repo.Ref)
+}
+
+// fetch returns the body of a file, or found=false if it does not exist
+func (c *RawClient) fetch(endpoint string) (string, bool, error) {
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return "", false, fmt.Errorf("repository request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() // Ignore close error as we already read the body
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", false, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", false, fmt.Errorf("repository host returned %s for %s", resp.Status, endpoint)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLicenseText))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read repository file: %w", err)
+	}
+	return string(data), true, nil
+}
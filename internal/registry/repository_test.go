@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    interface{}
+		gitHead  string
+		expected Repository
+		pinned   bool
+	}{
+		{"git+https url with sha", "git+https://github.com/lodash/lodash.git#abc123", "", Repository{"lodash", "lodash", "abc123"}, true},
+		{"object form with gitHead", map[string]interface{}{"type": "git", "url": "https://github.com/expressjs/express.git"}, "def456", Repository{"expressjs", "express", "def456"}, true},
+		{"ssh url", "git@github.com:user/repo.git#v1.2.3", "", Repository{"user", "repo", "v1.2.3"}, true},
+		{"github shorthand", "github:user/repo#main", "", Repository{"user", "repo", "main"}, true},
+		{"bare shorthand", "user/repo", "abc", Repository{"user", "repo", "abc"}, true},
+		{"unpinned", "https://github.com/user/repo", "", Repository{}, false},
+		{"other host", "https://gitlab.com/user/repo.git#abc", "", Repository{}, false},
+		{"non-git repository", map[string]interface{}{"type": "svn", "url": "https://github.com/user/repo"}, "abc", Repository{}, false},
+		{"missing", nil, "abc", Repository{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, pinned := ParseRepository(tt.field, tt.gitHead)
+			if repo != tt.expected || pinned != tt.pinned {
+				t.Errorf("expected %+v (pinned=%v), got %+v (pinned=%v)", tt.expected, tt.pinned, repo, pinned)
+			}
+		})
+	}
+}
+
+func TestRawClient_LicenseText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/repo/abc123/LICENSE.md":
+			fmt.Fprint(w, "MIT License\n\nPermission is hereby granted, free of charge")
+		case "/user/repo/main/LICENSE":
+			fmt.Fprint(w, "GNU GENERAL PUBLIC LICENSE\nVersion 3")
+		case "/user/broken/abc123/LICENSE":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewRawClientWithURL(server.URL)
+
+	// The pinned commit is read, not the current default branch
+	text, err := client.LicenseText(Repository{Owner: "user", Name: "repo", Ref: "abc123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(text, "MIT License") {
+		t.Errorf("expected the license at abc123, got %q", text)
+	}
+
+	if _, err := client.LicenseText(Repository{Owner: "user", Name: "repo", Ref: "missing"}); err == nil {
+		t.Error("expected an error when no license file exists at the ref")
+	}
+	if _, err := client.LicenseText(Repository{Owner: "user", Name: "broken", Ref: "abc123"}); err == nil {
+		t.Error("expected an error for a failing host")
+	}
+}
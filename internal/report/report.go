@@ -0,0 +1,167 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+// Grouping keys supported by GroupBy
+const (
+	GroupByLicense  = "license"
+	GroupByCategory = "category"
+	GroupByManager  = "manager"
+	GroupByProject  = "project"
+)
+
+// Dependency is a flat report entry that can be grouped
+type Dependency struct {
+	Name       string  `json:"name"`
+	Version    string  `json:"version"`
+	License    string  `json:"license"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	// Category is the license category as the caller's analyzer classifies
+	// it, which GroupByCategory groups by
+	Category analyzer.LicenseCategory `json:"-"`
+	Manager  string                   `json:"-"`
+	Project  string                   `json:"-"`
+}
+
+// Group contains the dependencies sharing the same grouping key
+type Group struct {
+	Key          string       `json:"key"`
+	Count        int          `json:"count"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// GroupBy nests dependencies under the given grouping key.
+// Groups are ordered by descending count, then by key.
+func GroupBy(dependencies []Dependency, field string) ([]Group, error) {
+	keyFunc, err := groupKeyFunc(field)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var groups []Group
+	for _, dep := range dependencies {
+		key := keyFunc(dep)
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Key: key})
+		}
+		groups[i].Dependencies = append(groups[i].Dependencies, dep)
+		groups[i].Count++
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	return groups, nil
+}
+
+func groupKeyFunc(field string) (func(Dependency) string, error) {
+	switch strings.ToLower(field) {
+	case GroupByLicense:
+		return func(d Dependency) string { return d.License }, nil
+	case GroupByCategory:
+		return func(d Dependency) string { return d.Category.String() }, nil
+	case GroupByManager:
+		return func(d Dependency) string { return d.Manager }, nil
+	case GroupByProject:
+		return func(d Dependency) string { return d.Project }, nil
+	default:
+		return nil, fmt.Errorf("unsupported group-by key: %s", field)
+	}
+}
+
+// Remediation actions, in the order GroupByAction lists them
+const (
+	ActionRemove = "Remove or replace"
+	ActionReview = "Review"
+	ActionOK     = "OK"
+)
+
+// lowConfidence is the detection confidence below which a license needs review
+const lowConfidence = 0.5
+
+// ActionFor derives what to do about a dependency. Denied, strong copyleft and
+// proprietary licenses have to go, unknown, weak copyleft and uncertain
+// detections need a look, and pre-approved packages are fine regardless.
+func ActionFor(category analyzer.LicenseCategory, confidence float64, denied, approved bool) string {
+	switch {
+	case approved:
+		return ActionOK
+	case denied || category == analyzer.StrongCopyleft || category == analyzer.Proprietary:
+		return ActionRemove
+	case category == analyzer.Unknown || category == analyzer.WeakCopyleft || confidence < lowConfidence:
+		return ActionReview
+	default:
+		return ActionOK
+	}
+}
+
+// GroupByAction nests dependencies under their remediation action. All three
+// groups are returned, most urgent first, so consumers can rely on the layout.
+func GroupByAction(dependencies []Dependency, actions []string) []Group {
+	return groupInOrder(dependencies, actions, []string{ActionRemove, ActionReview, ActionOK})
+}
+
+// Risk sections of the console-grouped format, most severe first
+const (
+	SectionHighRisk   = "High risk"
+	SectionUnknown    = "Unknown license"
+	SectionMediumRisk = "Medium risk"
+	SectionLowRisk    = "Low risk"
+)
+
+// SectionFor places a dependency in a risk section from the risk level of its
+// license category. Denied and proprietary licenses are high risk, while
+// pre-approved packages are low risk regardless.
+func SectionFor(category analyzer.LicenseCategory, riskLevel string, denied, approved bool) string {
+	switch {
+	case approved:
+		return SectionLowRisk
+	case denied || category == analyzer.Proprietary || riskLevel == "high":
+		return SectionHighRisk
+	case category == analyzer.Unknown:
+		return SectionUnknown
+	case riskLevel == "medium":
+		return SectionMediumRisk
+	default:
+		return SectionLowRisk
+	}
+}
+
+// GroupBySection nests dependencies under their risk section. All sections
+// are returned, most severe first.
+func GroupBySection(dependencies []Dependency, sections []string) []Group {
+	return groupInOrder(dependencies, sections, []string{SectionHighRisk, SectionUnknown, SectionMediumRisk, SectionLowRisk})
+}
+
+// groupInOrder nests each dependency under its key, returning one group per
+// key in the given order, empty ones included
+func groupInOrder(dependencies []Dependency, keys, order []string) []Group {
+	groups := make([]Group, len(order))
+	for g, key := range order {
+		groups[g] = Group{Key: key, Dependencies: []Dependency{}}
+	}
+	for i, dep := range dependencies {
+		for g := range groups {
+			if groups[g].Key == keys[i] {
+				groups[g].Dependencies = append(groups[g].Dependencies, dep)
+				groups[g].Count++
+			}
+		}
+	}
+	return groups
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+func TestGroupBy_License(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "left-pad", Version: "1.3.0", License: "WTFPL"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT"},
+	}
+
+	groups, err := GroupBy(deps, GroupByLicense)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+
+	if groups[0].Key != "MIT" || groups[0].Count != 2 {
+		t.Errorf("Expected first group MIT with 2 dependencies, got %s with %d", groups[0].Key, groups[0].Count)
+	}
+
+	if groups[0].Dependencies[0].Name != "react" || groups[0].Dependencies[1].Name != "lodash" {
+		t.Errorf("Expected dependencies to keep their original order, got %v", groups[0].Dependencies)
+	}
+
+	if groups[1].Key != "WTFPL" || groups[1].Count != 1 {
+		t.Errorf("Expected second group WTFPL with 1 dependency, got %s with %d", groups[1].Key, groups[1].Count)
+	}
+}
+
+func TestGroupBy_Category(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Category: analyzer.Permissive},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Category: analyzer.StrongCopyleft},
+		{Name: "apache-package", Version: "1.0.0", License: "Apache 2.0", Category: analyzer.Permissive},
+		{Name: "mystery", Version: "0.0.1", License: "Unknown", Category: analyzer.Unknown},
+	}
+
+	groups, err := GroupBy(deps, GroupByCategory)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, group := range groups {
+		counts[group.Key] = group.Count
+	}
+
+	expected := map[string]int{
+		"permissive":      2,
+		"strong-copyleft": 1,
+		"unknown":         1,
+	}
+	for key, count := range expected {
+		if counts[key] != count {
+			t.Errorf("Expected %d dependencies in %s, got %d", count, key, counts[key])
+		}
+	}
+
+	if groups[0].Key != "permissive" {
+		t.Errorf("Expected largest group first, got %s", groups[0].Key)
+	}
+}
+
+func TestGroupBy_UnsupportedKey(t *testing.T) {
+	if _, err := GroupBy(nil, "author"); err == nil {
+		t.Error("Expected error for unsupported group-by key")
+	}
+}
+
+func TestGroupByAction(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "mystery", Version: "0.0.1", License: "Unknown", Confidence: 0.0},
+		{Name: "guessed", Version: "1.0.0", License: "MIT", Confidence: 0.3},
+		{Name: "reviewed-gpl", Version: "2.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+	actions := make([]string, len(deps))
+	for i, dep := range deps {
+		actions[i] = ActionFor(analyzer.Categorize(dep.License), dep.Confidence, false, dep.Name == "reviewed-gpl")
+	}
+
+	groups := GroupByAction(deps, actions)
+
+	expected := map[string][]string{
+		ActionRemove: {"gpl-package"},
+		ActionReview: {"mystery", "guessed"},
+		ActionOK:     {"react", "reviewed-gpl"},
+	}
+	if len(groups) != 3 || groups[0].Key != ActionRemove || groups[2].Key != ActionOK {
+		t.Fatalf("Expected the three action groups most urgent first, got %+v", groups)
+	}
+	for _, group := range groups {
+		var names []string
+		for _, dep := range group.Dependencies {
+			names = append(names, dep.Name)
+		}
+		if len(names) != len(expected[group.Key]) || group.Count != len(names) {
+			t.Errorf("Expected %v under %s, got %v", expected[group.Key], group.Key, names)
+			continue
+		}
+		for i, name := range expected[group.Key] {
+			if names[i] != name {
+				t.Errorf("Expected %v under %s, got %v", expected[group.Key], group.Key, names)
+			}
+		}
+	}
+
+	// Denied categories have to go even when permissive
+	if action := ActionFor(analyzer.Permissive, 1.0, true, false); action != ActionRemove {
+		t.Errorf("Expected a denied license to be removed, got %s", action)
+	}
+}
+
+func TestGroupBySection(t *testing.T) {
+	deps := []Dependency{
+		{Name: "react", Version: "18.2.0", License: "MIT", Confidence: 1.0},
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-3.0", Confidence: 1.0},
+		{Name: "mystery", Version: "0.0.1", License: "Unknown", Confidence: 0.0},
+		{Name: "lgpl-package", Version: "1.0.0", License: "LGPL-3.0", Confidence: 1.0},
+		{Name: "reviewed-gpl", Version: "2.0.0", License: "GPL-3.0", Confidence: 1.0},
+	}
+	sections := make([]string, len(deps))
+	for i, dep := range deps {
+		category := analyzer.Categorize(dep.License)
+		sections[i] = SectionFor(category, analyzer.DefaultSeverities[category], false, dep.Name == "reviewed-gpl")
+	}
+
+	groups := GroupBySection(deps, sections)
+
+	expected := map[string][]string{
+		SectionHighRisk:   {"gpl-package"},
+		SectionUnknown:    {"mystery"},
+		SectionMediumRisk: {"lgpl-package"},
+		SectionLowRisk:    {"react", "reviewed-gpl"},
+	}
+	if len(groups) != 4 || groups[0].Key != SectionHighRisk || groups[3].Key != SectionLowRisk {
+		t.Fatalf("expected the sections most severe first, got %+v", groups)
+	}
+	for _, group := range groups {
+		var names []string
+		for _, dep := range group.Dependencies {
+			names = append(names, dep.Name)
+		}
+		if !reflect.DeepEqual(names, expected[group.Key]) || group.Count != len(names) {
+			t.Errorf("section %s: expected %v, got %v (count %d)", group.Key, expected[group.Key], names, group.Count)
+		}
+	}
+}
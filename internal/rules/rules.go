@@ -0,0 +1,228 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+// severityRank orders rule severities from least to most severe
+var severityRank = map[string]int{"low": 0, "medium": 1, "high": 2}
+
+// Policy is an ordered list of rules loaded from a policy file, e.g.
+//
+//	rules:
+//	  - name: no-gpl-in-products
+//	    when: {category: strong-copyleft, private: false}
+//	    then: {severity: high, message: "Strong copyleft in a distributed product"}
+//	  - when: {licenses: [GPL-2.0, Apache-2.0]}
+//	    then: {severity: high, message: "GPL-2.0 and Apache-2.0 licenses are incompatible"}
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule raises a finding when its condition holds
+type Rule struct {
+	Name string    `yaml:"name"`
+	When Condition `yaml:"when"`
+	Then Action    `yaml:"then"`
+}
+
+// Condition selects dependencies by license, category and package name
+// glob; every field that is set must match. Licenses is an aggregate
+// condition that holds when all listed licenses occur in the project, and
+// Private restricts the rule to private or distributed projects. License
+// and Licenses ignore case.
+type Condition struct {
+	License  string   `yaml:"license"`
+	Category string   `yaml:"category"`
+	Package  string   `yaml:"package"`
+	Licenses []string `yaml:"licenses"`
+	Private  *bool    `yaml:"private"`
+
+	category analyzer.LicenseCategory
+}
+
+// Action is the finding a matching rule produces
+type Action struct {
+	Severity string `yaml:"severity"`
+	Message  string `yaml:"message"`
+}
+
+// Dependency is a package with its normalized license and category
+type Dependency struct {
+	Name     string
+	Version  string
+	License  string
+	Category analyzer.LicenseCategory
+}
+
+// Finding is a rule that matched, with the packages that triggered it
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	Packages []string `json:"packages,omitempty"`
+}
+
+// Load reads and validates a JSON or YAML policy file
+func Load(policyPath string) (*Policy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	for i := range policy.Rules {
+		if err := policy.Rules[i].validate(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return &policy, nil
+}
+
+// validate checks a rule and resolves its category; unnamed rules are
+// named after their position in the policy
+func (r *Rule) validate(index int) error {
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("rule-%d", index+1)
+	}
+
+	when := &r.When
+	if when.License == "" && when.Category == "" && when.Package == "" && len(when.Licenses) == 0 && when.Private == nil {
+		return fmt.Errorf("rule %s has no condition", r.Name)
+	}
+	if when.Category != "" {
+		category, err := analyzer.ParseCategory(when.Category)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		when.category = category
+	}
+	if when.Package != "" {
+		if _, err := path.Match(when.Package, ""); err != nil {
+			return fmt.Errorf("rule %s: invalid package pattern %q: %w", r.Name, when.Package, err)
+		}
+	}
+
+	r.Then.Severity = strings.ToLower(strings.TrimSpace(r.Then.Severity))
+	if _, valid := severityRank[r.Then.Severity]; !valid {
+		return fmt.Errorf("rule %s: invalid severity %q (expected low, medium or high)", r.Name, r.Then.Severity)
+	}
+	if r.Then.Message == "" {
+		return fmt.Errorf("rule %s has no message", r.Name)
+	}
+
+	return nil
+}
+
+// Evaluate runs every rule against the dependencies of a project and returns
+// the findings in policy order
+func (p *Policy) Evaluate(dependencies []Dependency, private bool) []Finding {
+	findings := []Finding{}
+	for _, rule := range p.Rules {
+		if packages, matched := rule.When.match(dependencies, private); matched {
+			findings = append(findings, Finding{
+				Rule:     rule.Name,
+				Severity: rule.Then.Severity,
+				Message:  rule.Then.Message,
+				Packages: packages,
+			})
+		}
+	}
+	return findings
+}
+
+// match reports whether the condition holds and lists the packages involved
+func (c *Condition) match(dependencies []Dependency, private bool) ([]string, bool) {
+	if c.Private != nil && *c.Private != private {
+		return nil, false
+	}
+
+	var packages []string
+	selective := c.License != "" || c.Category != "" || c.Package != ""
+	if selective {
+		for _, dep := range dependencies {
+			if c.matchDependency(dep) {
+				packages = append(packages, dep.Name+"@"+dep.Version)
+			}
+		}
+		if len(packages) == 0 {
+			return nil, false
+		}
+	}
+
+	if len(c.Licenses) > 0 {
+		holders := make(map[string][]string)
+		for _, dep := range dependencies {
+			key := strings.ToLower(dep.License)
+			holders[key] = append(holders[key], dep.Name+"@"+dep.Version)
+		}
+		for _, license := range c.Licenses {
+			key := strings.ToLower(license)
+			if len(holders[key]) == 0 {
+				return nil, false
+			}
+			if !selective {
+				packages = append(packages, holders[key]...)
+			}
+		}
+	}
+
+	sort.Strings(packages)
+	return packages, true
+}
+
+func (c *Condition) matchDependency(dep Dependency) bool {
+	if c.License != "" && !strings.EqualFold(c.License, dep.License) {
+		return false
+	}
+	if c.Category != "" && c.category != dep.Category {
+		return false
+	}
+	if c.Package != "" {
+		if matched, _ := path.Match(c.Package, dep.Name); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// RaiseRiskLevel returns the higher of a risk level and the most severe finding
+func RaiseRiskLevel(riskLevel string, findings []Finding) string {
+	for _, finding := range findings {
+		if severityRank[finding.Severity] > severityRank[riskLevel] {
+			riskLevel = finding.Severity
+		}
+	}
+	return riskLevel
+}
+
+// Recommendation formats a finding like the analyzer's recommendations
+func (f Finding) Recommendation() string {
+	prefix := "ℹ️  "
+	switch f.Severity {
+	case "high":
+		prefix = "⛔ "
+	case "medium":
+		prefix = "⚠️  "
+	}
+	if len(f.Packages) == 0 {
+		return prefix + f.Message
+	}
+	return fmt.Sprintf("%s%s: %s", prefix, f.Message, strings.Join(f.Packages, ", "))
+}
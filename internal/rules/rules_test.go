@@ -0,0 +1,97 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	return path
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := Load(writePolicy(t, `
+rules:
+  - name: no-copyleft-when-distributed
+    when: {category: strong-copyleft, private: false}
+    then: {severity: high, message: "Strong copyleft in a distributed product"}
+  - when: {licenses: [gpl-2.0, Apache-2.0]}
+    then: {severity: high, message: "GPL-2.0 and Apache-2.0 licenses are incompatible"}
+  - name: internal-scope
+    when: {package: "@internal/*", license: mit}
+    then: {severity: low, message: "Internal packages should be UNLICENSED"}
+  - name: no-agpl
+    when: {license: AGPL-3.0}
+    then: {severity: high, message: "AGPL is banned"}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deps := []Dependency{
+		{Name: "gpl-package", Version: "1.0.0", License: "GPL-2.0", Category: analyzer.StrongCopyleft},
+		{Name: "apache-package", Version: "2.0.0", License: "Apache-2.0", Category: analyzer.Permissive},
+		{Name: "@internal/utils", Version: "0.1.0", License: "MIT", Category: analyzer.Permissive},
+		{Name: "lodash", Version: "4.17.21", License: "MIT", Category: analyzer.Permissive},
+	}
+
+	expected := []Finding{
+		{Rule: "no-copyleft-when-distributed", Severity: "high", Message: "Strong copyleft in a distributed product", Packages: []string{"gpl-package@1.0.0"}},
+		{Rule: "rule-2", Severity: "high", Message: "GPL-2.0 and Apache-2.0 licenses are incompatible", Packages: []string{"apache-package@2.0.0", "gpl-package@1.0.0"}},
+		{Rule: "internal-scope", Severity: "low", Message: "Internal packages should be UNLICENSED", Packages: []string{"@internal/utils@0.1.0"}},
+	}
+	if findings := policy.Evaluate(deps, false); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("expected %+v, got %+v", expected, findings)
+	}
+
+	// The distribution rule does not apply to private projects
+	findings := policy.Evaluate(deps, true)
+	if len(findings) != 2 || findings[0].Rule != "rule-2" {
+		t.Errorf("expected the distribution rule to be skipped for a private project, got %+v", findings)
+	}
+
+	if level := RaiseRiskLevel("low", findings); level != "high" {
+		t.Errorf("expected findings to raise the risk level to high, got %s", level)
+	}
+	if level := RaiseRiskLevel("medium", nil); level != "medium" {
+		t.Errorf("expected the risk level to be kept without findings, got %s", level)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no condition":     "rules:\n  - then: {severity: high, message: x}\n",
+		"unknown category": "rules:\n  - when: {category: viral}\n    then: {severity: high, message: x}\n",
+		"invalid severity": "rules:\n  - when: {license: MIT}\n    then: {severity: critical, message: x}\n",
+		"no message":       "rules:\n  - when: {license: MIT}\n    then: {severity: low}\n",
+		"bad pattern":      "rules:\n  - when: {package: \"[\"}\n    then: {severity: low, message: x}\n",
+		"unknown field":    "rules:\n  - when: {licence: MIT}\n    then: {severity: low, message: x}\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writePolicy(t, content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing policy file")
+	}
+}
+
+func TestFinding_Recommendation(t *testing.T) {
+	finding := Finding{Severity: "high", Message: "AGPL is banned", Packages: []string{"a@1.0.0", "b@2.0.0"}}
+	if got := finding.Recommendation(); got != "⛔ AGPL is banned: a@1.0.0, b@2.0.0" {
+		t.Errorf("unexpected recommendation: %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+	"github.com/StefanoA1/license-scanner/internal/constants"
+	"github.com/StefanoA1/license-scanner/internal/scanner"
+)
+
+// CycloneDXSpecVersion is the CycloneDX version written by EncodeCycloneDX
+const CycloneDXSpecVersion = "1.5"
+
+type cdxDocument struct {
+	BOMFormat   string         `json:"bomFormat"`
+	SpecVersion string         `json:"specVersion"`
+	Version     int            `json:"version"`
+	Metadata    cdxMetadata    `json:"metadata"`
+	Components  []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cdxTool `json:"components"`
+	} `json:"tools"`
+}
+
+type cdxTool struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type cdxComponent struct {
+	Type     string       `json:"type"`
+	Group    string       `json:"group,omitempty"`
+	Name     string       `json:"name"`
+	Version  string       `json:"version"`
+	PURL     string       `json:"purl,omitempty"`
+	Licenses []cdxLicense `json:"licenses,omitempty"`
+}
+
+type cdxLicense struct {
+	License struct {
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	} `json:"license"`
+}
+
+// EncodeCycloneDX converts scanned components into a CycloneDX JSON document.
+// Known licenses are written as SPDX ids, others by name; unknown licenses
+// are left out rather than asserted.
+func EncodeCycloneDX(components []Component, timestamp time.Time) ([]byte, error) {
+	document := cdxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: CycloneDXSpecVersion,
+		Version:     1,
+		Components:  make([]cdxComponent, 0, len(components)),
+	}
+	document.Metadata.Timestamp = timestamp.UTC().Format(time.RFC3339)
+	document.Metadata.Tools.Components = []cdxTool{{Type: "application", Name: "license-scanner"}}
+
+	for _, c := range components {
+		component := cdxComponent{Type: "library", Name: c.Name, Version: c.Version, PURL: PackageURL(c)}
+		// npm scopes are recorded as the component group, as Load expects
+		if scope, name, scoped := strings.Cut(c.Name, "/"); scoped && strings.HasPrefix(scope, "@") {
+			component.Group, component.Name = scope, name
+		}
+
+		if c.License != "" && c.License != constants.UnknownLicense {
+			var license cdxLicense
+			if _, known := analyzer.KnownLicenses[c.License]; known {
+				license.License.ID = c.License
+			} else {
+				license.License.Name = c.License
+			}
+			component.Licenses = []cdxLicense{license}
+		}
+		document.Components = append(document.Components, component)
+	}
+
+	output, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CycloneDX document: %w", err)
+	}
+	return output, nil
+}
+
+// PackageURL returns the purl of a component, e.g. pkg:npm/lodash@4.17.21.
+// Package managers without a purl type are written as generic packages.
+func PackageURL(c Component) string {
+	purlType := "generic"
+	switch c.Manager {
+	case "", constants.PackageManagerNPM, constants.PackageManagerYarn, constants.PackageManagerPnpm:
+		purlType = "npm"
+	case scanner.PackageManagerPip, constants.PackageManagerPoetry, constants.PackageManagerPipenv:
+		purlType = "pypi"
+	case constants.PackageManagerCargo:
+		purlType = "cargo"
+	case constants.PackageManagerGo:
+		purlType = "golang"
+	}
+
+	// Namespace segments such as an npm scope or a Go module path stay
+	// separated by slashes; "@" separates the version, so it is escaped
+	segments := strings.Split(c.Name, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "@", "%40")
+	}
+	name := strings.Join(segments, "/")
+
+	purl := "pkg:" + purlType + "/" + name
+	if c.Version != "" {
+		purl += "@" + url.PathEscape(c.Version)
+	}
+	return purl
+}
@@ -0,0 +1,70 @@
+package sbom
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestEncodeCycloneDX(t *testing.T) {
+	components := []Component{
+		{Name: "lodash", Version: "4.17.21", License: "MIT", Manager: "npm"},
+		{Name: "@babel/core", Version: "7.22.0", License: "MIT", Manager: "yarn"},
+		{Name: "left-pad", Version: "1.3.0", License: "Custom-1.0", Manager: "pnpm"},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Manager: "npm"},
+		{Name: "requests", Version: "2.31.0", License: "Apache-2.0", Manager: "pip"},
+	}
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	output, err := EncodeCycloneDX(components, timestamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertGolden(t, "cyclonedx.golden.json", output)
+}
+
+func TestEncodeCycloneDX_RoundTrip(t *testing.T) {
+	components := []Component{
+		{Name: "@babel/core", Version: "7.22.0", License: "MIT"},
+		{Name: "left-pad", Version: "1.3.0", License: "WTFPL"},
+	}
+
+	output, err := EncodeCycloneDX(components, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := Load(writeSBOM(t, string(output)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if drift := Compare(components, loaded); !drift.Empty() {
+		t.Errorf("expected the exported SBOM to match the scan, got %+v", drift)
+	}
+}
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		component Component
+		expected  string
+	}{
+		{Component{Name: "lodash", Version: "4.17.21", Manager: "npm"}, "pkg:npm/lodash@4.17.21"},
+		{Component{Name: "@angular/core", Version: "17.0.0"}, "pkg:npm/%40angular/core@17.0.0"},
+		{Component{Name: "requests", Version: "2.31.0", Manager: "pip"}, "pkg:pypi/requests@2.31.0"},
+		{Component{Name: "pyyaml", Version: "6.0.1", Manager: "poetry"}, "pkg:pypi/pyyaml@6.0.1"},
+		{Component{Name: "jquery", Version: "3.7.1", Manager: "bower"}, "pkg:generic/jquery@3.7.1"},
+		{Component{Name: "serde", Version: "1.0.188", Manager: "cargo"}, "pkg:cargo/serde@1.0.188"},
+		{Component{Name: "golang.org/x/text", Version: "v0.14.0", Manager: "go"}, "pkg:golang/golang.org/x/text@v0.14.0"},
+		{Component{Name: "github.com/BurntSushi/toml", Version: "v1.3.2", Manager: "go"}, "pkg:golang/github.com/BurntSushi/toml@v1.3.2"},
+		{Component{Name: "no-version", Manager: "npm"}, "pkg:npm/no-version"},
+	}
+
+	for _, tt := range tests {
+		if got := PackageURL(tt.component); got != tt.expected {
+			t.Errorf("PackageURL(%+v) = %q, want %q", tt.component, got, tt.expected)
+		}
+	}
+}
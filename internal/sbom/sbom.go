@@ -0,0 +1,261 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Component is a package listed in an SBOM
+type Component struct {
+	Name    string
+	Version string
+	// License is empty when the SBOM makes no assertion
+	License string
+	// Manager is the package manager that installed the component, used for
+	// its package URL when exporting
+	Manager string
+}
+
+// Drift lists the differences between a scan and an external SBOM
+type Drift struct {
+	// Missing are scanned packages the SBOM does not list
+	Missing []string `json:"missing"`
+	// Extra are SBOM components the scan did not find
+	Extra []string `json:"extra"`
+	// LicenseMismatches are packages both list with different licenses
+	LicenseMismatches []Mismatch `json:"licenseMismatches"`
+}
+
+// Mismatch is a package whose license differs between the scan and the SBOM
+type Mismatch struct {
+	Package string `json:"package"`
+	Scanned string `json:"scanned"`
+	SBOM    string `json:"sbom"`
+}
+
+// Empty reports whether the scan and the SBOM agree
+func (d *Drift) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.LicenseMismatches) == 0
+}
+
+// Load reads the components of a CycloneDX or SPDX JSON document
+func Load(path string) ([]Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+
+	var header struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM: %w", err)
+	}
+
+	switch {
+	case header.BOMFormat == "CycloneDX":
+		return parseCycloneDX(data)
+	case header.SPDXVersion != "":
+		return parseSPDX(data)
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format in %s (expected CycloneDX or SPDX JSON)", path)
+	}
+}
+
+// Licenses maps name@version to the license of every component the SBOM
+// asserts one for
+func Licenses(components []Component) map[string]string {
+	licenses := make(map[string]string, len(components))
+	for _, c := range components {
+		if c.License != "" {
+			licenses[c.Name+"@"+c.Version] = c.License
+		}
+	}
+	return licenses
+}
+
+// parseCycloneDX reads components, including nested ones. The license list is
+// joined into an OR expression. A component's purl names the package as its
+// package manager does, so it wins over the name and version fields.
+func parseCycloneDX(data []byte) ([]Component, error) {
+	type cdxComponent struct {
+		Group    string `json:"group"`
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		PURL     string `json:"purl"`
+		Licenses []struct {
+			License struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"license"`
+			Expression string `json:"expression"`
+		} `json:"licenses"`
+		Components []json.RawMessage `json:"components"`
+	}
+	var document struct {
+		Components []json.RawMessage `json:"components"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse CycloneDX document: %w", err)
+	}
+
+	var components []Component
+	var collect func(raw []json.RawMessage) error
+	collect = func(raw []json.RawMessage) error {
+		for _, message := range raw {
+			var c cdxComponent
+			if err := json.Unmarshal(message, &c); err != nil {
+				return fmt.Errorf("failed to parse CycloneDX component: %w", err)
+			}
+
+			var licenses []string
+			for _, l := range c.Licenses {
+				switch {
+				case l.Expression != "":
+					licenses = append(licenses, l.Expression)
+				case l.License.ID != "":
+					licenses = append(licenses, l.License.ID)
+				case l.License.Name != "":
+					licenses = append(licenses, l.License.Name)
+				}
+			}
+
+			// npm scopes are recorded as the component group
+			name, version := c.Name, c.Version
+			if c.Group != "" {
+				name = c.Group + "/" + c.Name
+			}
+			if purlName, purlVersion, ok := ParsePackageURL(c.PURL); ok {
+				name, version = purlName, purlVersion
+			}
+			components = append(components, Component{Name: name, Version: version, License: strings.Join(licenses, " OR ")})
+
+			if err := collect(c.Components); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := collect(document.Components); err != nil {
+		return nil, err
+	}
+
+	return components, nil
+}
+
+// parseSPDX reads packages, skipping the ones the document describes (the
+// project itself). The concluded license is preferred over the declared one.
+func parseSPDX(data []byte) ([]Component, error) {
+	var document struct {
+		DocumentDescribes []string `json:"documentDescribes"`
+		Packages          []struct {
+			SPDXID           string `json:"SPDXID"`
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse SPDX document: %w", err)
+	}
+
+	described := make(map[string]bool, len(document.DocumentDescribes))
+	for _, id := range document.DocumentDescribes {
+		described[id] = true
+	}
+
+	var components []Component
+	for _, p := range document.Packages {
+		if described[p.SPDXID] {
+			continue
+		}
+		license := spdxAssertion(p.LicenseConcluded)
+		if license == "" {
+			license = spdxAssertion(p.LicenseDeclared)
+		}
+		components = append(components, Component{Name: p.Name, Version: p.VersionInfo, License: license})
+	}
+
+	return components, nil
+}
+
+// ParsePackageURL returns the name and version of a purl such as
+// pkg:npm/%40scope/name@1.0.0, ignoring qualifiers and subpaths
+func ParsePackageURL(purl string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return "", "", false
+	}
+	if end := strings.IndexAny(rest, "?#"); end >= 0 {
+		rest = rest[:end]
+	}
+	_, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return "", "", false
+	}
+
+	var version string
+	if at := strings.LastIndex(path, "@"); at > 0 {
+		path, version = path[:at], path[at+1:]
+	}
+	name, err := url.PathUnescape(path)
+	if err != nil || name == "" {
+		return "", "", false
+	}
+	if version, err = url.PathUnescape(version); err != nil {
+		return "", "", false
+	}
+	return name, version, true
+}
+
+// spdxAssertion returns a license field, or "" for NOASSERTION and NONE
+func spdxAssertion(license string) string {
+	switch license {
+	case "NOASSERTION", "NONE":
+		return ""
+	}
+	return license
+}
+
+// Compare diffs scanned packages against SBOM components by name@version.
+// Licenses are compared case-insensitively and only where the SBOM asserts one.
+func Compare(scanned, components []Component) *Drift {
+	drift := &Drift{Missing: []string{}, Extra: []string{}, LicenseMismatches: []Mismatch{}}
+
+	listed := make(map[string]Component, len(components))
+	for _, c := range components {
+		listed[c.Name+"@"+c.Version] = c
+	}
+
+	found := make(map[string]bool, len(scanned))
+	for _, dep := range scanned {
+		key := dep.Name + "@" + dep.Version
+		found[key] = true
+		c, ok := listed[key]
+		if !ok {
+			drift.Missing = append(drift.Missing, key)
+			continue
+		}
+		if c.License != "" && !strings.EqualFold(strings.TrimSpace(c.License), strings.TrimSpace(dep.License)) {
+			drift.LicenseMismatches = append(drift.LicenseMismatches, Mismatch{Package: key, Scanned: dep.License, SBOM: c.License})
+		}
+	}
+	for key := range listed {
+		if !found[key] {
+			drift.Extra = append(drift.Extra, key)
+		}
+	}
+
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Extra)
+	sort.Slice(drift.LicenseMismatches, func(i, j int) bool {
+		return drift.LicenseMismatches[i].Package < drift.LicenseMismatches[j].Package
+	})
+	return drift
+}
@@ -0,0 +1,149 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeSBOM(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sbom.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write SBOM: %v", err)
+	}
+	return path
+}
+
+func TestCompare_CycloneDX(t *testing.T) {
+	path := writeSBOM(t, `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"metadata": {"component": {"name": "my-app", "version": "1.0.0"}},
+		"components": [
+			{"name": "react", "version": "18.2.0", "licenses": [{"license": {"id": "MIT"}}]},
+			{"group": "@babel", "name": "core", "version": "7.22.0", "licenses": [{"expression": "MIT"}],
+			 "components": [{"name": "left-pad", "version": "1.3.0", "licenses": [{"license": {"name": "WTFPL"}}]}]},
+			{"name": "lodash", "version": "4.17.21", "licenses": [{"license": {"id": "ISC"}}]}
+		]
+	}`)
+
+	components, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(components) != 4 || components[1].Name != "@babel/core" {
+		t.Fatalf("expected 4 components including the nested one, got %+v", components)
+	}
+
+	scanned := []Component{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "@babel/core", Version: "7.22.0", License: "MIT"},
+		{Name: "lodash", Version: "4.17.21", License: "MIT"},
+		{Name: "express", Version: "4.18.2", License: "MIT"},
+	}
+	drift := Compare(scanned, components)
+
+	expected := &Drift{
+		Missing:           []string{"express@4.18.2"},
+		Extra:             []string{"left-pad@1.3.0"},
+		LicenseMismatches: []Mismatch{{Package: "lodash@4.17.21", Scanned: "MIT", SBOM: "ISC"}},
+	}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("expected %+v, got %+v", expected, drift)
+	}
+	if drift.Empty() {
+		t.Error("expected drift to be reported")
+	}
+}
+
+func TestLoad_SPDX(t *testing.T) {
+	path := writeSBOM(t, `{
+		"spdxVersion": "SPDX-2.3",
+		"documentDescribes": ["SPDXRef-root"],
+		"packages": [
+			{"SPDXID": "SPDXRef-root", "name": "my-app", "versionInfo": "1.0.0"},
+			{"SPDXID": "SPDXRef-1", "name": "react", "versionInfo": "18.2.0", "licenseConcluded": "MIT"},
+			{"SPDXID": "SPDXRef-2", "name": "qs", "versionInfo": "6.11.0", "licenseConcluded": "NOASSERTION", "licenseDeclared": "BSD-3-Clause"},
+			{"SPDXID": "SPDXRef-3", "name": "mystery", "versionInfo": "0.1.0", "licenseConcluded": "NOASSERTION"}
+		]
+	}`)
+
+	components, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Component{
+		{Name: "react", Version: "18.2.0", License: "MIT"},
+		{Name: "qs", Version: "6.11.0", License: "BSD-3-Clause"},
+		{Name: "mystery", Version: "0.1.0"},
+	}
+	if !reflect.DeepEqual(components, expected) {
+		t.Errorf("expected %+v, got %+v", expected, components)
+	}
+
+	// Components without a license assertion are not mismatches
+	drift := Compare([]Component{{Name: "mystery", Version: "0.1.0", License: "Unknown"}}, components[2:])
+	if !drift.Empty() {
+		t.Errorf("expected no drift, got %+v", drift)
+	}
+}
+
+func TestLoad_Unsupported(t *testing.T) {
+	if _, err := Load(writeSBOM(t, `{"packages": []}`)); err == nil {
+		t.Error("expected an error for an unknown document format")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing SBOM")
+	}
+}
+
+func TestLicenses_CycloneDXPackageURLs(t *testing.T) {
+	path := writeSBOM(t, `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"components": [
+			{"name": "util", "version": "v1", "purl": "pkg:npm/%40scope/util@1.2.0", "licenses": [{"license": {"id": "BSD-3-Clause"}}]},
+			{"name": "react", "version": "18.2.0", "purl": "pkg:npm/react@18.2.0?repository_url=https://registry.npmjs.org", "licenses": [{"license": {"id": "MIT"}}]},
+			{"name": "mystery", "version": "0.1.0"}
+		]
+	}`)
+
+	components, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"@scope/util@1.2.0": "BSD-3-Clause", "react@18.2.0": "MIT"}
+	if licenses := Licenses(components); !reflect.DeepEqual(licenses, expected) {
+		t.Errorf("expected %v, got %v", expected, licenses)
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		purl    string
+		name    string
+		version string
+		ok      bool
+	}{
+		{"pkg:npm/lodash@4.17.21", "lodash", "4.17.21", true},
+		{"pkg:npm/%40babel/core@7.22.0", "@babel/core", "7.22.0", true},
+		{"pkg:npm/@babel/core@7.22.0", "@babel/core", "7.22.0", true},
+		{"pkg:pypi/requests@2.31.0#src", "requests", "2.31.0", true},
+		{"pkg:npm/left-pad", "left-pad", "", true},
+		{"npm/left-pad@1.3.0", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			name, version, ok := ParsePackageURL(tt.purl)
+			if name != tt.name || version != tt.version || ok != tt.ok {
+				t.Errorf("ParsePackageURL(%q) = %q, %q, %v, want %q, %q, %v", tt.purl, name, version, ok, tt.name, tt.version, tt.ok)
+			}
+		})
+	}
+}
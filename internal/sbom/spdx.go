@@ -0,0 +1,155 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+)
+
+// SPDXVersion is the SPDX version written by EncodeSPDXJSON and EncodeSPDXTagValue
+const SPDXVersion = "SPDX-2.3"
+
+// spdxNoAssertion marks fields the scan cannot vouch for
+const spdxNoAssertion = "NOASSERTION"
+
+// spdxIDUnsafe matches characters not allowed in SPDX element ids
+var spdxIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+type spdxDocument struct {
+	SPDXVersion       string           `json:"spdxVersion"`
+	DataLicense       string           `json:"dataLicense"`
+	SPDXID            string           `json:"SPDXID"`
+	Name              string           `json:"name"`
+	DocumentNamespace string           `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo `json:"creationInfo"`
+	Packages          []spdxPackage    `json:"packages"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// EncodeSPDXJSON converts scanned components into an SPDX JSON document
+func EncodeSPDXJSON(components []Component, name string, timestamp time.Time) ([]byte, error) {
+	output, err := json.MarshalIndent(newSPDXDocument(components, name, timestamp), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SPDX document: %w", err)
+	}
+	return output, nil
+}
+
+// EncodeSPDXTagValue converts scanned components into an SPDX tag-value document
+func EncodeSPDXTagValue(components []Component, name string, timestamp time.Time) []byte {
+	document := newSPDXDocument(components, name, timestamp)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SPDXVersion: %s\n", document.SPDXVersion)
+	fmt.Fprintf(&b, "DataLicense: %s\n", document.DataLicense)
+	fmt.Fprintf(&b, "SPDXID: %s\n", document.SPDXID)
+	fmt.Fprintf(&b, "DocumentName: %s\n", document.Name)
+	fmt.Fprintf(&b, "DocumentNamespace: %s\n", document.DocumentNamespace)
+	for _, creator := range document.CreationInfo.Creators {
+		fmt.Fprintf(&b, "Creator: %s\n", creator)
+	}
+	fmt.Fprintf(&b, "Created: %s\n", document.CreationInfo.Created)
+
+	for _, p := range document.Packages {
+		fmt.Fprintf(&b, "\nPackageName: %s\n", p.Name)
+		fmt.Fprintf(&b, "SPDXID: %s\n", p.SPDXID)
+		if p.VersionInfo != "" {
+			fmt.Fprintf(&b, "PackageVersion: %s\n", p.VersionInfo)
+		}
+		fmt.Fprintf(&b, "PackageDownloadLocation: %s\n", p.DownloadLocation)
+		fmt.Fprintf(&b, "FilesAnalyzed: %t\n", p.FilesAnalyzed)
+		fmt.Fprintf(&b, "PackageLicenseConcluded: %s\n", p.LicenseConcluded)
+		fmt.Fprintf(&b, "PackageLicenseDeclared: %s\n", p.LicenseDeclared)
+		for _, ref := range p.ExternalRefs {
+			fmt.Fprintf(&b, "ExternalRef: %s %s %s\n", ref.ReferenceCategory, ref.ReferenceType, ref.ReferenceLocator)
+		}
+	}
+	return []byte(b.String())
+}
+
+// newSPDXDocument builds the document shared by both SPDX serializations.
+// The namespace is derived from the content, so the same scan at the same
+// time always yields the same document.
+func newSPDXDocument(components []Component, name string, timestamp time.Time) spdxDocument {
+	created := timestamp.UTC().Format(time.RFC3339)
+	hash := sha256.New()
+	fmt.Fprintln(hash, name, created)
+
+	document := spdxDocument{
+		SPDXVersion:  SPDXVersion,
+		DataLicense:  "CC0-1.0",
+		SPDXID:       "SPDXRef-DOCUMENT",
+		Name:         name,
+		CreationInfo: spdxCreationInfo{Created: created, Creators: []string{"Tool: license-scanner"}},
+		Packages:     make([]spdxPackage, 0, len(components)),
+	}
+	for i, c := range components {
+		fmt.Fprintln(hash, c.Name, c.Version, c.License)
+		license := spdxLicense(c.License)
+		document.Packages = append(document.Packages, spdxPackage{
+			// The index keeps ids unique when merged projects share a package
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%d-%s", i+1, strings.Trim(spdxIDUnsafe.ReplaceAllString(c.Name+"-"+c.Version, "-"), "-")),
+			Name:             c.Name,
+			VersionInfo:      c.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: license,
+			LicenseDeclared:  license,
+			ExternalRefs: []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: PackageURL(c)},
+			},
+		})
+	}
+	document.DocumentNamespace = "https://spdx.org/spdxdocs/" + spdxIDUnsafe.ReplaceAllString(name, "-") +
+		"-" + hex.EncodeToString(hash.Sum(nil))[:16]
+	return document
+}
+
+// spdxLicense returns the license as a valid SPDX expression: known license
+// ids and expressions made only of them. Anything else is NOASSERTION.
+func spdxLicense(license string) string {
+	parsed, err := analyzer.ParseLicenseExpression(license)
+	if err != nil || !knownLicenses(parsed) {
+		return spdxNoAssertion
+	}
+	return parsed.SPDX()
+}
+
+func knownLicenses(p *analyzer.ParsedLicense) bool {
+	if p.Operator == "" {
+		_, known := analyzer.KnownLicenses[p.License]
+		return known
+	}
+	for _, operand := range p.Licenses {
+		if !knownLicenses(operand) {
+			return false
+		}
+	}
+	return true
+}
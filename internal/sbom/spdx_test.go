@@ -0,0 +1,82 @@
+package sbom
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func spdxTestComponents() []Component {
+	return []Component{
+		{Name: "lodash", Version: "4.17.21", License: "MIT", Manager: "npm"},
+		{Name: "@babel/core", Version: "7.22.0", License: "MIT OR Apache-2.0", Manager: "npm"},
+		{Name: "left-pad", Version: "1.3.0", License: "Custom-1.0", Manager: "npm"},
+		{Name: "mystery", Version: "0.1.0", License: "Unknown", Manager: "npm"},
+		{Name: "requests", Version: "2.31.0", License: "Apache-2.0", Manager: "pip"},
+	}
+}
+
+func assertGolden(t *testing.T, name string, output []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, output, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(output, expected) {
+		t.Errorf("output differs from %s:\n%s", golden, output)
+	}
+}
+
+func TestEncodeSPDXJSON(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	output, err := EncodeSPDXJSON(spdxTestComponents(), "my-app", timestamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertGolden(t, "spdx.golden.json", output)
+
+	// The document reads back as the scanned components, minus unasserted licenses
+	loaded, err := Load(writeSBOM(t, string(output)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	drift := Compare(spdxTestComponents(), loaded)
+	if !drift.Empty() {
+		t.Errorf("expected the exported SBOM to match the scan, got %+v", drift)
+	}
+}
+
+func TestEncodeSPDXTagValue(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	assertGolden(t, "spdx.golden.spdx", EncodeSPDXTagValue(spdxTestComponents(), "my-app", timestamp))
+}
+
+func TestSPDXLicense(t *testing.T) {
+	tests := map[string]string{
+		"MIT":                       "MIT",
+		"MIT OR Apache-2.0":         "MIT OR Apache-2.0",
+		"GPL-3.0-or-later":          "GPL-3.0-or-later",
+		"GPL-2.0-only OR MIT":       "GPL-2.0-only OR MIT",
+		"LGPL-2.1+":                 "LGPL-2.1+",
+		"(MIT AND ISC) OR GPL-2.0":  "(MIT AND ISC) OR GPL-2.0",
+		"WTFPL":                     "WTFPL",
+		"Custom-1.0":                spdxNoAssertion,
+		"MIT OR Custom-1.0":         spdxNoAssertion,
+		"Unknown":                   spdxNoAssertion,
+		"SEE LICENSE IN LICENSE.md": spdxNoAssertion,
+		"":                          spdxNoAssertion,
+	}
+	for license, expected := range tests {
+		if got := spdxLicense(license); got != expected {
+			t.Errorf("spdxLicense(%q) = %q, want %q", license, got, expected)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+)
+
+// DefaultConcurrency is how many projects ScanProjects scans at once
+const DefaultConcurrency = 4
+
+// ProjectResult is the outcome of scanning one project path
+type ProjectResult struct {
+	Path   string
+	Result *ScanResult
+	Err    error
+}
+
+// ScanProjects scans independent projects in parallel, at most concurrency at
+// a time, using newScanner to configure each one. A failing project does not
+// abort the others; results are ordered by project path.
+func ScanProjects(paths []string, concurrency int, newScanner func(path string) *Scanner) []ProjectResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]ProjectResult, len(paths))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result, err := newScanner(path).Scan()
+			results[i] = ProjectResult{Path: path, Result: result, Err: err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results
+}
+
+// Merge combines the successful project results into one. Each dependency is
+// attributed to its project and package manager; coverage and source metrics
+// are recomputed over all dependencies.
+func Merge(results []ProjectResult) *ScanResult {
+	merged := &ScanResult{
+		Dependencies: []EnrichedDependency{},
+		Warnings:     []string{},
+	}
+
+	projectLicenses := make(map[string]bool)
+	scanned := 0
+	allPrivate := true
+	installedCount := 0
+	for _, project := range results {
+		if project.Err != nil || project.Result == nil {
+			continue
+		}
+		scanned++
+		result := project.Result
+
+		if merged.PackageManager == "" {
+			merged.PackageManager = result.PackageManager
+		} else if merged.PackageManager != result.PackageManager {
+			merged.PackageManager = "mixed"
+		}
+		projectLicenses[result.ProjectLicense] = true
+		allPrivate = allPrivate && result.ProjectPrivate
+
+		for _, dep := range result.Dependencies {
+			// Root filesystem scans already attribute each package
+			if dep.Project == "" {
+				dep.Project = project.Path
+				dep.Manager = result.PackageManager
+			}
+			if dep.Installed {
+				installedCount++
+			}
+			merged.Dependencies = append(merged.Dependencies, dep)
+		}
+		for _, warning := range result.Warnings {
+			if len(results) > 1 {
+				warning = fmt.Sprintf("%s: %s", project.Path, warning)
+			}
+			merged.Warnings = append(merged.Warnings, warning)
+		}
+	}
+
+	// A single project license only makes sense if every project agrees
+	merged.ProjectLicense = constants.UnknownLicense
+	if len(projectLicenses) == 1 {
+		for license := range projectLicenses {
+			merged.ProjectLicense = license
+		}
+	}
+	merged.ProjectPrivate = scanned > 0 && allPrivate
+
+	merged.InstalledCoverage = 1.0
+	if len(merged.Dependencies) > 0 {
+		merged.InstalledCoverage = math.Round(float64(installedCount)/float64(len(merged.Dependencies))*100) / 100
+	}
+	merged.SourceMetrics = computeSourceMetrics(merged.Dependencies)
+
+	return merged
+}
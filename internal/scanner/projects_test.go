@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/StefanoA1/license-scanner/internal/detector"
+)
+
+func TestScanProjects(t *testing.T) {
+	fs := NewMockFileSystem()
+	for _, project := range []struct{ path, dep, license string }{
+		{"repos/web", "react", "MIT"},
+		{"repos/api", "express", "MIT"},
+		{"repos/cli", "commander", "ISC"},
+	} {
+		fs.AddFile(filepath.Join(project.path, "package-lock.json"), `{
+			"packages": {"node_modules/`+project.dep+`": {"version": "1.0.0"}}
+		}`)
+		fs.AddFile(filepath.Join(project.path, "node_modules", project.dep, "package.json"),
+			`{"license": "`+project.license+`"}`)
+	}
+
+	paths := []string{"repos/web", "repos/missing", "repos/cli", "repos/api"}
+	results := ScanProjects(paths, 2, func(path string) *Scanner {
+		return NewWithDependencies(path, detector.NewWithFileSystem(fs), fs)
+	})
+
+	expectedOrder := []string{"repos/api", "repos/cli", "repos/missing", "repos/web"}
+	for i, result := range results {
+		if result.Path != expectedOrder[i] {
+			t.Fatalf("expected results ordered by path %v, got %s at %d", expectedOrder, result.Path, i)
+		}
+	}
+
+	// The failing project is reported without aborting the others
+	if results[2].Err == nil {
+		t.Error("expected an error for the project without a lock file")
+	}
+	for _, i := range []int{0, 1, 3} {
+		if results[i].Err != nil {
+			t.Errorf("unexpected error for %s: %v", results[i].Path, results[i].Err)
+		}
+	}
+
+	merged := Merge(results)
+	if len(merged.Dependencies) != 3 {
+		t.Fatalf("expected 3 merged dependencies, got %d", len(merged.Dependencies))
+	}
+
+	expected := []struct{ name, project string }{
+		{"express", "repos/api"},
+		{"commander", "repos/cli"},
+		{"react", "repos/web"},
+	}
+	for i, dep := range merged.Dependencies {
+		if dep.Name != expected[i].name || dep.Project != expected[i].project || dep.Manager != "npm" {
+			t.Errorf("expected %s from %s, got %s from %s (%s)", expected[i].name, expected[i].project, dep.Name, dep.Project, dep.Manager)
+		}
+	}
+
+	if merged.InstalledCoverage != 1.0 || merged.SourceMetrics["package.json"].Count != 3 {
+		t.Errorf("expected metrics over all projects, got coverage %v and %v", merged.InstalledCoverage, merged.SourceMetrics)
+	}
+}
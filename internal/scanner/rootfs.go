@@ -0,0 +1,260 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/constants"
+	"github.com/StefanoA1/license-scanner/internal/detector"
+	"github.com/StefanoA1/license-scanner/internal/parser"
+)
+
+// Package managers of packages found by a root filesystem walk
+const (
+	PackageManagerRootFS = "rootfs"
+	PackageManagerPip    = constants.PackageManagerPip
+	PackageManagerDeb    = "deb"
+	PackageManagerAPK    = "apk"
+	PackageManagerRPM    = "rpm"
+)
+
+// pseudoFileSystems are kernel-provided directories skipped at the rootfs top level
+var pseudoFileSystems = map[string]bool{"proc": true, "sys": true, "dev": true}
+
+// SetRootFS makes Scan treat the root path as an unpacked container root
+// filesystem and report every installed language package found in it
+func (s *Scanner) SetRootFS(rootFS bool) {
+	s.rootFS = rootFS
+}
+
+// scanRootFS reports the OS packages of the root filesystem and walks it for
+// node_modules and Python site-packages directories. There is no lock file,
+// so every package found on disk is reported once per name@version,
+// attributed to its install directory.
+func (s *Scanner) scanRootFS() (*ScanResult, error) {
+	lister, ok := s.fs.(parser.DirReader)
+	if !ok {
+		return nil, fmt.Errorf("file system cannot list directories")
+	}
+	if _, err := lister.ReadDir(s.rootPath); err != nil {
+		return nil, fmt.Errorf("failed to read root filesystem %s: %w", s.rootPath, err)
+	}
+
+	walker := &rootFSWalker{scanner: s, lister: lister, seen: make(map[string]bool)}
+	walker.collectOSPackages(s.rootPath)
+	walker.walk(s.rootPath, true)
+
+	return &ScanResult{
+		PackageManager:    PackageManagerRootFS,
+		ProjectLicense:    constants.UnknownLicense,
+		Dependencies:      walker.dependencies,
+		SourceMetrics:     computeSourceMetrics(walker.dependencies),
+		InstalledCoverage: 1.0,
+		Warnings:          []string{},
+	}, nil
+}
+
+type rootFSWalker struct {
+	scanner      *Scanner
+	lister       parser.DirReader
+	seen         map[string]bool
+	dependencies []EnrichedDependency
+}
+
+// walk visits every directory below dir. Symlinks are not followed, so
+// links back up the tree cannot loop.
+func (w *rootFSWalker) walk(dir string, top bool) {
+	entries, err := w.lister.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	switch filepath.Base(dir) {
+	case constants.NodeModulesDir:
+		w.collectNodeModules(dir, entries)
+	case "site-packages", "dist-packages":
+		w.collectSitePackages(dir, entries)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || (top && pseudoFileSystems[entry.Name()]) {
+			continue
+		}
+		w.walk(filepath.Join(dir, entry.Name()), false)
+	}
+}
+
+// collectNodeModules reports the packages installed in a node_modules
+// directory, including scoped ones. Nested node_modules are found by the walk.
+func (w *rootFSWalker) collectNodeModules(dir string, entries []os.DirEntry) {
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		packagePath := filepath.Join(dir, entry.Name())
+		if strings.HasPrefix(entry.Name(), "@") {
+			scoped, err := w.lister.ReadDir(packagePath)
+			if err != nil {
+				continue
+			}
+			for _, scopedEntry := range scoped {
+				if scopedEntry.IsDir() {
+					w.addNodePackage(dir, filepath.Join(packagePath, scopedEntry.Name()))
+				}
+			}
+			continue
+		}
+		w.addNodePackage(dir, packagePath)
+	}
+}
+
+func (w *rootFSWalker) addNodePackage(installDir, packagePath string) {
+	file, err := w.scanner.fs.Open(filepath.Join(packagePath, constants.PackageJSONFile))
+	if err != nil {
+		return
+	}
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	err = json.NewDecoder(file).Decode(&manifest)
+	_ = file.Close() // Ignore close error as we already read the file
+	if err != nil || manifest.Name == "" {
+		return
+	}
+
+	info, err := w.scanner.licenseDetector.DetectLicense(packagePath)
+	if err != nil {
+		info = &detector.LicenseInfo{License: constants.UnknownLicense, Source: constants.DetectionFailedSource}
+	}
+	w.add(installDir, constants.PackageManagerNPM, manifest.Name, manifest.Version, info)
+}
+
+// collectSitePackages reports the Python distributions installed in a
+// site-packages directory from their <name>-<version>.dist-info directories
+func (w *rootFSWalker) collectSitePackages(dir string, entries []os.DirEntry) {
+	pythonDetector := detector.NewPythonInstalledDetectorWithFileSystem(w.scanner.fs, dir)
+	for _, entry := range entries {
+		distInfo, isDistInfo := strings.CutSuffix(entry.Name(), ".dist-info")
+		if !entry.IsDir() || !isDistInfo {
+			continue
+		}
+		// Installers replace dashes in names and versions, so the first dash separates them
+		name, version, found := strings.Cut(distInfo, "-")
+		if !found {
+			continue
+		}
+
+		info, err := pythonDetector.DetectLicense(name, version)
+		if err != nil {
+			info = &detector.LicenseInfo{License: constants.UnknownLicense, Source: constants.DetectionFailedSource}
+		}
+		w.add(dir, PackageManagerPip, name, version, info)
+	}
+}
+
+// collectOSPackages reports the packages recorded in the dpkg and apk
+// databases. The RPM database is binary, so on other systems the packages
+// are taken from the RPM %license directories, without a version.
+func (w *rootFSWalker) collectOSPackages(rootfs string) {
+	osDetector := detector.NewOSPackageDetectorWithFileSystem(w.scanner.fs, rootfs)
+
+	dpkg, hasDpkg := w.readPackageDatabase(filepath.Join(rootfs, constants.DpkgStatusFile))
+	for _, pkg := range dpkg {
+		// Removed packages keep a paragraph with a "deinstall ok config-files" status
+		if pkg["Package"] == "" || !strings.HasSuffix(pkg["Status"], " installed") {
+			continue
+		}
+		info, _ := osDetector.DetectLicense(pkg["Package"])
+		w.add(rootfs, PackageManagerDeb, pkg["Package"], pkg["Version"], info)
+	}
+
+	apk, hasAPK := w.readPackageDatabase(filepath.Join(rootfs, constants.APKDatabaseFile))
+	for _, pkg := range apk {
+		if pkg["P"] == "" {
+			continue
+		}
+		info := &detector.LicenseInfo{License: pkg["L"], Confidence: 0.9, Source: constants.APKDatabaseSource}
+		if info.License == "" {
+			info, _ = osDetector.DetectLicense(pkg["P"])
+		}
+		w.add(rootfs, PackageManagerAPK, pkg["P"], pkg["V"], info)
+	}
+
+	if hasDpkg || hasAPK {
+		return
+	}
+	licenseDir := filepath.Join(rootfs, constants.RPMLicenseDir)
+	entries, err := w.lister.ReadDir(licenseDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, _ := osDetector.DetectLicense(entry.Name())
+		w.add(rootfs, PackageManagerRPM, entry.Name(), "", info)
+	}
+}
+
+// readPackageDatabase reads the "Key: value" paragraphs of a dpkg status or
+// apk installed database. Continuation lines are skipped.
+func (w *rootFSWalker) readPackageDatabase(path string) ([]map[string]string, bool) {
+	file, err := w.scanner.fs.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer func() { _ = file.Close() }() // Ignore close error as we only read the file
+
+	var packages []map[string]string
+	pkg := map[string]string{}
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lines.Scan() {
+		line := lines.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(pkg) > 0 {
+				packages = append(packages, pkg)
+				pkg = map[string]string{}
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		if key, value, found := strings.Cut(line, ":"); found {
+			pkg[key] = strings.TrimSpace(value)
+		}
+	}
+	if len(pkg) > 0 {
+		packages = append(packages, pkg)
+	}
+	return packages, true
+}
+
+func (w *rootFSWalker) add(installDir, manager, name, version string, info *detector.LicenseInfo) {
+	key := manager + ":" + name + "@" + version
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+
+	w.dependencies = append(w.dependencies, EnrichedDependency{
+		Name:       name,
+		Version:    version,
+		License:    info.License,
+		Confidence: info.Confidence,
+		Source:     info.Source,
+		TextHash:   info.TextHash,
+		Installed:  true,
+		Project:    installDir,
+		Manager:    manager,
+
+		LicenseTruncated: info.Truncated,
+	})
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/StefanoA1/license-scanner/internal/detector"
+)
+
+func TestScanner_Scan_RootFS(t *testing.T) {
+	fs := NewMockFileSystem()
+	globalModules := filepath.Join("rootfs", "usr", "lib", "node_modules")
+	fs.AddFile(filepath.Join(globalModules, "npm", "package.json"),
+		`{"name": "npm", "version": "10.2.4", "license": "Artistic-2.0"}`)
+	fs.AddFile(filepath.Join(globalModules, "npm", "node_modules", "semver", "package.json"),
+		`{"name": "semver", "version": "7.5.4", "license": "ISC"}`)
+	fs.AddFile(filepath.Join(globalModules, "@scope", "tool", "package.json"),
+		`{"name": "@scope/tool", "version": "1.0.0", "license": "MIT"}`)
+
+	// The same package installed by another app is reported once
+	fs.AddFile(filepath.Join("rootfs", "app", "node_modules", "semver", "package.json"),
+		`{"name": "semver", "version": "7.5.4", "license": "ISC"}`)
+
+	sitePackages := filepath.Join("rootfs", "usr", "lib", "python3.11", "site-packages")
+	fs.AddFile(filepath.Join(sitePackages, "requests-2.31.0.dist-info", "METADATA"),
+		"Metadata-Version: 2.1\nName: requests\nVersion: 2.31.0\nLicense: Apache 2.0\n")
+	fs.AddFile(filepath.Join(sitePackages, "requests", "__init__.py"), "")
+
+	// Kernel pseudo file systems are not walked
+	fs.AddFile(filepath.Join("rootfs", "proc", "1", "root", "node_modules", "ghost", "package.json"),
+		`{"name": "ghost", "version": "1.0.0"}`)
+
+	s := NewWithDependencies("rootfs", detector.NewWithFileSystem(fs), fs)
+	s.SetRootFS(true)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.PackageManager != PackageManagerRootFS {
+		t.Errorf("expected package manager %s, got %s", PackageManagerRootFS, result.PackageManager)
+	}
+
+	expected := map[string]struct{ license, manager, project string }{
+		"npm@10.2.4":        {"Artistic-2.0", "npm", globalModules},
+		"semver@7.5.4":      {"ISC", "npm", ""},
+		"@scope/tool@1.0.0": {"MIT", "npm", globalModules},
+		"requests@2.31.0":   {"Apache-2.0", PackageManagerPip, sitePackages},
+	}
+	if len(result.Dependencies) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %d: %+v", len(expected), len(result.Dependencies), result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		want, ok := expected[dep.Name+"@"+dep.Version]
+		if !ok {
+			t.Errorf("unexpected dependency %s@%s", dep.Name, dep.Version)
+			continue
+		}
+		if dep.License != want.license || dep.Manager != want.manager {
+			t.Errorf("%s: expected %s from %s, got %s from %s", dep.Name, want.license, want.manager, dep.License, dep.Manager)
+		}
+		if want.project != "" && dep.Project != want.project {
+			t.Errorf("%s: expected install dir %s, got %s", dep.Name, want.project, dep.Project)
+		}
+		if !dep.Installed {
+			t.Errorf("%s: expected packages found on disk to be installed", dep.Name)
+		}
+	}
+}
+
+func TestScanner_Scan_RootFSMissing(t *testing.T) {
+	fs := NewMockFileSystem()
+	s := NewWithDependencies("missing", detector.NewWithFileSystem(fs), fs)
+	s.SetRootFS(true)
+	if _, err := s.Scan(); err == nil {
+		t.Error("expected an error for a root filesystem that does not exist")
+	}
+}
+
+func TestScanner_Scan_RootFSOSPackages(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected map[string]string
+		manager  string
+	}{
+		{
+			name: "dpkg",
+			files: map[string]string{
+				"var/lib/dpkg/status": "Package: curl\nStatus: install ok installed\nVersion: 7.88.1-10\nDescription: command line tool\n transferring data with URL syntax\n\n" +
+					"Package: zlib1g\nStatus: install ok installed\nVersion: 1:1.2.13\n\n" +
+					"Package: removed\nStatus: deinstall ok config-files\nVersion: 1.0\n",
+				"usr/share/doc/curl/copyright": "Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/\n\nFiles: *\nLicense: Expat\n",
+			},
+			expected: map[string]string{"curl@7.88.1-10": "MIT", "zlib1g@1:1.2.13": "Unknown"},
+			manager:  PackageManagerDeb,
+		},
+		{
+			name: "apk",
+			files: map[string]string{
+				"lib/apk/db/installed": "C:Q1abc=\nP:musl\nV:1.2.4-r2\nL:MIT\n\nP:busybox\nV:1.36.1-r5\nL:GPL-2.0-only\n",
+			},
+			expected: map[string]string{"musl@1.2.4-r2": "MIT", "busybox@1.36.1-r5": "GPL-2.0-only"},
+			manager:  PackageManagerAPK,
+		},
+		{
+			name: "rpm",
+			files: map[string]string{
+				"usr/share/licenses/zlib/COPYING": "GNU General Public License, version 2\n",
+			},
+			expected: map[string]string{"zlib@": "GPL-2.0"},
+			manager:  PackageManagerRPM,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewMockFileSystem()
+			for path, content := range tt.files {
+				fs.AddFile(filepath.Join("rootfs", path), content)
+			}
+
+			s := NewWithDependencies("rootfs", detector.NewWithFileSystem(fs), fs)
+			s.SetRootFS(true)
+			result, err := s.Scan()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Dependencies) != len(tt.expected) {
+				t.Fatalf("expected %d dependencies, got %d: %+v", len(tt.expected), len(result.Dependencies), result.Dependencies)
+			}
+			for _, dep := range result.Dependencies {
+				license, ok := tt.expected[dep.Name+"@"+dep.Version]
+				if !ok {
+					t.Errorf("unexpected dependency %s@%s", dep.Name, dep.Version)
+					continue
+				}
+				if dep.License != license || dep.Manager != tt.manager {
+					t.Errorf("%s: expected %s from %s, got %s from %s", dep.Name, license, tt.manager, dep.License, dep.Manager)
+				}
+			}
+		})
+	}
+}
@@ -1,14 +1,26 @@
 package scanner
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/StefanoA1/license-scanner/internal/constants"
 	"github.com/StefanoA1/license-scanner/internal/detector"
+	"github.com/StefanoA1/license-scanner/internal/history"
 	"github.com/StefanoA1/license-scanner/internal/parser"
+	"github.com/StefanoA1/license-scanner/internal/registry"
 )
 
 type Scanner struct {
@@ -16,18 +28,99 @@ type Scanner struct {
 	licenseDetector *detector.Detector
 	fs              parser.FileSystem
 	verbose         bool
+	registry        *registry.Client
+	repository      *registry.RawClient
+	packageFilter   []string
+	includeSubtree  bool
+	rootFS          bool
+	prodOnly        bool
+	workers         int
+	// directOnly leaves transitive dependencies out of the scan
+	directOnly bool
+	// workspaces merges npm workspace members and attributes dependencies
+	workspaces bool
+	// goModules detects the licenses of Go modules in the module cache
+	goModules *detector.GoModuleDetector
+	// python detects the licenses of Python packages in site-packages
+	python       *detector.PythonInstalledDetector
+	sitePackages string
+	// sbomLicenses maps name@version to the license an SBOM declares
+	sbomLicenses map[string]string
+	// pnpLocations maps name@version to the install location recorded by
+	// Yarn Plug'n'Play, relative to the project root
+	pnpLocations map[string]string
+	// yarnCache lists the sorted archive names of the Yarn Berry cache
+	yarnCache []string
 }
 
 type ScanResult struct {
-	Dependencies []EnrichedDependency `json:"dependencies"`
+	PackageManager string `json:"packageManager"`
+	LockFile       string `json:"lockFile"`
+	ProjectLicense string `json:"projectLicense"`
+	// ProjectPrivate is set when the root package.json has "private": true,
+	// meaning the project is not published or distributed
+	ProjectPrivate bool                    `json:"projectPrivate"`
+	Dependencies   []EnrichedDependency    `json:"dependencies"`
+	SourceMetrics  map[string]SourceMetric `json:"sourceMetrics"`
+	// InstalledCoverage is the fraction of dependencies installed on disk
+	InstalledCoverage float64  `json:"installedCoverage"`
+	Warnings          []string `json:"warnings"`
+}
+
+// LowCoverageThreshold is the installed coverage below which the install is
+// reported as incomplete
+const LowCoverageThreshold = 0.9
+
+// SBOMConfidence is the confidence of a license declared by a trusted SBOM,
+// curated data that is used without reading the package
+const SBOMConfidence = 0.95
+
+// LockFileConfidence is the confidence of a license recorded in the lock
+// file: declared metadata, but not read from the installed package
+const LockFileConfidence = 0.9
+
+// SourceMetric aggregates how often a detection source was chosen and how
+// confident its detections were
+type SourceMetric struct {
+	Count             int     `json:"count"`
+	AverageConfidence float64 `json:"averageConfidence"`
 }
 
 type EnrichedDependency struct {
-	Name       string  `json:"name"`
-	Version    string  `json:"version"`
-	License    string  `json:"license"`
-	Confidence float64 `json:"confidence"`
-	Source     string  `json:"source"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	License      string   `json:"license"`
+	Confidence   float64  `json:"confidence"`
+	Source       string   `json:"source"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Depth        int      `json:"depth,omitempty"`
+	TextHash     string   `json:"licenseTextHash,omitempty"`
+	Installed    bool     `json:"installed"`
+	// Direct is set for packages the root package.json depends on
+	Direct bool `json:"direct,omitempty"`
+	// LicenseTruncated is set when only the head of the license file was matched
+	LicenseTruncated bool `json:"licenseTruncated,omitempty"`
+	// RegistryLicense is set when the registry disagrees with the local detection
+	RegistryLicense string `json:"registryLicense,omitempty"`
+	// Overridden is set when package.json overrides or resolutions change the
+	// version the lock file resolved
+	Overridden bool `json:"overridden,omitempty"`
+	// Bundled is set for bundleDependencies and their subtree, which ship
+	// inside the package and are redistributed with it
+	Bundled bool `json:"bundled,omitempty"`
+	// Local is set for file: and link: dependencies, which are first-party
+	Local bool `json:"local,omitempty"`
+	// Unresolved is set when Version is a package.json range, as the
+	// project has no lock file
+	Unresolved bool `json:"unresolved,omitempty"`
+	// Workspaces are the workspace members depending on the package,
+	// directly or transitively (SetWorkspaces)
+	Workspaces []string `json:"workspaces,omitempty"`
+	// Introduced is the commit that added the dependency to the lock file
+	Introduced *history.Introduction `json:"introduced,omitempty"`
+	// Project and Manager attribute the dependency when projects are merged
+	Project string `json:"project,omitempty"`
+	Manager string `json:"manager,omitempty"`
 }
 
 func New(rootPath string) *Scanner {
@@ -64,11 +157,117 @@ func NewWithDependencies(rootPath string, licenseDetector *detector.Detector, fs
 	}
 }
 
+// SetRegistry enables online registry lookups for packages whose license
+// cannot be detected locally
+func (s *Scanner) SetRegistry(client *registry.Client) {
+	s.registry = client
+}
+
+// SetRepositoryClient enables fetching the LICENSE of packages without a
+// local license from their git repository, at the commit they were published from
+func (s *Scanner) SetRepositoryClient(client *registry.RawClient) {
+	s.repository = client
+}
+
+// SetMaxLicenseSize caps how many bytes of each license file are matched
+func (s *Scanner) SetMaxLicenseSize(size int64) {
+	s.licenseDetector.SetMaxLicenseSize(size)
+}
+
+// SetDetectionCache reuses the detections cached for unchanged packages,
+// e.g. by earlier scans sharing the cache
+func (s *Scanner) SetDetectionCache(cache detector.Cache) {
+	s.licenseDetector.SetCache(cache)
+}
+
+// SetConfidenceCaps limits the confidence of license text matches per license
+func (s *Scanner) SetConfidenceCaps(caps map[string]float64) {
+	s.licenseDetector.SetConfidenceCaps(caps)
+}
+
+// SetPackageFilter restricts the scan to the named packages, given as
+// "name" or "name@version". With includeSubtree their dependencies are kept too.
+func (s *Scanner) SetPackageFilter(packages []string, includeSubtree bool) {
+	s.packageFilter = packages
+	s.includeSubtree = includeSubtree
+}
+
+// SetSBOMLicenses trusts the licenses of an SBOM, keyed by name@version:
+// packages it covers skip detection and registry lookups
+func (s *Scanner) SetSBOMLicenses(licenses map[string]string) {
+	s.sbomLicenses = licenses
+}
+
+// SetWorkspaces merges the dependencies of npm workspace members that have
+// their own package-lock.json and attributes every dependency to the members
+// using it
+func (s *Scanner) SetWorkspaces(workspaces bool) {
+	s.workspaces = workspaces
+}
+
+// SetWorkers sets how many dependencies are enriched in parallel; zero or
+// less uses one worker per CPU
+func (s *Scanner) SetWorkers(workers int) {
+	s.workers = workers
+}
+
+func (s *Scanner) detectionWorkers() int {
+	if s.workers < 1 {
+		return runtime.NumCPU()
+	}
+	return s.workers
+}
+
+// SetProdOnly leaves dev dependencies, which are not shipped, out of the scan
+func (s *Scanner) SetProdOnly(prodOnly bool) {
+	s.prodOnly = prodOnly
+}
+
+// SetGoModuleDetector sets how Go modules are detected, e.g. from another
+// module cache or with an online fallback; by default the module cache the
+// go command uses is read
+func (s *Scanner) SetGoModuleDetector(goModules *detector.GoModuleDetector) {
+	s.goModules = goModules
+}
+
+// SetSitePackages sets the site-packages directory Python packages are
+// installed in; by default the one of a .venv or venv virtualenv in the
+// project is used
+func (s *Scanner) SetSitePackages(dir string) {
+	s.sitePackages = dir
+}
+
+// SetDirectOnly restricts the scan to the dependencies listed in the root
+// package.json
+func (s *Scanner) SetDirectOnly(directOnly bool) {
+	s.directOnly = directOnly
+}
+
 func (s *Scanner) Scan() (*ScanResult, error) {
+	return s.ScanContext(context.Background())
+}
+
+// ScanContext is Scan stopping early with ctx.Err() once ctx is done. The
+// context is checked after parsing and before each dependency is detected.
+func (s *Scanner) ScanContext(ctx context.Context) (*ScanResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.rootFS {
+		return s.scanRootFS()
+	}
+
 	// Detect which lock file exists
 	lockFilePath, packageManager, err := parser.DetectLockFile(s.fs, s.rootPath)
+	unresolved := false
 	if err != nil {
-		return nil, fmt.Errorf("no lock file found in %s", s.rootPath)
+		// Libraries often commit only package.json; its ranges stand in for
+		// the versions a lock file would have resolved
+		manifestPath := filepath.Join(s.rootPath, constants.PackageJSONFile)
+		if _, err := s.fs.Stat(manifestPath); err != nil {
+			return nil, fmt.Errorf("no lock file found in %s", s.rootPath)
+		}
+		lockFilePath, packageManager, unresolved = manifestPath, constants.PackageManagerNPM, true
 	}
 
 	if s.verbose {
@@ -80,10 +279,28 @@ func (s *Scanner) Scan() (*ScanResult, error) {
 	switch packageManager {
 	case "npm":
 		lockParser = parser.NewNPMParserWithFS(s.fs)
+		if unresolved {
+			lockParser = parser.NewPackageJSONParserWithFS(s.fs)
+		}
 	case "pnpm":
 		lockParser = parser.NewPnpmParserWithFS(s.fs)
 	case "yarn":
 		lockParser = parser.NewYarnParserWithFS(s.fs)
+	case "bower":
+		lockParser = parser.NewBowerParserWithFS(s.fs)
+	case "cargo":
+		lockParser = parser.NewCargoParserWithFS(s.fs)
+	case "go":
+		lockParser = parser.NewGoModParserWithFS(s.fs)
+		if s.goModules == nil {
+			s.goModules = detector.NewGoModuleDetectorWithFileSystem(s.fs, detector.DefaultGoModCache())
+		}
+	case "poetry":
+		lockParser = parser.NewPoetryParserWithFS(s.fs)
+	case "pipenv":
+		lockParser = parser.NewPipenvParserWithFS(s.fs)
+	case "pip":
+		lockParser = parser.NewRequirementsParserWithFS(s.fs)
 	default:
 		return nil, fmt.Errorf("unsupported package manager: %s", packageManager)
 	}
@@ -92,15 +309,155 @@ func (s *Scanner) Scan() (*ScanResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse lock file: %w", err)
 	}
+	if isPython(packageManager) {
+		sitePackages := s.sitePackages
+		if sitePackages == "" {
+			sitePackages = s.findSitePackages()
+		}
+		s.python = detector.NewPythonInstalledDetectorWithFileSystem(s.fs, sitePackages)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Plug'n'Play installs have no node_modules; the map says where each package is
+	if packageManager == constants.PackageManagerYarn {
+		if locations, err := parser.ParsePnpData(s.fs, s.rootPath); err == nil {
+			s.pnpLocations = locations
+		}
+		s.yarnCache = s.indexYarnCache()
+	}
+
+	// pnpm workspace members are first-party packages linked into each other
+	if packageManager == constants.PackageManagerPnpm {
+		if members, err := parser.ParsePnpmWorkspace(s.fs, s.rootPath); err == nil {
+			markWorkspaceMembers(dependencies, members)
+		}
+	}
+
+	// npm and Yarn workspace members may also keep a lock file of their own
+	var workspaces map[string][]string
+	if s.workspaces {
+		dependencies, workspaces = s.mergeWorkspaces(dependencies)
+	}
+
+	// The root package.json says what is direct; without one the top of the
+	// lock file graph stands in for it
+	if direct, err := parser.ParseDirect(s.fs, filepath.Join(s.rootPath, constants.PackageJSONFile)); err == nil {
+		for i, dep := range dependencies {
+			dependencies[i].Direct = dep.Direct || isDirect(dep, direct)
+		}
+	} else {
+		for i, dep := range dependencies {
+			dependencies[i].Direct = dep.Direct || dep.Depth == 1
+		}
+	}
+
+	if s.directOnly {
+		direct := dependencies[:0]
+		for _, dep := range dependencies {
+			if dep.Direct {
+				direct = append(direct, dep)
+			}
+		}
+		dependencies = direct
+	}
+
+	if s.prodOnly {
+		prod := dependencies[:0]
+		for _, dep := range dependencies {
+			if !dep.Dev {
+				prod = append(prod, dep)
+			}
+		}
+		dependencies = prod
+	}
+
+	if len(s.packageFilter) > 0 {
+		dependencies = filterPackages(dependencies, s.packageFilter, s.includeSubtree)
+	}
+
+	// Overrides and resolutions decide what actually gets installed; a stale
+	// lock file still reports the version the override replaced. Only the
+	// packages whose version changes are annotated, by name@version.
+	overridden := make(map[string]bool)
+	if overrides, err := parser.ParseOverrides(s.fs, filepath.Join(s.rootPath, constants.PackageJSONFile)); err == nil {
+		for i, dep := range dependencies {
+			if override, ok := overrides[dep.Name]; ok && override.Version != "" && override.Version != dep.Version {
+				dependencies[i].Version = override.Version
+				overridden[dep.Name+"@"+override.Version] = true
+			}
+		}
+	}
+
+	// Bundled dependencies ship inside the package tarball together with
+	// everything they depend on
+	bundled := make(map[string]bool)
+	if names, err := parser.ParseBundled(s.fs, filepath.Join(s.rootPath, constants.PackageJSONFile)); err == nil && len(names) > 0 {
+		specs := make([]string, 0, len(names))
+		for name := range names {
+			specs = append(specs, name)
+		}
+		for _, dep := range filterPackages(dependencies, specs, true) {
+			bundled[dep.Name+"@"+dep.Version] = true
+		}
+	}
 
 	// Enrich dependencies with license information
 	nodeModulesPath := filepath.Join(s.rootPath, constants.NodeModulesDir)
 
-	var enrichedDeps []EnrichedDependency
+	// Parents are needed to find nested copies that were not hoisted
+	parents := make(map[string][]string)
 	for _, dep := range dependencies {
-		packagePath := s.resolvePackagePath(nodeModulesPath, packageManager, dep)
-		licenseInfo, err := s.licenseDetector.DetectLicense(packagePath)
-		if err != nil {
+		for _, child := range dep.Dependencies {
+			parents[child] = append(parents[child], dep.Name)
+		}
+	}
+
+	// The registry and repository lookups only know npm packages
+	online := usesNPMRegistry(packageManager)
+
+	// Detection is dominated by file system reads, so packages are enriched
+	// in parallel; results keep the lock file order
+	enrich := func(dep parser.Dependency) (EnrichedDependency, []string) {
+		var warnings []string
+		packagePath := s.resolvePackagePath(nodeModulesPath, packageManager, dep, parents[dep.Name])
+		// Local packages are detected from their source directory when it exists
+		local := dep.Local != ""
+		if local && s.pathExists(filepath.Join(s.rootPath, dep.Local, constants.PackageJSONFile)) {
+			packagePath = filepath.Join(s.rootPath, dep.Local)
+		}
+		installed := s.isInstalled(packagePath)
+		if installed {
+			if declared, mismatch := s.nameMismatch(packagePath, dep); mismatch {
+				warnings = append(warnings, fmt.Sprintf(
+					"⚠️  %s@%s is installed with the package.json name %s - verify the package was not tampered with or misplaced",
+					dep.Name, dep.Version, declared))
+			}
+		}
+		// A license declared by a trusted SBOM saves reading the package. One
+		// recorded in the lock file only stands in for a package that is not
+		// installed, so installed license files are still read and hashed.
+		var licenseInfo *detector.LicenseInfo
+		var err error
+		sbomLicense, fromSBOM := s.sbomLicenses[dep.Name+"@"+dep.Version]
+		if fromSBOM {
+			licenseInfo = &detector.LicenseInfo{
+				License:    sbomLicense,
+				Confidence: SBOMConfidence,
+				Source:     constants.SBOMSource,
+			}
+		} else if dep.License != "" && !installed {
+			licenseInfo = &detector.LicenseInfo{
+				License:    dep.License,
+				Confidence: LockFileConfidence,
+				Source:     constants.LockFileSource,
+			}
+		} else if packageManager == constants.PackageManagerGo {
+			licenseInfo, err = s.goModules.DetectLicense(dep.Name, dep.Version)
+		} else if isPython(packageManager) {
+			licenseInfo, err = s.python.DetectLicense(dep.Name, dep.Version)
+		} else if licenseInfo, err = s.licenseDetector.DetectLicense(packagePath); err != nil {
 			// If detection fails, use default values
 			licenseInfo = &detector.LicenseInfo{
 				License:    constants.UnknownLicense,
@@ -109,33 +466,557 @@ func (s *Scanner) Scan() (*ScanResult, error) {
 			}
 		}
 
-		enrichedDeps = append(enrichedDeps, EnrichedDependency{
-			Name:       dep.Name,
-			Version:    dep.Version,
-			License:    licenseInfo.License,
-			Confidence: licenseInfo.Confidence,
-			Source:     licenseInfo.Source,
-		})
+		if licenseInfo.License == constants.UnknownLicense && s.repository != nil && online && !local && !fromSBOM {
+			if info := s.repositoryLicense(packagePath); info != nil {
+				licenseInfo = info
+			}
+		}
+
+		// Fall back to the registry when nothing was found on disk, otherwise
+		// cross-check the local detection: a mismatch hints at tampered or
+		// stale files in the install. Local packages are never published.
+		registryLicense := ""
+		if s.registry != nil && online && !local && !fromSBOM {
+			license, err := s.registry.License(dep.Name, dep.Version)
+			switch {
+			case err != nil:
+				if s.verbose {
+					fmt.Fprintf(os.Stderr, "Registry lookup failed for %s@%s: %v\n", dep.Name, dep.Version, err)
+				}
+			case license == "":
+			case licenseInfo.License == constants.UnknownLicense:
+				licenseInfo = &detector.LicenseInfo{
+					License:    license,
+					Confidence: 0.8,
+					Source:     constants.RegistrySource,
+				}
+			case !strings.EqualFold(strings.TrimSpace(license), strings.TrimSpace(licenseInfo.License)):
+				registryLicense = license
+				warnings = append(warnings, fmt.Sprintf(
+					"⚠️  %s@%s is %s locally but %s in the registry - verify the installed files",
+					dep.Name, dep.Version, licenseInfo.License, license))
+			}
+		}
+
+		return EnrichedDependency{
+			Name:         dep.Name,
+			Version:      dep.Version,
+			License:      licenseInfo.License,
+			Confidence:   licenseInfo.Confidence,
+			Source:       licenseInfo.Source,
+			Dependencies: dep.Dependencies,
+			Depth:        dep.Depth,
+			Direct:       dep.Direct,
+			TextHash:     licenseInfo.TextHash,
+			Installed:    installed,
+
+			LicenseTruncated: licenseInfo.Truncated,
+			RegistryLicense:  registryLicense,
+			Overridden:       overridden[dep.Name+"@"+dep.Version],
+			Bundled:          bundled[dep.Name+"@"+dep.Version],
+			Local:            local,
+			Unresolved:       dep.Unresolved,
+			Workspaces:       workspaces[dep.Name+"@"+dep.Version],
+		}, warnings
+	}
+
+	enrichedDeps := make([]EnrichedDependency, len(dependencies))
+	depWarnings := make([][]string, len(dependencies))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.detectionWorkers(), max(len(dependencies), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				enrichedDeps[i], depWarnings[i] = enrich(dependencies[i])
+			}
+		}()
+	}
+	for i := range dependencies {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	enrichedDeps, depWarnings = dedupeDependencies(enrichedDeps, depWarnings)
+
+	installedCount := 0
+	warnings := []string{}
+	for i, dep := range enrichedDeps {
+		if dep.Installed {
+			installedCount++
+		}
+		warnings = append(warnings, depWarnings[i]...)
+	}
+
+	// A missing install explains Unknown licenses better than the packages do
+	coverage := 1.0
+	if len(enrichedDeps) > 0 {
+		coverage = math.Round(float64(installedCount)/float64(len(enrichedDeps))*100) / 100
+	}
+	if unresolved {
+		warnings = append(warnings,
+			"⚠️  No lock file found - versions are the package.json ranges, run npm install to resolve them")
+	} else if coverage < LowCoverageThreshold {
+		warnings = append(warnings, fmt.Sprintf(
+			"⚠️  %s appears incomplete (%.0f%% of dependencies installed) - run %s before scanning",
+			installDirName(packageManager), coverage*100, installCommand(packageManager)))
+	}
+
+	// The project's own license, read from the root package.json or LICENSE
+	projectLicense := constants.UnknownLicense
+	if info, err := s.licenseDetector.DetectLicense(s.rootPath); err == nil {
+		projectLicense = info.License
 	}
 
 	return &ScanResult{
-		Dependencies: enrichedDeps,
+		PackageManager: packageManager,
+		LockFile:       lockFilePath,
+		ProjectLicense: projectLicense,
+		ProjectPrivate: s.isPrivate(s.rootPath),
+		Dependencies:   enrichedDeps,
+		SourceMetrics:  computeSourceMetrics(enrichedDeps),
+
+		InstalledCoverage: coverage,
+		Warnings:          warnings,
 	}, nil
 }
 
+// isDirect reports whether dep is the copy the root package.json asks for:
+// npm installs it at the top of node_modules and Yarn records the range, so
+// nested copies of the same name stay transitive
+func isDirect(dep parser.Dependency, direct map[string]string) bool {
+	spec, ok := direct[dep.Name]
+	switch {
+	case !ok:
+		return false
+	case dep.Path != "":
+		return dep.Path == path.Join(constants.NodeModulesDir, dep.Name)
+	case len(dep.Ranges) > 0:
+		return slices.Contains(dep.Ranges, spec)
+	}
+	return true
+}
+
+// dedupeDependencies keeps one entry per name@version, as lock files list
+// a package once per install path or pnpm peer set. The most confident
+// detection among the copies wins and their warnings are kept.
+func dedupeDependencies(deps []EnrichedDependency, warnings [][]string) ([]EnrichedDependency, [][]string) {
+	index := make(map[string]int, len(deps))
+	unique := make([]EnrichedDependency, 0, len(deps))
+	var uniqueWarnings [][]string
+	for i, dep := range deps {
+		key := dep.Name + "@" + dep.Version
+		j, ok := index[key]
+		if !ok {
+			index[key] = len(unique)
+			unique = append(unique, dep)
+			uniqueWarnings = append(uniqueWarnings, warnings[i])
+			continue
+		}
+		installed := unique[j].Installed || dep.Installed
+		direct := unique[j].Direct || dep.Direct
+		if dep.Confidence > unique[j].Confidence {
+			unique[j] = dep
+		}
+		unique[j].Installed, unique[j].Direct = installed, direct
+		uniqueWarnings[j] = append(uniqueWarnings[j], warnings[i]...)
+	}
+	return unique, uniqueWarnings
+}
+
+// mergeWorkspaces adds the dependencies of workspace members with their
+// own package-lock.json, de-duplicated by name and version, and maps each
+// name@version to the members depending on it
+func (s *Scanner) mergeWorkspaces(dependencies []parser.Dependency) ([]parser.Dependency, map[string][]string) {
+	members, err := parser.ParseNPMWorkspaces(s.fs, s.rootPath)
+	if err != nil {
+		if s.verbose {
+			fmt.Fprintf(os.Stderr, "No workspaces: %v\n", err)
+		}
+		return dependencies, nil
+	}
+
+	// Members sharing the root lock file use the subtree of what they declare
+	workspaces := make(map[string][]string)
+	attribute := func(dep parser.Dependency, member string) {
+		key := dep.Name + "@" + dep.Version
+		if !slices.Contains(workspaces[key], member) {
+			workspaces[key] = append(workspaces[key], member)
+		}
+	}
+	root := dependencies
+	seen := make(map[string]bool, len(dependencies))
+	for _, dep := range dependencies {
+		seen[dep.Name+"@"+dep.Version] = true
+	}
+	for _, member := range members {
+		memberRoot := filepath.Join(s.rootPath, filepath.FromSlash(member.Path))
+		lockFilePath, packageManager, err := parser.DetectLockFile(s.fs, memberRoot)
+		if err != nil || packageManager != constants.PackageManagerNPM {
+			for _, dep := range filterPackages(root, member.Dependencies, true) {
+				attribute(dep, member.Path)
+			}
+			continue
+		}
+		memberDeps, err := parser.NewNPMParserWithFS(s.fs).Parse(lockFilePath)
+		if err != nil {
+			if s.verbose {
+				fmt.Fprintf(os.Stderr, "Skipping lock file of workspace %s: %v\n", member.Path, err)
+			}
+			continue
+		}
+		for _, dep := range memberDeps {
+			attribute(dep, member.Path)
+			if seen[dep.Name+"@"+dep.Version] {
+				continue
+			}
+			seen[dep.Name+"@"+dep.Version] = true
+			// Install paths are relative to the member
+			if dep.Path == "" {
+				dep.Path = path.Join(constants.NodeModulesDir, dep.Name)
+			}
+			dep.Path = path.Join(member.Path, dep.Path)
+			dependencies = append(dependencies, dep)
+		}
+	}
+	for _, members := range workspaces {
+		sort.Strings(members)
+	}
+	return dependencies, workspaces
+}
+
+// markWorkspaceMembers tags links to workspace members as local to the
+// member directory, taking their versions from the member manifests
+func markWorkspaceMembers(dependencies []parser.Dependency, members []parser.WorkspaceMember) {
+	byName := make(map[string]parser.WorkspaceMember, len(members))
+	for _, member := range members {
+		if member.Name != "" {
+			byName[member.Name] = member
+		}
+	}
+	for i, dep := range dependencies {
+		member, ok := byName[dep.Name]
+		if !ok {
+			continue
+		}
+		// A registry package may share the name of a member
+		linked := strings.HasPrefix(dep.Version, "link:") || strings.HasPrefix(dep.Version, "workspace:")
+		if dep.Local == member.Path || linked {
+			dependencies[i].Local = member.Path
+			dependencies[i].Version = member.Version
+		}
+	}
+}
+
+// repositoryLicense detects the license from the LICENSE file in the package's
+// repository at the commit pinned by its package.json, or returns nil
+func (s *Scanner) repositoryLicense(packagePath string) *detector.LicenseInfo {
+	file, err := s.fs.Open(filepath.Join(packagePath, constants.PackageJSONFile))
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Repository interface{} `json:"repository"`
+		GitHead    string      `json:"gitHead"`
+	}
+	err = json.NewDecoder(file).Decode(&manifest)
+	_ = file.Close() // Ignore close error as we already read the file
+	if err != nil {
+		return nil
+	}
+
+	repo, pinned := registry.ParseRepository(manifest.Repository, manifest.GitHead)
+	if !pinned {
+		return nil
+	}
+	text, err := s.repository.LicenseText(repo)
+	if err != nil {
+		if s.verbose {
+			fmt.Fprintf(os.Stderr, "Repository license lookup failed for %s: %v\n", packagePath, err)
+		}
+		return nil
+	}
+
+	license, confidence := detector.IdentifyLicenseText(text)
+	if license == constants.UnknownLicense {
+		return nil
+	}
+	return &detector.LicenseInfo{License: license, Confidence: confidence, Source: constants.RepositorySource}
+}
+
+// manifest is the subset of a package.json the scanner reads itself
+type manifest struct {
+	Name    string `json:"name"`
+	Private bool   `json:"private"`
+}
+
+// readManifest reads the package.json in dir
+func (s *Scanner) readManifest(dir string) (manifest, bool) {
+	var m manifest
+	file, err := s.fs.Open(filepath.Join(dir, constants.PackageJSONFile))
+	if err != nil {
+		return m, false
+	}
+	defer func() {
+		_ = file.Close() // Ignore close error as we already read the file
+	}()
+
+	if err := json.NewDecoder(file).Decode(&m); err != nil {
+		return m, false
+	}
+	return m, true
+}
+
+// isPrivate reports whether the package.json in dir is marked "private": true
+func (s *Scanner) isPrivate(dir string) bool {
+	m, _ := s.readManifest(dir)
+	return m.Private
+}
+
+// nameMismatch returns the name declared by an installed package when it is
+// not the one the lock file expects, e.g. after corruption or manual edits
+func (s *Scanner) nameMismatch(packagePath string, dep parser.Dependency) (string, bool) {
+	m, ok := s.readManifest(packagePath)
+	if !ok || m.Name == "" {
+		return "", false
+	}
+	expected := dep.Name
+	if dep.Alias != "" {
+		expected = dep.Alias
+	}
+	return m.Name, m.Name != expected
+}
+
+// isInstalled reports whether a resolved package path exists on disk. Paths
+// inside Yarn cache archives are installed when the archive exists.
+func (s *Scanner) isInstalled(packagePath string) bool {
+	if index := strings.Index(filepath.ToSlash(packagePath), ".zip/"); index >= 0 {
+		return s.pathExists(packagePath[:index+len(".zip")])
+	}
+	return s.pathExists(packagePath) || s.pathExists(filepath.Join(packagePath, constants.PackageJSONFile))
+}
+
+// usesNPMRegistry reports whether the packages of a package manager are
+// published to the npm registry, which the online lookups query
+func usesNPMRegistry(packageManager string) bool {
+	switch packageManager {
+	case constants.PackageManagerCargo, constants.PackageManagerGo:
+		return false
+	}
+	return !isPython(packageManager)
+}
+
+// isPython reports whether a package manager installs Python packages
+func isPython(packageManager string) bool {
+	switch packageManager {
+	case constants.PackageManagerPoetry, constants.PackageManagerPipenv, constants.PackageManagerPip:
+		return true
+	}
+	return false
+}
+
+// findSitePackages returns the site-packages directory of a .venv or venv
+// virtualenv in the project, or "" without one
+func (s *Scanner) findSitePackages() string {
+	for _, venv := range []string{".venv", "venv"} {
+		// Windows virtualenvs have no Python version in the path
+		if dir := filepath.Join(s.rootPath, venv, "Lib", "site-packages"); s.pathExists(dir) {
+			return dir
+		}
+		lister, ok := s.fs.(parser.DirReader)
+		if !ok {
+			continue
+		}
+		entries, err := lister.ReadDir(filepath.Join(s.rootPath, venv, "lib"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			dir := filepath.Join(s.rootPath, venv, "lib", entry.Name(), "site-packages")
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "python") && s.pathExists(dir) {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// installDirName returns where the package manager installs dependencies
+func installDirName(packageManager string) string {
+	switch packageManager {
+	case constants.PackageManagerBower:
+		return constants.BowerDir
+	case constants.PackageManagerCargo:
+		return constants.CargoVendorDir
+	case constants.PackageManagerGo:
+		return "Go module cache"
+	case constants.PackageManagerPoetry, constants.PackageManagerPipenv, constants.PackageManagerPip:
+		return "site-packages"
+	}
+	return constants.NodeModulesDir
+}
+
+// installCommand returns the command that restores a complete install
+func installCommand(packageManager string) string {
+	switch packageManager {
+	case constants.PackageManagerYarn:
+		return "yarn install --frozen-lockfile"
+	case constants.PackageManagerPnpm:
+		return "pnpm install --frozen-lockfile"
+	case constants.PackageManagerBower:
+		return "bower install"
+	case constants.PackageManagerCargo:
+		return "cargo vendor"
+	case constants.PackageManagerGo:
+		return "go mod download"
+	case constants.PackageManagerPoetry:
+		return "poetry install"
+	case constants.PackageManagerPipenv:
+		return "pipenv sync --dev"
+	case constants.PackageManagerPip:
+		return "pip install -r requirements.txt"
+	default:
+		return "npm ci"
+	}
+}
+
+// AnnotateIntroductions attributes each dependency to the commit that added
+// it to the lock file, using git blame. The project must be a git work tree.
+func (r *ScanResult) AnnotateIntroductions() error {
+	blame, err := history.BlameFile(r.LockFile)
+	if err != nil {
+		return err
+	}
+	for i, dep := range r.Dependencies {
+		if introduction, ok := blame.Introduced(r.PackageManager, dep.Name, dep.Version); ok {
+			r.Dependencies[i].Introduced = &introduction
+		}
+	}
+	return nil
+}
+
+// Hash returns a stable SHA-256 over the sorted dependency set and licenses.
+// Volatile fields such as detection sources are excluded, so the hash only
+// changes when a dependency or its license does.
+func (r *ScanResult) Hash() string {
+	entries := make([]string, len(r.Dependencies))
+	for i, dep := range r.Dependencies {
+		entries[i] = dep.Name + "@" + dep.Version + "\t" + dep.License
+	}
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// computeSourceMetrics returns per-source usage counts and average confidence
+func computeSourceMetrics(dependencies []EnrichedDependency) map[string]SourceMetric {
+	totals := make(map[string]float64)
+	metrics := make(map[string]SourceMetric)
+	for _, dep := range dependencies {
+		metric := metrics[dep.Source]
+		metric.Count++
+		metrics[dep.Source] = metric
+		totals[dep.Source] += dep.Confidence
+	}
+
+	for source, metric := range metrics {
+		// Round to two decimals to keep the report readable
+		metric.AverageConfidence = math.Round(totals[source]/float64(metric.Count)*100) / 100
+		metrics[source] = metric
+	}
+	return metrics
+}
+
+// filterPackages keeps the dependencies matching the filter specs and,
+// optionally, everything they transitively depend on
+func filterPackages(dependencies []parser.Dependency, specs []string, includeSubtree bool) []parser.Dependency {
+	wanted := make(map[string]string) // name -> version ("" matches any)
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, version := spec, ""
+		// Skip the leading @ of scoped packages when locating the version separator
+		if at := strings.LastIndex(spec, "@"); at > 0 {
+			name, version = spec[:at], spec[at+1:]
+		}
+		wanted[name] = version
+	}
+
+	keep := make(map[string]bool)
+	var queue []string
+	for _, dep := range dependencies {
+		if version, ok := wanted[dep.Name]; ok && (version == "" || version == dep.Version) {
+			key := dep.Name + "@" + dep.Version
+			if !keep[key] {
+				keep[key] = true
+				queue = append(queue, dep.Name)
+			}
+		}
+	}
+
+	if includeSubtree {
+		byName := make(map[string][]parser.Dependency)
+		for _, dep := range dependencies {
+			byName[dep.Name] = append(byName[dep.Name], dep)
+		}
+		visited := make(map[string]bool)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			for _, dep := range byName[name] {
+				for _, child := range dep.Dependencies {
+					for _, childDep := range byName[child] {
+						keep[childDep.Name+"@"+childDep.Version] = true
+					}
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+
+	var filtered []parser.Dependency
+	for _, dep := range dependencies {
+		if keep[dep.Name+"@"+dep.Version] {
+			filtered = append(filtered, dep)
+		}
+	}
+	return filtered
+}
+
 // resolvePackagePath resolves the actual file system path for a package based on the package manager
-func (s *Scanner) resolvePackagePath(nodeModulesPath, packageManager string, dep parser.Dependency) string {
+func (s *Scanner) resolvePackagePath(nodeModulesPath, packageManager string, dep parser.Dependency, parents []string) string {
 	switch packageManager {
 	case constants.PackageManagerPnpm:
 		// For pnpm, try multiple possible paths since the structure can vary
-		// Pattern: node_modules/.pnpm/<package>@<version>/node_modules/<package>
+		// Pattern: node_modules/.pnpm/<package>@<version>/node_modules/<package>,
+		// with scoped packages stored as .pnpm/@scope+package@<version>
 		pnpmStorePath := filepath.Join(nodeModulesPath, constants.PnpmStoreDir)
 
-		// For scoped packages, pnpm may encode the @ symbol
+		// For scoped packages, pnpm may encode the @ symbol, and current
+		// versions flatten the scope as @scope+package
 		encodedName := strings.ReplaceAll(dep.Name, "@", "%40")
+		flatName := strings.ReplaceAll(dep.Name, "/", "+")
 
-		// Try with exact version match (both encoded and non-encoded names)
+		// Try with exact version match (flattened, plain and encoded names)
 		candidates := []string{
+			flatName + "@" + dep.Version,
 			dep.Name + "@" + dep.Version,
 			encodedName + "@" + dep.Version,
 		}
@@ -149,12 +1030,14 @@ func (s *Scanner) resolvePackagePath(nodeModulesPath, packageManager string, dep
 
 		// Try to find any version of the package in the .pnpm store
 		// This handles cases where the version might have additional qualifiers
-		if entries, err := os.ReadDir(pnpmStorePath); err == nil {
+		if lister, ok := s.fs.(parser.DirReader); ok {
+			entries, _ := lister.ReadDir(pnpmStorePath)
 			for _, entry := range entries {
 				if entry.IsDir() {
 					entryName := entry.Name()
 					// Check for both regular and encoded package names
-					if strings.HasPrefix(entryName, dep.Name+"@") ||
+					if strings.HasPrefix(entryName, flatName+"@") ||
+						strings.HasPrefix(entryName, dep.Name+"@") ||
 						strings.HasPrefix(entryName, encodedName+"@") {
 						candidatePath := filepath.Join(pnpmStorePath, entryName, constants.NodeModulesDir, dep.Name)
 						if s.pathExists(candidatePath) {
@@ -174,9 +1057,62 @@ func (s *Scanner) resolvePackagePath(nodeModulesPath, packageManager string, dep
 		// Return the expected pnpm path even if it doesn't exist (for error handling)
 		return filepath.Join(pnpmStorePath, dep.Name+"@"+dep.Version, constants.NodeModulesDir, dep.Name)
 
-	case constants.PackageManagerNPM, constants.PackageManagerYarn:
+	case constants.PackageManagerBower:
+		return filepath.Join(parser.BowerComponentsDir(s.fs, s.rootPath), dep.Name)
+
+	case constants.PackageManagerGo:
+		return s.goModules.ModuleDir(dep.Name, dep.Version)
+
+	case constants.PackageManagerPoetry, constants.PackageManagerPipenv, constants.PackageManagerPip:
+		return s.python.DistInfoDir(dep.Name, dep.Version)
+
+	case constants.PackageManagerCargo:
+		// cargo vendor appends the version when several versions are vendored
+		vendorPath := filepath.Join(s.rootPath, constants.CargoVendorDir)
+		versionedPath := filepath.Join(vendorPath, dep.Name+"-"+dep.Version)
+		if s.pathExists(versionedPath) {
+			return versionedPath
+		}
+		return filepath.Join(vendorPath, dep.Name)
+
+	case constants.PackageManagerYarn:
+		name := dep.Name
+		if dep.Alias != "" {
+			name = dep.Alias
+		}
+		if location, ok := s.pnpLocations[name+"@"+dep.Version]; ok {
+			return filepath.Join(s.rootPath, filepath.FromSlash(location))
+		}
+
+		// Yarn Berry with Plug'n'Play keeps packages zipped in .yarn/cache
+		standardPath := filepath.Join(nodeModulesPath, dep.Name)
+		if !s.pathExists(standardPath) {
+			if nestedPath := s.findNestedPackage(nodeModulesPath, dep, parents); nestedPath != "" {
+				return nestedPath
+			}
+			if zipPath := s.findYarnCacheZip(dep); zipPath != "" {
+				return filepath.Join(zipPath, constants.NodeModulesDir, dep.Name)
+			}
+		}
+		return standardPath
+
+	case constants.PackageManagerNPM:
+		// The lock file records where each copy is installed, including nested ones
+		if dep.Path != "" {
+			installedPath := filepath.Join(s.rootPath, filepath.FromSlash(dep.Path))
+			if s.pathExists(installedPath) {
+				return installedPath
+			}
+		}
+
 		// Standard node_modules structure
-		return filepath.Join(nodeModulesPath, dep.Name)
+		standardPath := filepath.Join(nodeModulesPath, dep.Name)
+		if !s.pathExists(standardPath) {
+			if nestedPath := s.findNestedPackage(nodeModulesPath, dep, parents); nestedPath != "" {
+				return nestedPath
+			}
+		}
+		return standardPath
 
 	default:
 		// Default to standard structure
@@ -184,6 +1120,54 @@ func (s *Scanner) resolvePackagePath(nodeModulesPath, packageManager string, dep
 	}
 }
 
+// findNestedPackage looks for a copy of the package that was not hoisted and
+// lives in the node_modules directory of one of its parents
+func (s *Scanner) findNestedPackage(nodeModulesPath string, dep parser.Dependency, parents []string) string {
+	for _, parent := range parents {
+		nestedPath := filepath.Join(nodeModulesPath, parent, constants.NodeModulesDir, dep.Name)
+		if s.pathExists(nestedPath) {
+			return nestedPath
+		}
+	}
+	return ""
+}
+
+// indexYarnCache lists the archives of the Yarn Berry cache once per scan,
+// sorted so findYarnCacheZip can search them by prefix
+func (s *Scanner) indexYarnCache() []string {
+	lister, ok := s.fs.(parser.DirReader)
+	if !ok {
+		return nil
+	}
+	entries, err := lister.ReadDir(filepath.Join(s.rootPath, constants.YarnCacheDir))
+	if err != nil {
+		return nil
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".zip") {
+			archives = append(archives, entry.Name())
+		}
+	}
+	sort.Strings(archives)
+	return archives
+}
+
+// findYarnCacheZip locates a package archive in the Yarn Berry cache. Archives are
+// named <name>-npm-<version>-<checksum>.zip, with the scope slash replaced by a dash.
+func (s *Scanner) findYarnCacheZip(dep parser.Dependency) string {
+	prefix := strings.ReplaceAll(dep.Name, "/", "-") + "-npm-"
+	if dep.Version != "" {
+		prefix += dep.Version + "-"
+	}
+	i := sort.SearchStrings(s.yarnCache, prefix)
+	if i < len(s.yarnCache) && strings.HasPrefix(s.yarnCache[i], prefix) {
+		return filepath.Join(s.rootPath, constants.YarnCacheDir, s.yarnCache[i])
+	}
+	return ""
+}
+
 // pathExists checks if a path exists on the file system
 func (s *Scanner) pathExists(path string) bool {
 	_, err := s.fs.Stat(path)
@@ -1,15 +1,29 @@
 package scanner
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	iofs "io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+	"github.com/StefanoA1/license-scanner/internal/constants"
 	"github.com/StefanoA1/license-scanner/internal/detector"
+	"github.com/StefanoA1/license-scanner/internal/registry"
 )
 
 // MockFileSystem implements detector.FileSystem for testing
@@ -64,6 +78,35 @@ func (fs *MockFileSystem) Join(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+// ReadDir lists the direct children of a directory
+func (fs *MockFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	dir := filepath.Clean(path)
+	seen := make(map[string]bool)
+	var entries []os.DirEntry
+	add := func(p string, isDir bool) {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		name := strings.Split(filepath.ToSlash(rel), "/")[0]
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		entries = append(entries, iofs.FileInfoToDirEntry(&mockFileInfo{name: name, isDir: isDir || name != filepath.Base(rel)}))
+	}
+	for p := range fs.files {
+		add(p, false)
+	}
+	for p := range fs.dirs {
+		add(p, true)
+	}
+	if len(entries) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
 type mockFileInfo struct {
 	name  string
 	isDir bool
@@ -142,6 +185,34 @@ func TestScanner_Scan_NPM(t *testing.T) {
 	}
 }
 
+func TestScanner_Scan_LicenseExpression(t *testing.T) {
+	// The expression reaches the analyzer intact, so the OR picks MIT
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `# yarn lockfile v1
+
+dual@^1.0.0:
+  version "1.0.0"
+`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "dual", "package.json"), `{"name": "dual", "version": "1.0.0", "license": "MIT OR GPL-3.0"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 1 || result.Dependencies[0].License != "MIT OR GPL-3.0" {
+		t.Fatalf("expected the MIT OR GPL-3.0 expression, got %+v", result.Dependencies)
+	}
+
+	dep := result.Dependencies[0]
+	analysis := analyzer.New().Analyze([]analyzer.Dependency{
+		{Name: dep.Name, Version: dep.Version, License: dep.License, Confidence: dep.Confidence, Depth: 1},
+	})
+	if analysis.RiskLevel != "low" || analysis.LicenseCounts["MIT"] != 1 || analysis.LicenseCounts["GPL-3.0"] != 0 {
+		t.Errorf("expected MIT at low risk, got %s with %v", analysis.RiskLevel, analysis.LicenseCounts)
+	}
+}
+
 func TestScanner_Scan_Yarn(t *testing.T) {
 	fs := NewMockFileSystem()
 
@@ -196,6 +267,135 @@ express@4.18.0:
 	}
 }
 
+// readDirCountingFileSystem counts the listings of each directory
+type readDirCountingFileSystem struct {
+	*MockFileSystem
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (fs *readDirCountingFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	fs.mu.Lock()
+	fs.counts[filepath.Clean(path)]++
+	fs.mu.Unlock()
+	return fs.MockFileSystem.ReadDir(path)
+}
+
+func TestScanner_Scan_YarnBerryCache(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `lodash@^4.17.21:
+  version "4.17.21"
+
+"@babel/core@^7.22.0":
+  version "7.22.0"
+`)
+
+	// Plug'n'Play installs have no node_modules, only zipped packages
+	lodashZip := buildZip(t, map[string]string{
+		"node_modules/lodash/package.json": `{"name": "lodash", "license": "MIT"}`,
+	})
+	babelZip := buildZip(t, map[string]string{
+		"node_modules/@babel/core/package.json": `{"name": "@babel/core"}`,
+		"node_modules/@babel/core/LICENSE":      "MIT License\n\nPermission is hereby granted, free of charge",
+	})
+	fs.AddFile(filepath.Join(testRoot, ".yarn", "cache", "lodash-npm-4.17.21-6382451519-eb835a2e51.zip"), lodashZip)
+	fs.AddFile(filepath.Join(testRoot, ".yarn", "cache", "@babel-core-npm-7.22.0-2a5b1c3d4e-0123456789.zip"), babelZip)
+
+	counting := &readDirCountingFileSystem{MockFileSystem: fs, counts: make(map[string]int)}
+	scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), counting)
+	result, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The cache is listed once, not once per dependency
+	if count := counting.counts[filepath.Join(testRoot, ".yarn", "cache")]; count != 1 {
+		t.Errorf("expected the Yarn cache to be listed once, got %d", count)
+	}
+
+	expected := map[string]string{
+		"lodash":      "package.json",
+		"@babel/core": "LICENSE file",
+	}
+	for _, dep := range result.Dependencies {
+		if dep.License != "MIT" {
+			t.Errorf("dependency %s: expected license MIT, got %s", dep.Name, dep.License)
+		}
+		if dep.Source != expected[dep.Name] {
+			t.Errorf("dependency %s: expected source %q, got %q", dep.Name, expected[dep.Name], dep.Source)
+		}
+	}
+}
+
+func TestScanner_Scan_YarnPnpData(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `__metadata:
+  version: 8
+
+"esbuild@npm:^0.19.0":
+  version: 0.19.0
+  resolution: "esbuild@npm:0.19.0"
+  languageName: node
+  linkType: hard
+
+"lodash@npm:^4.17.21":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  languageName: node
+  linkType: hard
+`)
+	// Only the map knows about unplugged packages and custom cache folders
+	fs.AddFile(filepath.Join(testRoot, ".pnp.data.json"), `{
+		"packageRegistryData": [
+			[null, [[null, {"packageLocation": "./"}]]],
+			["esbuild", [["npm:0.19.0", {"packageLocation": "./.yarn/unplugged/esbuild-npm-0.19.0-0123456789/node_modules/esbuild/"}]]],
+			["lodash", [["npm:4.17.21", {"packageLocation": "./vendor/yarn-cache/lodash.zip/node_modules/lodash/"}]]]
+		]
+	}`)
+	fs.AddFile(filepath.Join(testRoot, ".yarn", "unplugged", "esbuild-npm-0.19.0-0123456789", "node_modules", "esbuild", "package.json"), `{"name": "esbuild", "license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "vendor", "yarn-cache", "lodash.zip"), buildZip(t, map[string]string{
+		"node_modules/lodash/package.json": `{"name": "lodash", "license": "MIT"}`,
+	}))
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Dependencies) != 2 {
+		t.Fatalf("expected 2 dependencies, got %+v", result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if dep.License != "MIT" || dep.Source != "package.json" || !dep.Installed {
+			t.Errorf("dependency %s: expected MIT from its package.json at the Plug'n'Play location, got %+v", dep.Name, dep)
+		}
+	}
+}
+
+// buildZip creates an in-memory zip archive with the given files
+func buildZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.String()
+}
+
 func TestScanner_Scan_Pnpm(t *testing.T) {
 	fs := NewMockFileSystem()
 
@@ -268,6 +468,47 @@ func TestScanner_Scan_NoLockFile(t *testing.T) {
 	}
 }
 
+func TestScanner_Scan_PackageJSONOnly(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{
+		"name": "my-lib",
+		"dependencies": {"lodash": "^4.17.0"},
+		"devDependencies": {"jest": "^29.0.0"}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "lodash", "package.json"), `{"name": "lodash", "version": "4.17.21", "license": "MIT"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Dependencies) != 2 {
+		t.Fatalf("expected 2 dependencies, got %+v", result.Dependencies)
+	}
+	lodash := result.Dependencies[0]
+	if lodash.Name != "lodash" || lodash.Version != "^4.17.0" || lodash.License != "MIT" || !lodash.Unresolved {
+		t.Errorf("expected unresolved lodash@^4.17.0 detected as MIT, got %+v", lodash)
+	}
+	if result.LockFile != filepath.Join(testRoot, "package.json") {
+		t.Errorf("expected package.json in place of the lock file, got %s", result.LockFile)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "No lock file found") {
+		t.Errorf("expected a warning about the missing lock file, got %v", result.Warnings)
+	}
+
+	// Dev dependencies are dropped like those of a lock file
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetProdOnly(true)
+	result, err = s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 1 || result.Dependencies[0].Name != "lodash" {
+		t.Errorf("expected only lodash with -prod-only, got %+v", result.Dependencies)
+	}
+}
+
 func TestScanner_Scan_LicenseDetectionFallback(t *testing.T) {
 	fs := NewMockFileSystem()
 
@@ -322,104 +563,1400 @@ func TestScanner_Scan_LicenseDetectionFallback(t *testing.T) {
 	}
 }
 
-func TestScanner_Scan_MixedLicenseSources(t *testing.T) {
+func TestScanner_Scan_ProjectLicense(t *testing.T) {
 	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
 
-	// Add package-lock.json with multiple dependencies
-	lockContent := `{
-		"name": "test-project",
-		"version": "1.0.0",
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{"packages": {"node_modules/lodash": {"version": "4.17.21"}}}`)
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{"name": "test-project", "license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "lodash", "package.json"), `{"license": "MIT"}`)
+
+	scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	result, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.ProjectLicense != "MIT" {
+		t.Errorf("expected project license MIT, got %q", result.ProjectLicense)
+	}
+
+	// Without a root manifest or LICENSE the project license is unknown
+	fs = NewMockFileSystem()
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{"packages": {}}`)
+	result, err = NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ProjectLicense != "Unknown" {
+		t.Errorf("expected unknown project license, got %q", result.ProjectLicense)
+	}
+}
+
+func TestScanner_Scan_PackageFilter(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
 		"packages": {
-			"": {
-				"name": "test-project",
-				"version": "1.0.0"
-			},
-			"node_modules/package-json-license": {
-				"version": "1.0.0"
-			},
-			"node_modules/license-file-license": {
-				"version": "1.0.0"
-			},
-			"node_modules/no-license": {
-				"version": "1.0.0"
-			}
+			"node_modules/lodash": {"version": "4.17.21"},
+			"node_modules/express": {"version": "4.18.0", "dependencies": {"accepts": "~1.3.8"}},
+			"node_modules/accepts": {"version": "1.3.8"},
+			"node_modules/@types/node": {"version": "18.0.0"},
+			"node_modules/react": {"version": "18.2.0"}
 		}
-	}`
-	// Use cross-platform test root path
-	testRoot := filepath.Join("test")
+	}`)
+
+	tests := []struct {
+		name           string
+		packages       []string
+		includeSubtree bool
+		expected       []string
+	}{
+		{
+			name:     "names only",
+			packages: []string{"lodash", "express"},
+			expected: []string{"express", "lodash"},
+		},
+		{
+			name:     "scoped name with version",
+			packages: []string{"@types/node@18.0.0", "react@17.0.0"},
+			expected: []string{"@types/node"},
+		},
+		{
+			name:           "with subtree",
+			packages:       []string{"express"},
+			includeSubtree: true,
+			expected:       []string{"accepts", "express"},
+		},
+	}
 
-	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), lockContent)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+			scanner.SetPackageFilter(tt.packages, tt.includeSubtree)
 
-	// Add license via package.json for first dependency
-	fs.AddFile(filepath.Join(testRoot, "node_modules", "package-json-license", "package.json"), `{"license": "Apache-2.0"}`)
+			result, err := scanner.Scan()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
-	// Add license via LICENSE file for second dependency
-	fs.AddFile(filepath.Join(testRoot, "node_modules", "license-file-license", "LICENSE"), "Apache License\nVersion 2.0, January 2004\n\nLicensed under the Apache License, Version 2.0")
+			var names []string
+			for _, dep := range result.Dependencies {
+				names = append(names, dep.Name)
+			}
+			sort.Strings(names)
 
-	// No license information for third dependency
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
 
-	mockDetector := detector.NewWithFileSystem(fs)
-	scanner := NewWithDependencies(testRoot, mockDetector, fs)
+func TestScanner_Scan_SourceMetrics(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/a": {"version": "1.0.0"},
+			"node_modules/b": {"version": "1.0.0"},
+			"node_modules/c": {"version": "1.0.0"},
+			"node_modules/d": {"version": "1.0.0"},
+			"node_modules/e": {"version": "1.0.0"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "a", "package.json"), `{"license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "b", "package.json"), `{"license": "ISC"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "c", "LICENSE"), "MIT License\n\nPermission is hereby granted, free of charge")
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "d", "LICENSE"), "Some custom license text")
 
+	scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
 	result, err := scanner.Scan()
 	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-		return
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(result.Dependencies) != 3 {
-		t.Errorf("expected 3 dependencies, got %d", len(result.Dependencies))
-		return
+	expected := map[string]SourceMetric{
+		"package.json": {Count: 2, AverageConfidence: 1.0},
+		"LICENSE file": {Count: 2, AverageConfidence: 0.55},
+		"not found":    {Count: 1, AverageConfidence: 0.0},
+	}
+	if len(result.SourceMetrics) != len(expected) {
+		t.Errorf("expected metrics for %d sources, got %v", len(expected), result.SourceMetrics)
+	}
+	for source, metric := range expected {
+		if result.SourceMetrics[source] != metric {
+			t.Errorf("source %q: expected %+v, got %+v", source, metric, result.SourceMetrics[source])
+		}
 	}
+}
 
-	// Convert to map for easier checking
-	depMap := make(map[string]EnrichedDependency)
-	for _, dep := range result.Dependencies {
-		depMap[dep.Name] = dep
+func TestScanner_Scan_NestedDependency(t *testing.T) {
+	testRoot := filepath.Join("test")
+
+	t.Run("npm nested install path", func(t *testing.T) {
+		fs := NewMockFileSystem()
+		fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+			"packages": {
+				"node_modules/debug": {"version": "4.3.4"},
+				"node_modules/express": {"version": "4.18.0", "dependencies": {"debug": "2.6.9"}},
+				"node_modules/express/node_modules/debug": {"version": "2.6.9"}
+			}
+		}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "debug", "package.json"), `{"license": "MIT"}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "express", "package.json"), `{"license": "MIT"}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "express", "node_modules", "debug", "package.json"), `{"license": "ISC"}`)
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "debug"))
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "express"))
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "express", "node_modules", "debug"))
+
+		result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		licenses := make(map[string]string)
+		for _, dep := range result.Dependencies {
+			licenses[dep.Name+"@"+dep.Version] = dep.License
+		}
+		if licenses["debug@4.3.4"] != "MIT" || licenses["debug@2.6.9"] != "ISC" {
+			t.Errorf("expected hoisted and nested debug to be detected separately, got %v", licenses)
+		}
+	})
+
+	t.Run("yarn parent node_modules", func(t *testing.T) {
+		fs := NewMockFileSystem()
+		fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `express@^4.18.0:
+  version "4.18.0"
+  dependencies:
+    cookie "0.5.0"
+
+cookie@0.5.0:
+  version "0.5.0"
+`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "express", "package.json"), `{"license": "MIT"}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "express", "node_modules", "cookie", "package.json"), `{"license": "ISC"}`)
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "express"))
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "express", "node_modules", "cookie"))
+
+		result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, dep := range result.Dependencies {
+			if dep.Name == "cookie" && dep.License != "ISC" {
+				t.Errorf("expected nested cookie to be found under express, got %s from %s", dep.License, dep.Source)
+			}
+		}
+	})
+}
+
+func TestScanner_Scan_DuplicateDependencies(t *testing.T) {
+	testRoot := filepath.Join("test")
+	count := func(deps []EnrichedDependency) map[string]int {
+		counts := make(map[string]int)
+		for _, dep := range deps {
+			counts[dep.Name+"@"+dep.Version]++
+		}
+		return counts
 	}
 
-	// Check package.json source
-	if dep, exists := depMap["package-json-license"]; exists {
-		if dep.License != "Apache-2.0" {
-			t.Errorf("package-json-license: expected license 'Apache-2.0', got %s", dep.License)
+	t.Run("legacy nested duplicate", func(t *testing.T) {
+		fs := NewMockFileSystem()
+		fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+			"lockfileVersion": 1,
+			"dependencies": {
+				"a": {"version": "1.0.0", "requires": {"ms": "2.1.2"}, "dependencies": {"ms": {"version": "2.1.2"}}},
+				"b": {"version": "1.0.0", "requires": {"ms": "2.1.2"}, "dependencies": {"ms": {"version": "2.1.2"}}},
+				"ms": {"version": "2.1.2"}
+			}
+		}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "ms", "package.json"), `{"license": "MIT"}`)
+
+		result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if dep.Source != "package.json" {
-			t.Errorf("package-json-license: expected source 'package.json', got %s", dep.Source)
+		expected := map[string]int{"a@1.0.0": 1, "b@1.0.0": 1, "ms@2.1.2": 1}
+		if counts := count(result.Dependencies); !reflect.DeepEqual(counts, expected) {
+			t.Errorf("expected %v, got %v", expected, counts)
 		}
-		if dep.Confidence != 1.0 {
-			t.Errorf("package-json-license: expected confidence 1.0, got %f", dep.Confidence)
+	})
+
+	t.Run("copies disagreeing on the license", func(t *testing.T) {
+		fs := NewMockFileSystem()
+		fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+			"lockfileVersion": 3,
+			"packages": {
+				"node_modules/a": {"version": "1.0.0"},
+				"node_modules/a/node_modules/ms": {"version": "2.1.2"},
+				"node_modules/b": {"version": "1.0.0"},
+				"node_modules/b/node_modules/ms": {"version": "2.1.2"}
+			}
+		}`)
+		// The first copy only has an unrecognized LICENSE file
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "a", "node_modules", "ms"))
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "a", "node_modules", "ms", "LICENSE"), "All rights reserved by nobody in particular")
+		fs.AddDir(filepath.Join(testRoot, "node_modules", "b", "node_modules", "ms"))
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "b", "node_modules", "ms", "package.json"), `{"license": "ISC"}`)
+
+		result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-	} else {
-		t.Error("package-json-license dependency not found")
+		if counts := count(result.Dependencies); counts["ms@2.1.2"] != 1 {
+			t.Fatalf("expected ms@2.1.2 once, got %v", counts)
+		}
+		for _, dep := range result.Dependencies {
+			if dep.Name == "ms" && (dep.License != "ISC" || dep.Confidence != 1.0 || !dep.Installed) {
+				t.Errorf("expected the most confident copy (ISC from package.json), got %+v", dep)
+			}
+		}
+	})
+}
+
+func TestScanner_Scan_PrivateProject(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{"name": "internal-app", "private": true}`)
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{"packages": {}}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// Check LICENSE file source
-	if dep, exists := depMap["license-file-license"]; exists {
-		if dep.License != "Apache-2.0" {
-			t.Errorf("license-file-license: expected license 'Apache-2.0', got %s", dep.License)
+	if !result.ProjectPrivate {
+		t.Error("expected the project to be detected as private")
+	}
+}
+
+func TestScanResult_Hash(t *testing.T) {
+	scan := func(reactLicense string) string {
+		fs := NewMockFileSystem()
+		testRoot := filepath.Join("test")
+		fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+			"packages": {
+				"node_modules/react": {"version": "18.2.0"},
+				"node_modules/lodash": {"version": "4.17.21"}
+			}
+		}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "react", "package.json"), `{"license": "`+reactLicense+`"}`)
+		fs.AddFile(filepath.Join(testRoot, "node_modules", "lodash", "package.json"), `{"license": "MIT"}`)
+
+		result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if dep.Source != "LICENSE file" {
-			t.Errorf("license-file-license: expected source 'LICENSE file', got %s", dep.Source)
+		return result.Hash()
+	}
+
+	first, second := scan("MIT"), scan("MIT")
+	if first != second {
+		t.Errorf("expected identical scans to hash the same, got %s and %s", first, second)
+	}
+
+	if changed := scan("GPL-3.0"); changed == first {
+		t.Error("expected a license change to change the hash")
+	}
+
+	// Dependency order does not matter
+	reordered := &ScanResult{Dependencies: []EnrichedDependency{
+		{Name: "b", Version: "1.0.0", License: "MIT"},
+		{Name: "a", Version: "1.0.0", License: "ISC", Source: "LICENSE file"},
+	}}
+	ordered := &ScanResult{Dependencies: []EnrichedDependency{
+		{Name: "a", Version: "1.0.0", License: "ISC", Source: "package.json"},
+		{Name: "b", Version: "1.0.0", License: "MIT"},
+	}}
+	if reordered.Hash() != ordered.Hash() {
+		t.Error("expected the hash to ignore ordering and detection sources")
+	}
+}
+
+func TestScanner_Scan_Overrides(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{
+		"dependencies": {"express": "^4.18.0"},
+		"overrides": {"qs": "6.11.2", "express": "4.18.2"}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/express": {"version": "4.18.2", "dependencies": {"qs": "6.11.0"}},
+			"node_modules/qs": {"version": "6.11.0"}
 		}
-		if dep.Confidence != 0.9 {
-			t.Errorf("license-file-license: expected confidence 0.9, got %f", dep.Confidence)
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "express", "package.json"), `{"license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "qs", "package.json"), `{"license": "BSD-3-Clause"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dep := range result.Dependencies {
+		switch dep.Name {
+		case "qs":
+			if dep.Version != "6.11.2" || !dep.Overridden {
+				t.Errorf("expected qs overridden to 6.11.2, got %s (overridden=%v)", dep.Version, dep.Overridden)
+			}
+		case "express":
+			// The override pins the version the lock file already has
+			if dep.Version != "4.18.2" || dep.Overridden {
+				t.Errorf("expected express untouched, got %s (overridden=%v)", dep.Version, dep.Overridden)
+			}
 		}
-	} else {
-		t.Error("license-file-license dependency not found")
 	}
+}
 
-	// Check no license fallback
-	if dep, exists := depMap["no-license"]; exists {
-		if dep.License != "Unknown" {
-			t.Errorf("no-license: expected license 'Unknown', got %s", dep.License)
+func TestScanner_Scan_Bundled(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{
+		"dependencies": {"gpl-lib": "^1.0.0", "react": "^18.2.0"},
+		"bundleDependencies": ["gpl-lib"]
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/gpl-lib": {"version": "1.0.0", "dependencies": {"helper": "^2.0.0"}},
+			"node_modules/helper": {"version": "2.0.0"},
+			"node_modules/react": {"version": "18.2.0"}
 		}
-		if dep.Source != "not found" {
-			t.Errorf("no-license: expected source 'not found', got %s", dep.Source)
+	}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Bundled packages ship with everything they depend on
+	expected := map[string]bool{"gpl-lib": true, "helper": true, "react": false}
+	for _, dep := range result.Dependencies {
+		if dep.Bundled != expected[dep.Name] {
+			t.Errorf("%s: expected bundled=%v, got %v", dep.Name, expected[dep.Name], dep.Bundled)
 		}
-		if dep.Confidence != 0.0 {
-			t.Errorf("no-license: expected confidence 0.0, got %f", dep.Confidence)
+	}
+}
+
+func TestScanner_Scan_RepositoryLicense(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/acme/widget/3f2e1d0/LICENSE" {
+			fmt.Fprint(w, "Apache License, Version 2.0, January 2004")
+			return
 		}
-	} else {
-		t.Error("no-license dependency not found")
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {"node_modules/widget": {"version": "1.0.0"}}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "widget", "package.json"),
+		`{"name": "widget", "repository": "git+https://github.com/acme/widget.git", "gitHead": "3f2e1d0"}`)
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetRepositoryClient(registry.NewRawClientWithURL(server.URL))
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dep := result.Dependencies[0]
+	if dep.License != "Apache-2.0" || dep.Source != constants.RepositorySource {
+		t.Errorf("expected Apache-2.0 from the repository, got %s from %s", dep.License, dep.Source)
+	}
+}
+
+func TestScanner_Scan_LocalDependency(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"": {"dependencies": {"shared": "file:packages/shared"}},
+			"node_modules/shared": {"resolved": "packages/shared", "link": true},
+			"packages/shared": {"name": "shared", "version": "2.0.0"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "packages", "shared", "package.json"), `{"name": "shared", "license": "BSD-3-Clause"}`)
+
+	// A registry lookup would fail the test: local packages are never published
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected registry request for %s", r.URL.Path)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetRegistry(registry.NewWithURL(server.URL))
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Dependencies) != 1 {
+		t.Fatalf("expected 1 dependency, got %+v", result.Dependencies)
+	}
+	dep := result.Dependencies[0]
+	if !dep.Local || !dep.Installed {
+		t.Errorf("expected shared to be tagged local and installed, got %+v", dep)
+	}
+	if dep.Version != "2.0.0" || dep.License != "BSD-3-Clause" {
+		t.Errorf("expected shared@2.0.0 resolved from packages/shared as BSD-3-Clause, got %s@%s %s", dep.Name, dep.Version, dep.License)
+	}
+}
+
+func TestScanner_Scan_ProdOnly(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"": {"dependencies": {"express": "^4.18.0"}, "devDependencies": {"jest": "^29.0.0"}},
+			"node_modules/express": {"version": "4.18.0"},
+			"node_modules/jest": {"version": "29.0.0", "dev": true}
+		}
+	}`)
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 2 {
+		t.Errorf("expected dev dependencies to be scanned by default, got %+v", result.Dependencies)
+	}
+
+	s.SetProdOnly(true)
+	result, err = s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 1 || result.Dependencies[0].Name != "express" {
+		t.Errorf("expected only express with -prod-only, got %+v", result.Dependencies)
+	}
+}
+
+func TestScanner_Scan_Direct(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{
+		"dependencies": {"debug": "^4.3.4", "ms": "^3.0.0"},
+		"devDependencies": {"jest": "^29.0.0"}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"": {"dependencies": {"debug": "^4.3.4", "ms": "^3.0.0"}, "devDependencies": {"jest": "^29.0.0"}},
+			"node_modules/debug": {"version": "4.3.4", "dependencies": {"ms": "2.1.2"}},
+			"node_modules/debug/node_modules/ms": {"version": "2.1.2"},
+			"node_modules/ms": {"version": "3.0.0"},
+			"node_modules/jest": {"version": "29.0.0", "dev": true}
+		}
+	}`)
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	direct := make(map[string]bool)
+	for _, dep := range result.Dependencies {
+		direct[dep.Name+"@"+dep.Version] = dep.Direct
+	}
+	// The nested ms is only there for debug
+	expected := map[string]bool{"debug@4.3.4": true, "ms@2.1.2": false, "ms@3.0.0": true, "jest@29.0.0": true}
+	if !reflect.DeepEqual(direct, expected) {
+		t.Errorf("expected %v, got %v", expected, direct)
+	}
+
+	s.SetDirectOnly(true)
+	result, err = s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 3 {
+		t.Errorf("expected the 3 direct dependencies with -direct-only, got %+v", result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if !dep.Direct {
+			t.Errorf("expected only direct dependencies, got %s@%s", dep.Name, dep.Version)
+		}
+	}
+}
+
+func TestScanner_Scan_Direct_Yarn(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{"dependencies": {"chalk": "^4.1.0"}}`)
+	fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `# yarn lockfile v1
+
+chalk@^2.4.2:
+  version "2.4.2"
+
+chalk@^4.1.0:
+  version "4.1.2"
+`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 2 {
+		t.Fatalf("expected both copies of chalk, got %+v", result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if dep.Direct != (dep.Version == "4.1.2") {
+			t.Errorf("expected only the ^4.1.0 copy of chalk to be direct, got %s direct=%v", dep.Version, dep.Direct)
+		}
+	}
+}
+
+func TestScanner_Scan_Cargo(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("rust")
+	fs.AddFile(filepath.Join(testRoot, "Cargo.lock"), `version = 3
+
+[[package]]
+name = "myapp"
+version = "0.1.0"
+dependencies = [
+ "rand 0.7.3",
+ "rand 0.8.5",
+ "serde",
+]
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "serde"
+version = "1.0.188"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`)
+	// cargo vendor suffixes the version of all but one copy
+	fs.AddDir(filepath.Join(testRoot, "vendor", "rand"))
+	fs.AddDir(filepath.Join(testRoot, "vendor", "rand-0.7.3"))
+	fs.AddFile(filepath.Join(testRoot, "vendor", "rand", "Cargo.toml"), "[package]\nname = \"rand\"\nlicense = \"MIT OR Apache-2.0\"\n")
+	fs.AddFile(filepath.Join(testRoot, "vendor", "rand-0.7.3", "Cargo.toml"), "[package]\nname = \"rand\"\nlicense = \"MIT\"\n")
+
+	// Crates are not npm packages, even when one shares an npm package's name
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		fmt.Fprint(w, `{"license": "ISC"}`)
+	}))
+	defer server.Close()
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetRegistry(registry.NewWithURL(server.URL))
+	s.SetRepositoryClient(registry.NewRawClientWithURL(server.URL))
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PackageManager != "cargo" {
+		t.Errorf("expected the cargo package manager, got %s", result.PackageManager)
+	}
+
+	expected := map[string]string{
+		"rand@0.7.3":    "MIT",
+		"rand@0.8.5":    "MIT OR Apache-2.0",
+		"serde@1.0.188": "Unknown",
+	}
+	if len(result.Dependencies) != len(expected) {
+		t.Fatalf("expected %d crates, got %+v", len(expected), result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if license := expected[dep.Name+"@"+dep.Version]; dep.License != license {
+			t.Errorf("expected %s for %s@%s, got %s", license, dep.Name, dep.Version, dep.License)
+		}
+		if installed := dep.Name == "rand"; dep.Installed != installed || !dep.Direct {
+			t.Errorf("expected %s@%s direct with installed=%v, got %+v", dep.Name, dep.Version, installed, dep)
+		}
+	}
+	if lookups.Load() != 0 {
+		t.Errorf("expected no npm registry or repository lookups for crates, got %d", lookups.Load())
+	}
+}
+
+func TestScanner_Scan_GoModules(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("goapp")
+	fs.AddFile(filepath.Join(testRoot, "go.mod"), `module example.com/app
+
+go 1.22
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	golang.org/x/text v0.14.0 // indirect
+)
+`)
+	modCache := filepath.Join("gopath", "pkg", "mod")
+	// Upper-case letters are escaped in the module cache
+	tomlDir := filepath.Join(modCache, "github.com", "!burnt!sushi", "toml@v1.3.2")
+	fs.AddDir(tomlDir)
+	fs.AddFile(filepath.Join(tomlDir, "LICENSE"), "The MIT License (MIT)\n\nPermission is hereby granted, free of charge")
+
+	// The npm registry knows nothing about Go modules
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		fmt.Fprint(w, `{"license": "ISC"}`)
+	}))
+	defer server.Close()
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetGoModuleDetector(detector.NewGoModuleDetectorWithFileSystem(fs, modCache))
+	s.SetRegistry(registry.NewWithURL(server.URL))
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PackageManager != "go" {
+		t.Errorf("expected the go package manager, got %s", result.PackageManager)
+	}
+	if len(result.Dependencies) != 2 {
+		t.Fatalf("expected 2 modules, got %+v", result.Dependencies)
+	}
+
+	toml, text := result.Dependencies[0], result.Dependencies[1]
+	if toml.License != "MIT" || !toml.Installed || !toml.Direct {
+		t.Errorf("expected the direct toml module to be MIT from the module cache, got %+v", toml)
+	}
+	if text.License != "Unknown" || text.Installed || text.Direct || text.Depth != 2 {
+		t.Errorf("expected the indirect text module to be missing from the cache, got %+v", text)
+	}
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[0], "go mod download") {
+		t.Errorf("expected a warning to download the modules, got %v", result.Warnings)
+	}
+	if lookups.Load() != 0 {
+		t.Errorf("expected no npm registry lookups for Go modules, got %d", lookups.Load())
+	}
+}
+
+func TestScanner_Scan_Pipenv(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("pyapp")
+	fs.AddFile(filepath.Join(testRoot, "Pipfile.lock"), `{
+    "default": {
+        "pyyaml": {"version": "==6.0.1"},
+        "requests": {"version": "==2.31.0"}
+    },
+    "develop": {
+        "pytest": {"version": "==7.4.2"}
+    }
+}`)
+	fs.AddFile(filepath.Join(testRoot, "Pipfile"), "[packages]\nrequests = \"*\"\n\n[dev-packages]\npytest = \"*\"\n")
+	sitePackages := filepath.Join(testRoot, ".venv", "lib", "python3.11", "site-packages")
+	fs.AddDir(sitePackages)
+	// Installers keep the project's own spelling of the name
+	fs.AddDir(filepath.Join(sitePackages, "PyYAML-6.0.1.dist-info"))
+	fs.AddFile(filepath.Join(sitePackages, "PyYAML-6.0.1.dist-info", "METADATA"), "Name: PyYAML\nLicense: MIT\n")
+	fs.AddDir(filepath.Join(sitePackages, "requests-2.31.0.dist-info"))
+	fs.AddFile(filepath.Join(sitePackages, "requests-2.31.0.dist-info", "METADATA"), "Name: requests\nLicense: Apache 2.0\n")
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetProdOnly(true)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PackageManager != "pipenv" {
+		t.Errorf("expected the pipenv package manager, got %s", result.PackageManager)
+	}
+
+	// The develop section is left out with prod-only
+	expected := map[string]string{
+		"pyyaml@6.0.1":    "MIT",
+		"requests@2.31.0": "Apache-2.0",
+	}
+	if len(result.Dependencies) != len(expected) {
+		t.Fatalf("expected %d packages, got %+v", len(expected), result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if license := expected[dep.Name+"@"+dep.Version]; dep.License != license || !dep.Installed {
+			t.Errorf("expected %s@%s installed with %s, got %+v", dep.Name, dep.Version, license, dep)
+		}
+		if direct := dep.Name == "requests"; dep.Direct != direct {
+			t.Errorf("expected %s direct=%v, got %+v", dep.Name, direct, dep)
+		}
+	}
+}
+
+func TestScanner_Scan_RequirementsDirectOnly(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("pyapp")
+	fs.AddFile(filepath.Join(testRoot, "requirements.txt"), "requests==2.31.0\nurllib3==2.0.7\n")
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetDirectOnly(true)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Every requirement is top level
+	if len(result.Dependencies) != 2 {
+		t.Errorf("expected both requirements to be direct, got %+v", result.Dependencies)
+	}
+}
+
+func TestScanner_Scan_PnpmScopedStore(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.AddFile(filepath.Join("test", "pnpm-lock.yaml"), `lockfileVersion: '6.0'
+
+packages:
+  /@scope/util@1.2.0:
+    dev: false
+  /@scope/ui@2.0.0(react@18.2.0):
+    dev: false
+`)
+
+	// pnpm 7+ flattens the scope into the store directory name, here once
+	// with an exact version and once with a peer suffix
+	store := filepath.Join("test", "node_modules", ".pnpm")
+	fs.AddFile(filepath.Join(store, "@scope+util@1.2.0", "node_modules", "@scope", "util", "package.json"), `{"license": "BSD-3-Clause"}`)
+	fs.AddFile(filepath.Join(store, "@scope+ui@2.0.0_react@18.2.0", "node_modules", "@scope", "ui", "package.json"), `{"license": "ISC"}`)
+	fs.AddDir(filepath.Join(store, "@scope+util@1.2.0", "node_modules", "@scope", "util"))
+	fs.AddDir(filepath.Join(store, "@scope+ui@2.0.0_react@18.2.0", "node_modules", "@scope", "ui"))
+
+	result, err := NewWithDependencies("test", detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"@scope/util": "BSD-3-Clause", "@scope/ui": "ISC"}
+	if len(result.Dependencies) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %+v", len(expected), result.Dependencies)
+	}
+	for _, dep := range result.Dependencies {
+		if dep.License != expected[dep.Name] || !dep.Installed {
+			t.Errorf("%s: expected installed %s from the store, got %s (installed %v)", dep.Name, expected[dep.Name], dep.License, dep.Installed)
+		}
+	}
+}
+
+func TestScanner_Scan_PnpmWorkspace(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("repo")
+	fs.AddFile(filepath.Join(testRoot, "pnpm-workspace.yaml"), "packages:\n  - 'packages/*'\n")
+	fs.AddFile(filepath.Join(testRoot, "pnpm-lock.yaml"), `lockfileVersion: 5.4
+
+importers:
+  packages/app:
+    dependencies:
+      shared: link:../shared
+      lodash: 4.17.21
+  packages/shared:
+    dependencies:
+      lodash: 4.17.21
+
+packages:
+  /lodash@4.17.21:
+    resolution: {integrity: sha512-abc}
+`)
+	fs.AddFile(filepath.Join(testRoot, "packages", "app", "package.json"), `{"name": "app", "version": "1.0.0"}`)
+	fs.AddFile(filepath.Join(testRoot, "packages", "shared", "package.json"), `{"name": "shared", "version": "2.1.0", "license": "UNLICENSED"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var shared *EnrichedDependency
+	for i, dep := range result.Dependencies {
+		if dep.Name == "shared" {
+			shared = &result.Dependencies[i]
+		} else if dep.Local {
+			t.Errorf("expected only the workspace member to be local, got %+v", dep)
+		}
+	}
+	if shared == nil || !shared.Local || shared.Version != "2.1.0" || shared.License != "UNLICENSED" {
+		t.Errorf("expected shared@2.1.0 tagged local with the license of its manifest, got %+v", shared)
+	}
+}
+
+func TestScanner_Scan_Workspaces(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package.json"), `{"name": "monorepo", "workspaces": ["packages/*"]}`)
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"name": "monorepo"},
+			"node_modules/lodash": {"version": "4.17.21"},
+			"node_modules/debug": {"version": "4.3.4", "dependencies": {"ms": "2.1.2"}},
+			"node_modules/ms": {"version": "2.1.2"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "lodash", "package.json"), `{"name": "lodash", "license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "debug", "package.json"), `{"name": "debug", "license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "ms", "package.json"), `{"name": "ms", "license": "MIT"}`)
+
+	// web uses the root lock file, api keeps its own
+	fs.AddFile(filepath.Join(testRoot, "packages", "web", "package.json"), `{"name": "web", "dependencies": {"lodash": "^4.17.0", "debug": "^4.3.0"}}`)
+	fs.AddFile(filepath.Join(testRoot, "packages", "api", "package.json"), `{"name": "api", "dependencies": {"ms": "^2.1.3", "lodash": "^4.17.0"}}`)
+	fs.AddFile(filepath.Join(testRoot, "packages", "api", "package-lock.json"), `{
+		"lockfileVersion": 3,
+		"packages": {
+			"": {"name": "api"},
+			"node_modules/ms": {"version": "2.1.3"},
+			"node_modules/lodash": {"version": "4.17.21"}
+		}
+	}`)
+	fs.AddDir(filepath.Join(testRoot, "packages", "api", "node_modules", "ms"))
+	fs.AddFile(filepath.Join(testRoot, "packages", "api", "node_modules", "ms", "package.json"), `{"name": "ms", "license": "ISC"}`)
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetWorkspaces(true)
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := make(map[string]string)
+	for _, dep := range result.Dependencies {
+		found[dep.Name+"@"+dep.Version] = fmt.Sprintf("%s %v", dep.License, dep.Workspaces)
+	}
+	expected := map[string]string{
+		// lodash@4.17.21 is in both lock files but listed once
+		"lodash@4.17.21": "MIT [packages/api packages/web]",
+		"debug@4.3.4":    "MIT [packages/web]",
+		"ms@2.1.2":       "MIT [packages/web]",
+		// Detected in the member's node_modules
+		"ms@2.1.3": "ISC [packages/api]",
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %v, got %v", expected, found)
+	}
+
+	// Without the option only the root lock file is read
+	result, err = NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 3 || result.Dependencies[0].Workspaces != nil {
+		t.Errorf("expected the 3 root dependencies without workspaces, got %+v", result.Dependencies)
+	}
+}
+
+func TestScanner_Scan_PartialInstall(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/a": {"version": "1.0.0"},
+			"node_modules/b": {"version": "1.0.0"},
+			"node_modules/c": {"version": "1.0.0"},
+			"node_modules/d": {"version": "1.0.0"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "a", "package.json"), `{"license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "b", "package.json"), `{"name": "b"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.InstalledCoverage != 0.5 {
+		t.Errorf("expected installed coverage 0.5, got %v", result.InstalledCoverage)
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "npm ci") {
+		t.Errorf("expected incomplete install warning, got %v", result.Warnings)
+	}
+
+	// b is installed without license information, c and d are missing
+	for _, dep := range result.Dependencies {
+		expected := dep.Name == "a" || dep.Name == "b"
+		if dep.Installed != expected {
+			t.Errorf("dependency %s: expected installed=%v", dep.Name, expected)
+		}
+	}
+}
+
+func TestScanner_Scan_RegistryFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/some-package/1.0.0" {
+			fmt.Fprint(w, `{"license": "ISC"}`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/some-package": {"version": "1.0.0"},
+			"node_modules/local-package": {"version": "2.0.0"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "local-package", "package.json"), `{"license": "MIT"}`)
+
+	scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	scanner.SetRegistry(registry.NewWithURL(server.URL))
+
+	result, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dep := range result.Dependencies {
+		switch dep.Name {
+		case "some-package":
+			if dep.License != "ISC" || dep.Source != "registry" {
+				t.Errorf("expected registry license ISC, got %s from %s", dep.License, dep.Source)
+			}
+		case "local-package":
+			if dep.License != "MIT" || dep.Source != "package.json" {
+				t.Errorf("expected local detection to win, got %s from %s", dep.License, dep.Source)
+			}
+		}
+	}
+}
+
+func TestScanner_Scan_RegistryMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tampered-package/1.0.0":
+			fmt.Fprint(w, `{"license": "GPL-3.0"}`)
+		case "/honest-package/1.0.0":
+			fmt.Fprint(w, `{"license": "MIT"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/tampered-package": {"version": "1.0.0"},
+			"node_modules/honest-package": {"version": "1.0.0"}
+		}
+	}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "tampered-package", "package.json"), `{"license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "honest-package", "package.json"), `{"license": "MIT"}`)
+
+	scanner := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	scanner.SetRegistry(registry.NewWithURL(server.URL))
+
+	result, err := scanner.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dep := range result.Dependencies {
+		switch dep.Name {
+		case "tampered-package":
+			if dep.License != "MIT" || dep.RegistryLicense != "GPL-3.0" {
+				t.Errorf("expected MIT locally and GPL-3.0 in the registry, got %s and %s", dep.License, dep.RegistryLicense)
+			}
+		case "honest-package":
+			if dep.RegistryLicense != "" {
+				t.Errorf("expected no registry mismatch, got %s", dep.RegistryLicense)
+			}
+		}
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "tampered-package@1.0.0") {
+		t.Errorf("expected a mismatch warning for tampered-package, got %v", result.Warnings)
+	}
+}
+
+func TestScanner_Scan_MixedLicenseSources(t *testing.T) {
+	fs := NewMockFileSystem()
+
+	// Add package-lock.json with multiple dependencies
+	lockContent := `{
+		"name": "test-project",
+		"version": "1.0.0",
+		"packages": {
+			"": {
+				"name": "test-project",
+				"version": "1.0.0"
+			},
+			"node_modules/package-json-license": {
+				"version": "1.0.0"
+			},
+			"node_modules/license-file-license": {
+				"version": "1.0.0"
+			},
+			"node_modules/no-license": {
+				"version": "1.0.0"
+			}
+		}
+	}`
+	// Use cross-platform test root path
+	testRoot := filepath.Join("test")
+
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), lockContent)
+
+	// Add license via package.json for first dependency
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "package-json-license", "package.json"), `{"license": "Apache-2.0"}`)
+
+	// Add license via LICENSE file for second dependency
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "license-file-license", "LICENSE"), "Apache License\nVersion 2.0, January 2004\n\nLicensed under the Apache License, Version 2.0")
+
+	// No license information for third dependency
+
+	mockDetector := detector.NewWithFileSystem(fs)
+	scanner := NewWithDependencies(testRoot, mockDetector, fs)
+
+	result, err := scanner.Scan()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+
+	if len(result.Dependencies) != 3 {
+		t.Errorf("expected 3 dependencies, got %d", len(result.Dependencies))
+		return
+	}
+
+	// Convert to map for easier checking
+	depMap := make(map[string]EnrichedDependency)
+	for _, dep := range result.Dependencies {
+		depMap[dep.Name] = dep
+	}
+
+	// Check package.json source
+	if dep, exists := depMap["package-json-license"]; exists {
+		if dep.License != "Apache-2.0" {
+			t.Errorf("package-json-license: expected license 'Apache-2.0', got %s", dep.License)
+		}
+		if dep.Source != "package.json" {
+			t.Errorf("package-json-license: expected source 'package.json', got %s", dep.Source)
+		}
+		if dep.Confidence != 1.0 {
+			t.Errorf("package-json-license: expected confidence 1.0, got %f", dep.Confidence)
+		}
+	} else {
+		t.Error("package-json-license dependency not found")
+	}
+
+	// Check LICENSE file source
+	if dep, exists := depMap["license-file-license"]; exists {
+		if dep.License != "Apache-2.0" {
+			t.Errorf("license-file-license: expected license 'Apache-2.0', got %s", dep.License)
+		}
+		if dep.Source != "LICENSE file" {
+			t.Errorf("license-file-license: expected source 'LICENSE file', got %s", dep.Source)
+		}
+		if dep.Confidence != 0.9 {
+			t.Errorf("license-file-license: expected confidence 0.9, got %f", dep.Confidence)
+		}
+	} else {
+		t.Error("license-file-license dependency not found")
+	}
+
+	// Check no license fallback
+	if dep, exists := depMap["no-license"]; exists {
+		if dep.License != "Unknown" {
+			t.Errorf("no-license: expected license 'Unknown', got %s", dep.License)
+		}
+		if dep.Source != "not found" {
+			t.Errorf("no-license: expected source 'not found', got %s", dep.Source)
+		}
+		if dep.Confidence != 0.0 {
+			t.Errorf("no-license: expected confidence 0.0, got %f", dep.Confidence)
+		}
+	} else {
+		t.Error("no-license dependency not found")
+	}
+}
+
+func TestScanner_Scan_PackageNameMismatch(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/left-pad": {"version": "1.3.0"},
+			"node_modules/lodash": {"version": "4.17.21"},
+			"node_modules/string-pad": {"name": "left-pad", "version": "1.3.0"}
+		}
+	}`)
+	// The left-pad directory holds another package
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "left-pad", "package.json"), `{"name": "evil-pad", "license": "MIT"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "lodash", "package.json"), `{"name": "lodash", "license": "MIT"}`)
+	// An npm: alias installs left-pad under another name
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "string-pad", "package.json"), `{"name": "left-pad", "license": "WTFPL"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "left-pad@1.3.0") || !strings.Contains(result.Warnings[0], "evil-pad") {
+		t.Errorf("expected a single name mismatch warning for left-pad, got %v", result.Warnings)
+	}
+}
+
+func TestScanner_Scan_LockFileLicense(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	fs.AddFile(filepath.Join(testRoot, "yarn.lock"), `lodash@^4.17.21:
+  version "4.17.21"
+  license "MIT"
+
+react@^18.2.0:
+  version "18.2.0"
+`)
+	// Only react is read from disk; lodash is not even installed
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "react", "package.json"), `{"name": "react", "license": "MIT"}`)
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sources := make(map[string]string)
+	for _, dep := range result.Dependencies {
+		if dep.License != "MIT" {
+			t.Errorf("dependency %s: expected MIT, got %s", dep.Name, dep.License)
+		}
+		sources[dep.Name] = fmt.Sprintf("%s %.1f", dep.Source, dep.Confidence)
+	}
+	expected := map[string]string{"lodash": "lock file 0.9", "react": "package.json 1.0"}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expected sources %v, got %v", expected, sources)
+	}
+}
+
+func TestScanner_Scan_LockFileLicenseInstalled(t *testing.T) {
+	fs := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	// package-lock v2 and v3 record the license of every package
+	fs.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"dependencies": {"ms": "^2.1.3"}},
+    "node_modules/ms": {"version": "2.1.3", "license": "MIT"}
+  }
+}`)
+	fs.AddDir(filepath.Join(testRoot, "node_modules", "ms"))
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "ms", "package.json"), `{"name": "ms", "version": "2.1.3"}`)
+	fs.AddFile(filepath.Join(testRoot, "node_modules", "ms", "LICENSE"), "MIT License\n\nPermission is hereby granted, free of charge, to any person obtaining a copy")
+
+	result, err := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs).Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Dependencies) != 1 {
+		t.Fatalf("expected 1 dependency, got %+v", result.Dependencies)
+	}
+
+	// The installed license file is read, so it can be hashed and reviewed
+	dep := result.Dependencies[0]
+	if dep.License != "MIT" || dep.Source != "LICENSE file" || dep.TextHash == "" {
+		t.Errorf("expected MIT from the hashed LICENSE file, got %+v", dep)
+	}
+}
+
+// cancelingFileSystem cancels the scan once the first installed package is read
+type cancelingFileSystem struct {
+	*MockFileSystem
+	cancel context.CancelFunc
+}
+
+func (fs *cancelingFileSystem) Open(path string) (io.ReadCloser, error) {
+	if strings.Contains(path, "node_modules") {
+		fs.cancel()
+	}
+	return fs.MockFileSystem.Open(path)
+}
+
+func TestScanner_ScanContext_Canceled(t *testing.T) {
+	fs := newLargeProject(100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewWithDependencies("test", detector.NewWithFileSystem(fs), fs)
+	if _, err := s.ScanContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled before parsing, got %v", err)
+	}
+
+	// Canceled while detecting: the remaining dependencies are skipped
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	canceling := &cancelingFileSystem{MockFileSystem: fs, cancel: cancel}
+	s = NewWithDependencies("test", detector.NewWithFileSystem(canceling), canceling)
+	s.SetWorkers(1)
+	if _, err := s.ScanContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled during detection, got %v", err)
+	}
+}
+
+// latencyFileSystem adds a fixed delay to every access, like a cold disk
+type latencyFileSystem struct {
+	*MockFileSystem
+	delay time.Duration
+}
+
+func (fs *latencyFileSystem) Open(path string) (io.ReadCloser, error) {
+	time.Sleep(fs.delay)
+	return fs.MockFileSystem.Open(path)
+}
+
+func (fs *latencyFileSystem) Stat(path string) (os.FileInfo, error) {
+	time.Sleep(fs.delay)
+	return fs.MockFileSystem.Stat(path)
+}
+
+// newLargeProject creates an npm project with count installed dependencies
+// whose licenses are only known from their package.json
+func newLargeProject(count int) *MockFileSystem {
+	fs := NewMockFileSystem()
+	var packages []string
+	for i := range count {
+		name := fmt.Sprintf("pkg-%04d", i)
+		license := []string{"MIT", "ISC", "Apache-2.0"}[i%3]
+		packages = append(packages, fmt.Sprintf(`"node_modules/%s": {"version": "1.0.%d"}`, name, i))
+		fs.AddFile(filepath.Join("test", "node_modules", name, "package.json"),
+			fmt.Sprintf(`{"name": %q, "license": %q}`, name, license))
+	}
+	fs.AddFile(filepath.Join("test", "package-lock.json"),
+		`{"name": "test-project", "packages": {"": {"name": "test-project"}, `+strings.Join(packages, ", ")+`}}`)
+	return fs
+}
+
+func TestScanner_Scan_Workers(t *testing.T) {
+	fs := newLargeProject(200)
+
+	sequential := NewWithDependencies("test", detector.NewWithFileSystem(fs), fs)
+	sequential.SetWorkers(1)
+	expected, err := sequential.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parallel := NewWithDependencies("test", detector.NewWithFileSystem(fs), fs)
+	parallel.SetWorkers(8)
+	result, err := parallel.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The npm parser walks a map, so compare both scans in name order
+	byName := func(deps []EnrichedDependency) []EnrichedDependency {
+		sorted := append([]EnrichedDependency(nil), deps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+		return sorted
+	}
+	if !reflect.DeepEqual(byName(result.Dependencies), byName(expected.Dependencies)) {
+		t.Error("expected parallel detection to match sequential detection")
+	}
+
+	// Every result must land at the index of the dependency it was detected for
+	for _, dep := range result.Dependencies {
+		var i int
+		if _, err := fmt.Sscanf(dep.Name, "pkg-%04d", &i); err != nil {
+			t.Fatalf("unexpected dependency %s", dep.Name)
+		}
+		if want := []string{"MIT", "ISC", "Apache-2.0"}[i%3]; dep.License != want || dep.Version != fmt.Sprintf("1.0.%d", i) {
+			t.Errorf("%s: expected %s@1.0.%d, got %s@%s", dep.Name, want, i, dep.License, dep.Version)
+		}
+	}
+}
+
+// BenchmarkScanner_Scan shows detection overlapping file system latency;
+// compare with go test -bench Scanner_Scan -benchtime 3x
+func BenchmarkScanner_Scan(b *testing.B) {
+	fs := &latencyFileSystem{MockFileSystem: newLargeProject(2000), delay: 10 * time.Microsecond}
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				s := NewWithDependencies("test", detector.NewWithFileSystem(fs), fs)
+				s.SetWorkers(workers)
+				if _, err := s.Scan(); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// countingFileSystem counts the files opened below each installed package
+type countingFileSystem struct {
+	*MockFileSystem
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (fs *countingFileSystem) Open(path string) (io.ReadCloser, error) {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts[:len(parts)-1] {
+		if part == "node_modules" {
+			fs.mu.Lock()
+			fs.opens[parts[i+1]]++
+			fs.mu.Unlock()
+			break
+		}
+	}
+	return fs.MockFileSystem.Open(path)
+}
+
+func TestScanner_Scan_SBOMLicenses(t *testing.T) {
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lookups[r.URL.Path]++
+		mu.Unlock()
+		fmt.Fprint(w, `{"license": "MIT"}`)
+	}))
+	defer server.Close()
+
+	mock := NewMockFileSystem()
+	testRoot := filepath.Join("test")
+	mock.AddFile(filepath.Join(testRoot, "package-lock.json"), `{
+		"packages": {
+			"node_modules/covered": {"version": "1.0.0"},
+			"node_modules/uncovered": {"version": "2.0.0"}
+		}
+	}`)
+	mock.AddFile(filepath.Join(testRoot, "node_modules", "covered", "LICENSE"), "MIT License")
+	mock.AddFile(filepath.Join(testRoot, "node_modules", "uncovered", "package.json"), `{"name": "uncovered", "license": "MIT"}`)
+	fs := &countingFileSystem{MockFileSystem: mock, opens: make(map[string]int)}
+
+	s := NewWithDependencies(testRoot, detector.NewWithFileSystem(fs), fs)
+	s.SetRegistry(registry.NewWithURL(server.URL))
+	// Only an exact name@version match is trusted
+	s.SetSBOMLicenses(map[string]string{"covered@1.0.0": "Apache-2.0", "uncovered@1.0.0": "ISC"})
+
+	result, err := s.Scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sources := make(map[string]string)
+	for _, dep := range result.Dependencies {
+		sources[dep.Name] = fmt.Sprintf("%s %s %.2f", dep.License, dep.Source, dep.Confidence)
+	}
+	expected := map[string]string{"covered": "Apache-2.0 SBOM 0.95", "uncovered": "MIT package.json 1.00"}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expected %v, got %v", expected, sources)
+	}
+
+	if fs.opens["covered"] != 0 || fs.opens["uncovered"] == 0 {
+		t.Errorf("expected only the uncovered package to be read, got %v", fs.opens)
+	}
+	if lookups["/covered/1.0.0"] != 0 || lookups["/uncovered/2.0.0"] != 1 {
+		t.Errorf("expected one registry lookup for the uncovered package only, got %v", lookups)
 	}
 }
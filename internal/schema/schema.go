@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect of generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema
+type Schema map[string]interface{}
+
+// Generate derives a JSON Schema from the JSON encoding of v's type. Named
+// struct types are emitted once under $defs and referenced; fields without
+// omitempty or omitzero are required.
+func Generate(v interface{}) Schema {
+	t := reflect.TypeOf(v)
+	g := &generator{defs: make(map[string]Schema), pkgPath: t.PkgPath()}
+	root := g.schemaFor(t, true)
+	root["$schema"] = Draft
+	if len(g.defs) > 0 {
+		defs := make(map[string]interface{}, len(g.defs))
+		for name, def := range g.defs {
+			defs[name] = def
+		}
+		root["$defs"] = defs
+	}
+	return root
+}
+
+type generator struct {
+	defs map[string]Schema
+	// pkgPath is the package of the root type, whose types need no qualifier
+	pkgPath string
+}
+
+// schemaFor returns the schema of t; inline forces the root struct to be
+// expanded rather than referenced
+func (g *generator) schemaFor(t reflect.Type, inline bool) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem(), false)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem(), false)}
+	case reflect.Struct:
+		if inline || t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.defName(t)
+		if _, exists := g.defs[name]; !exists {
+			g.defs[name] = nil // Reserve the name so recursive types terminate
+			g.defs[name] = g.structSchema(t)
+		}
+		return Schema{"$ref": "#/$defs/" + name}
+	default:
+		// Interfaces accept any JSON value
+		return Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) Schema {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		properties[name] = g.schemaFor(field.Type, false)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonName returns the encoded name of a field as encoding/json would
+func jsonName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	options = "," + options + ","
+	return name, strings.Contains(options, ",omitempty,") || strings.Contains(options, ",omitzero,"), false
+}
+
+// defName returns the $defs key of a named type, e.g. "Dependency" or
+// "approval.Violation" for types from other packages than the root type
+func (g *generator) defName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if pkg == "" || pkg == g.pkgPath {
+		return t.Name()
+	}
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + t.Name()
+}
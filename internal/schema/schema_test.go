@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type node struct {
+	Name     string                 `json:"name"`
+	Weight   float64                `json:"weight,omitempty"`
+	Children []node                 `json:"children"`
+	Labels   map[string]int         `json:"labels"`
+	Extra    interface{}            `json:"extra,omitempty"`
+	Ignored  string                 `json:"-"`
+	Meta     struct{ Private bool } `json:"meta"`
+	internal string
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(node{})
+
+	if schema["$schema"] != Draft || schema["type"] != "object" {
+		t.Fatalf("unexpected root schema: %v", schema)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"name", "weight", "children", "labels", "extra", "meta"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("expected property %s", name)
+		}
+	}
+	if _, ok := properties["Ignored"]; ok {
+		t.Error("expected json:\"-\" fields to be skipped")
+	}
+
+	if !reflect.DeepEqual(schema["required"], []string{"name", "children", "labels", "meta"}) {
+		t.Errorf("unexpected required fields: %v", schema["required"])
+	}
+
+	// The recursive type is referenced through $defs
+	children := properties["children"].(Schema)
+	if children["items"].(Schema)["$ref"] != "#/$defs/node" {
+		t.Errorf("expected children to reference the node definition, got %v", children)
+	}
+	if _, ok := schema["$defs"].(map[string]interface{})["node"]; !ok {
+		t.Errorf("expected a node definition, got %v", schema["$defs"])
+	}
+
+	if _, err := json.Marshal(schema); err != nil {
+		t.Errorf("schema is not valid JSON: %v", err)
+	}
+}
@@ -2,10 +2,19 @@ package templates
 
 import (
 	_ "embed"
+	"encoding/base64"
+	"fmt"
 	"html/template"
+	"net/http"
+	"os"
 	"strings"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
 )
 
+// DefaultTitle is the report title used when no custom title is provided
+const DefaultTitle = "License Scanner Report"
+
 //go:embed report.gohtml
 var reportHTML string
 
@@ -19,13 +28,41 @@ var reportJS string
 type TemplateData struct {
 	CSS template.CSS
 	JS  template.JS
+	// Optional branding shown in the report header
+	Logo  template.URL
+	Title string
+	// ShowSummary renders the summary panel (disabled by -no-summary)
+	ShowSummary bool
 	// Embed the actual report data
 	Summary struct {
-		TotalDependencies int      `json:"totalDependencies"`
-		UniqueLicenses    []string `json:"uniqueLicenses"`
-		RiskLevel         string   `json:"riskLevel"`
-		Conflicts         []string `json:"conflicts"`
-		Recommendations   []string `json:"recommendations"`
+		TotalDependencies   int                   `json:"totalDependencies"`
+		ResultHash          string                `json:"resultHash"`
+		InstalledCoverage   float64               `json:"installedCoverage"`
+		ProjectLicense      string                `json:"projectLicense"`
+		ProjectPrivate      bool                  `json:"projectPrivate"`
+		UniqueLicenses      []string              `json:"uniqueLicenses"`
+		UnknownPercentage   float64               `json:"unknownPercentage"`
+		HighRiskCount       int                   `json:"highRiskCount"`
+		RiskScore           float64               `json:"riskScore"`
+		RiskPoints          int                   `json:"riskPoints"`
+		RiskLevel           string                `json:"riskLevel"`
+		PredominantLicense  string                `json:"predominantLicense"`
+		Conflicts           []string              `json:"conflicts"`
+		Denied              []string              `json:"denied"`
+		Recommendations     []string              `json:"recommendations,omitempty"`
+		Obligations         []analyzer.Obligation `json:"obligations"`
+		CopyleftTainted     []string              `json:"copyleftTainted"`
+		ConfidenceHistogram map[string]int        `json:"confidenceHistogram"`
+		DepthCounts         map[int]int           `json:"depthCounts"`
+		// Dependencies that cannot be distributed under the project license
+		IncompatibleWithProject []string `json:"incompatibleWithProject,omitempty"`
+		// Recommendations with their category, severity and affected packages
+		StructuredRecommendations []analyzer.Recommendation `json:"structuredRecommendations,omitempty"`
+		// Conflicts with the direct and transitive dependencies involved
+		StructuredConflicts []analyzer.Conflict `json:"structuredConflicts,omitempty"`
+		// Scanner warnings such as tampered packages or an incomplete
+		// install, reported even with -no-recommendations
+		Warnings []string `json:"warnings,omitempty"`
 	} `json:"summary"`
 	Dependencies []Dependency `json:"dependencies"`
 	Timestamp    string       `json:"timestamp,omitempty"`
@@ -54,7 +91,28 @@ func GetReportTemplate() (*template.Template, error) {
 // GetTemplateData creates template data with embedded CSS and JS
 func GetTemplateData() TemplateData {
 	return TemplateData{
-		CSS: template.CSS(reportCSS),
-		JS:  template.JS(reportJS),
+		CSS:   template.CSS(reportCSS),
+		JS:    template.JS(reportJS),
+		Title: DefaultTitle,
+
+		ShowSummary: true,
+	}
+}
+
+// LoadLogo reads an image file and encodes it as a base64 data URL
+func LoadLogo(path string) (template.URL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logo: %w", err)
+	}
+
+	contentType := http.DetectContentType(data)
+	if strings.HasSuffix(strings.ToLower(path), ".svg") {
+		contentType = "image/svg+xml"
 	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("logo %s is not an image (%s)", path, contentType)
+	}
+
+	return template.URL("data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
 }
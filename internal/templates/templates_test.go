@@ -0,0 +1,95 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Minimal 1x1 PNG image
+var pngPixel = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+	0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89,
+}
+
+func renderReport(t *testing.T, data TemplateData) string {
+	t.Helper()
+	tmpl, err := GetReportTemplate()
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("failed to render template: %v", err)
+	}
+	return buf.String()
+}
+
+func TestReport_CustomBranding(t *testing.T) {
+	logoPath := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logoPath, pngPixel, 0o644); err != nil {
+		t.Fatalf("failed to write logo: %v", err)
+	}
+
+	logo, err := LoadLogo(logoPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := GetTemplateData()
+	data.Title = "ACME License Report"
+	data.Logo = logo
+	html := renderReport(t, data)
+
+	expectedLogo := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngPixel)
+	if !strings.Contains(html, `src="`+expectedLogo+`"`) {
+		t.Error("Expected base64 logo to be embedded in the report")
+	}
+
+	if !strings.Contains(html, "<title>ACME License Report</title>") {
+		t.Error("Expected custom title in the report")
+	}
+}
+
+func TestReport_DefaultTitle(t *testing.T) {
+	html := renderReport(t, GetTemplateData())
+
+	if !strings.Contains(html, "<title>"+DefaultTitle+"</title>") {
+		t.Error("Expected default title in the report")
+	}
+
+	if strings.Contains(html, `class="logo"`) {
+		t.Error("Expected no logo when none is configured")
+	}
+}
+
+func TestReport_ShowSummary(t *testing.T) {
+	if html := renderReport(t, GetTemplateData()); !strings.Contains(html, `<div class="summary">`) {
+		t.Error("Expected the summary panel by default")
+	}
+
+	data := GetTemplateData()
+	data.ShowSummary = false
+	html := renderReport(t, data)
+	if strings.Contains(html, `<div class="summary">`) || strings.Contains(html, "Risk Level") {
+		t.Error("Expected no summary panel when ShowSummary is false")
+	}
+	if !strings.Contains(html, "dependencyTable") {
+		t.Error("Expected the dependency table without the summary")
+	}
+}
+
+func TestLoadLogo_NotAnImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := LoadLogo(path); err == nil {
+		t.Error("Expected error for non-image logo")
+	}
+}
@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Defaults for how often files are checked and how long they must stay
+// unchanged before a change is reported
+const (
+	DefaultInterval = 250 * time.Millisecond
+	DefaultDebounce = 500 * time.Millisecond
+)
+
+// Watcher polls files for changes. Package managers rewrite lock files in
+// several steps, so a change is reported only once the files have settled.
+// Polling is deliberate rather than file system notifications: it needs no
+// dependency such as fsnotify, survives lock files being replaced by rename
+// and works on network and container mounts that send no events.
+type Watcher struct {
+	paths    []string
+	interval time.Duration
+	debounce time.Duration
+	stat     func(string) (os.FileInfo, error)
+}
+
+// fileState identifies a version of a file; a missing file has the zero state
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// New creates a watcher for the given files
+func New(paths []string) *Watcher {
+	return &Watcher{
+		paths:    paths,
+		interval: DefaultInterval,
+		debounce: DefaultDebounce,
+		stat:     os.Stat,
+	}
+}
+
+// SetInterval sets how often the files are checked
+func (w *Watcher) SetInterval(interval time.Duration) {
+	w.interval = interval
+}
+
+// SetDebounce sets how long the files must stay unchanged after a write
+// before onChange is called
+func (w *Watcher) SetDebounce(debounce time.Duration) {
+	w.debounce = debounce
+}
+
+// Run calls onChange after each settled change to the watched files until
+// ctx is done. Writes within the debounce window trigger a single call.
+func (w *Watcher) Run(ctx context.Context, onChange func()) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	current := w.snapshot()
+	var lastChange time.Time
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			if next := w.snapshot(); !equal(current, next) {
+				current = next
+				lastChange = now
+				pending = true
+				continue
+			}
+			if pending && now.Sub(lastChange) >= w.debounce {
+				pending = false
+				onChange()
+			}
+		}
+	}
+}
+
+func (w *Watcher) snapshot() []fileState {
+	states := make([]fileState, len(w.paths))
+	for i, path := range w.paths {
+		if info, err := w.stat(path); err == nil {
+			states[i] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return states
+}
+
+func equal(a, b []fileState) bool {
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package-lock.json")
+	if err := os.WriteFile(path, []byte(`{"lockfileVersion": 3}`), 0o644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+
+	watcher := New([]string{path})
+	watcher.SetInterval(10 * time.Millisecond)
+	watcher.SetDebounce(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var scans atomic.Int32
+	rescanned := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, func() {
+			scans.Add(1)
+			rescanned <- struct{}{}
+		})
+	}()
+
+	// Rapid successive writes, as npm install makes, trigger a single re-scan
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		content := []byte(`{"lockfileVersion": 3, "packages": {}}` + string(rune('a'+i)))
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("failed to update lock file: %v", err)
+		}
+		time.Sleep(15 * time.Millisecond)
+	}
+
+	select {
+	case <-rescanned:
+	case <-ctx.Done():
+		t.Fatal("expected a re-scan after the lock file changed")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if n := scans.Load(); n != 1 {
+		t.Errorf("expected the writes to be debounced into 1 re-scan, got %d", n)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected Run to stop with the context, got %v", err)
+	}
+}
+
+func TestWatcher_Run_NoChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yarn.lock")
+	if err := os.WriteFile(path, []byte("# yarn lockfile v1\n"), 0o644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+
+	watcher := New([]string{path})
+	watcher.SetInterval(5 * time.Millisecond)
+	watcher.SetDebounce(5 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	called := false
+	_ = watcher.Run(ctx, func() { called = true })
+
+	if called {
+		t.Error("expected no re-scan without a change")
+	}
+}
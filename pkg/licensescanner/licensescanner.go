@@ -0,0 +1,234 @@
+// Package licensescanner scans the dependencies of a JavaScript project for
+// their licenses and assesses the license risk, for embedding the scanner in
+// Go programs instead of running the CLI and parsing its JSON output.
+package licensescanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/StefanoA1/license-scanner/internal/analyzer"
+	"github.com/StefanoA1/license-scanner/internal/constants"
+	"github.com/StefanoA1/license-scanner/internal/detector"
+	"github.com/StefanoA1/license-scanner/internal/registry"
+	"github.com/StefanoA1/license-scanner/internal/scanner"
+)
+
+// Category classifies licenses by the obligations they impose
+type Category = analyzer.LicenseCategory
+
+// License categories
+const (
+	Permissive     = analyzer.Permissive
+	WeakCopyleft   = analyzer.WeakCopyleft
+	StrongCopyleft = analyzer.StrongCopyleft
+	Proprietary    = analyzer.Proprietary
+	Unknown        = analyzer.Unknown
+)
+
+// Obligation is an obligation triggered by the dependencies' licenses and
+// how many packages trigger it
+type Obligation = analyzer.Obligation
+
+// Recommendation is a recommendation with its category, severity and the
+// packages (name@version) it concerns
+type Recommendation = analyzer.Recommendation
+
+// Conflict is a license conflict with the direct dependencies involved and
+// whether transitive ones are too
+type Conflict = analyzer.Conflict
+
+// DefaultRegistryURL is the registry used for online lookups unless
+// Options.RegistryURL is set
+const DefaultRegistryURL = registry.DefaultURL
+
+// CacheStats are the hit, miss and eviction counters of a Cache and its size
+type CacheStats = detector.CacheStats
+
+// Cache holds license detections across scans, bounded to the least recently
+// used packages. It is safe for concurrent scans, e.g. of a server's requests.
+type Cache struct {
+	lru *detector.LRUCache
+}
+
+// NewCache creates a Cache holding up to capacity detections; zero or less
+// uses a default of 10000
+func NewCache(capacity int) *Cache {
+	return &Cache{lru: detector.NewLRUCache(capacity)}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *Cache) Stats() CacheStats {
+	return c.lru.Stats()
+}
+
+// ServeHTTP writes the cache counters as JSON, for mounting as a metrics
+// endpoint
+func (c *Cache) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.Stats()) // Ignore write errors of a disconnected client
+}
+
+// Options configures a scan. The zero value scans all dependencies offline.
+type Options struct {
+	// ProdOnly leaves out devDependencies and the packages only they depend on
+	ProdOnly bool
+	// Packages restricts the scan to the named packages (name or name@version)
+	Packages []string
+	// IncludeSubtree also scans the dependencies of Packages
+	IncludeSubtree bool
+	// Registry looks up undetected licenses in the npm registry, and on
+	// pkg.go.dev for Go modules missing from the module cache
+	Registry bool
+	// RegistryURL overrides DefaultRegistryURL
+	RegistryURL string
+	// MaxLicenseSize limits how many bytes of a LICENSE file are matched
+	MaxLicenseSize int64
+	// DenyCategories are license categories that raise the risk to high
+	DenyCategories []Category
+	// ProjectLicense is the license the project is distributed under, to
+	// report the dependencies incompatible with it; Scan fails if it is not
+	// a recognized license
+	ProjectLicense string
+	// Cache reuses the detections of packages unchanged since an earlier
+	// scan sharing it
+	Cache *Cache
+}
+
+// Dependency is a scanned package and its license
+type Dependency struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	License    string   `json:"license"`
+	Category   Category `json:"-"`
+	Confidence float64  `json:"confidence"`
+	Source     string   `json:"source"`
+	// Installed is false when the package was in the lock file but not on disk
+	Installed bool `json:"installed"`
+	// Local is set for file: and link: dependencies, which are first-party
+	Local bool `json:"local,omitempty"`
+	// Denied is set when the license category is in Options.DenyCategories
+	Denied bool `json:"denied,omitempty"`
+}
+
+// Report is the result of a scan
+type Report struct {
+	PackageManager string `json:"packageManager"`
+	ProjectLicense string `json:"projectLicense"`
+	// RiskLevel is low, medium or high
+	RiskLevel string `json:"riskLevel"`
+	// RiskScore is the share of dependencies at high risk, with medium risk
+	// ones weighted half, from 0 to 100
+	RiskScore         float64      `json:"riskScore"`
+	UnknownPercentage float64      `json:"unknownPercentage"`
+	Conflicts         []string     `json:"conflicts"`
+	Recommendations   []string     `json:"recommendations"`
+	Obligations       []Obligation `json:"obligations"`
+	Warnings          []string     `json:"warnings"`
+	Dependencies      []Dependency `json:"dependencies"`
+	// StructuredRecommendations are the Recommendations with the packages
+	// they concern, in the same order
+	StructuredRecommendations []Recommendation `json:"structuredRecommendations"`
+	// IncompatibleWithProject lists the dependencies (name@version and
+	// license) that cannot be distributed under Options.ProjectLicense
+	IncompatibleWithProject []string `json:"incompatibleWithProject,omitempty"`
+	// StructuredConflicts are the Conflicts with the dependencies involved,
+	// in the same order
+	StructuredConflicts []Conflict `json:"structuredConflicts"`
+}
+
+// Scan detects the licenses of the dependencies of the project at path and
+// analyzes them. When ctx is done first, Scan stops and returns ctx.Err().
+func Scan(ctx context.Context, path string, opts Options) (*Report, error) {
+	s := scanner.New(path)
+	s.SetProdOnly(opts.ProdOnly)
+	if opts.MaxLicenseSize > 0 {
+		s.SetMaxLicenseSize(opts.MaxLicenseSize)
+	}
+	if len(opts.Packages) > 0 {
+		s.SetPackageFilter(opts.Packages, opts.IncludeSubtree)
+	}
+	if opts.Cache != nil {
+		s.SetDetectionCache(opts.Cache.lru)
+	}
+	if opts.Registry {
+		registryURL := opts.RegistryURL
+		if registryURL == "" {
+			registryURL = DefaultRegistryURL
+		}
+		s.SetRegistry(registry.NewWithURL(registryURL))
+		goModules := detector.NewGoModuleDetector(detector.DefaultGoModCache())
+		goModules.SetOnlineLookup(registry.NewGoClient())
+		s.SetGoModuleDetector(goModules)
+	}
+
+	scanResult, err := s.ScanContext(ctx)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to scan %s: %w", path, err)
+	}
+
+	licenseAnalyzer := analyzer.New()
+	licenseAnalyzer.DenyCategories(opts.DenyCategories...)
+	if err := licenseAnalyzer.SetProjectLicense(opts.ProjectLicense); err != nil {
+		return nil, err
+	}
+
+	dependencies := make([]Dependency, len(scanResult.Dependencies))
+	analyzerDeps := make([]analyzer.Dependency, len(scanResult.Dependencies))
+	for i, dep := range scanResult.Dependencies {
+		license := dep.License
+		if license == "" {
+			license = constants.UnknownLicense
+		}
+		category := licenseAnalyzer.Category(license)
+		dependencies[i] = Dependency{
+			Name:       dep.Name,
+			Version:    dep.Version,
+			License:    license,
+			Category:   category,
+			Confidence: dep.Confidence,
+			Source:     dep.Source,
+			Installed:  dep.Installed,
+			Local:      dep.Local,
+			Denied:     licenseAnalyzer.Denies(category),
+		}
+		analyzerDeps[i] = analyzer.Dependency{
+			Name:         dep.Name,
+			Version:      dep.Version,
+			License:      license,
+			Confidence:   dep.Confidence,
+			Dependencies: dep.Dependencies,
+			Depth:        dep.Depth,
+			Bundled:      dep.Bundled,
+			Local:        dep.Local,
+			Direct:       dep.Direct,
+		}
+	}
+	analysis := licenseAnalyzer.Analyze(analyzerDeps)
+	projectLicense := scanResult.ProjectLicense
+	if opts.ProjectLicense != "" {
+		projectLicense = opts.ProjectLicense
+	}
+
+	return &Report{
+		PackageManager:    scanResult.PackageManager,
+		ProjectLicense:    projectLicense,
+		RiskLevel:         analysis.RiskLevel,
+		RiskScore:         analysis.RiskScore(),
+		UnknownPercentage: analysis.UnknownPercentage(),
+		Conflicts:         analysis.Conflicts,
+		Recommendations:   analysis.Recommendations,
+		Obligations:       analysis.Obligations,
+		Warnings:          scanResult.Warnings,
+		Dependencies:      dependencies,
+
+		StructuredRecommendations: analysis.StructuredRecommendations,
+		IncompatibleWithProject:   analysis.IncompatibleWithProject,
+		StructuredConflicts:       analysis.StructuredConflicts,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package licensescanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func newProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "package.json"), `{"name": "app", "license": "MIT"}`)
+	writeFile(t, filepath.Join(root, "package-lock.json"), `{
+		"packages": {
+			"": {"dependencies": {"react": "^18.2.0", "gpl-package": "^1.0.0"}},
+			"node_modules/react": {"version": "18.2.0"},
+			"node_modules/gpl-package": {"version": "1.0.0"}
+		}
+	}`)
+	writeFile(t, filepath.Join(root, "node_modules", "react", "package.json"), `{"name": "react", "license": "MIT"}`)
+	writeFile(t, filepath.Join(root, "node_modules", "gpl-package", "package.json"), `{"name": "gpl-package", "license": "GPL-3.0"}`)
+	return root
+}
+
+func TestScan(t *testing.T) {
+	report, err := Scan(context.Background(), newProject(t), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.PackageManager != "npm" || report.ProjectLicense != "MIT" {
+		t.Errorf("expected an MIT npm project, got %s %s", report.PackageManager, report.ProjectLicense)
+	}
+	if report.RiskLevel != "high" {
+		t.Errorf("expected high risk for a GPL dependency, got %s", report.RiskLevel)
+	}
+
+	categories := make(map[string]Category)
+	for _, dep := range report.Dependencies {
+		categories[dep.Name] = dep.Category
+		if !dep.Installed || dep.Source != "package.json" {
+			t.Errorf("dependency %s: expected an installed package.json detection, got %+v", dep.Name, dep)
+		}
+	}
+	if categories["react"] != Permissive || categories["gpl-package"] != StrongCopyleft {
+		t.Errorf("unexpected categories: %v", categories)
+	}
+}
+
+func TestScan_Options(t *testing.T) {
+	report, err := Scan(context.Background(), newProject(t), Options{
+		Packages:       []string{"gpl-package"},
+		DenyCategories: []Category{StrongCopyleft},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.Dependencies) != 1 || !report.Dependencies[0].Denied {
+		t.Errorf("expected only the denied gpl-package, got %+v", report.Dependencies)
+	}
+}
+
+func TestScan_ProjectLicense(t *testing.T) {
+	report, err := Scan(context.Background(), newProject(t), Options{ProjectLicense: "MIT"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.IncompatibleWithProject) != 1 || !strings.HasPrefix(report.IncompatibleWithProject[0], "gpl-package@1.0.0") {
+		t.Errorf("expected gpl-package to be incompatible with MIT, got %v", report.IncompatibleWithProject)
+	}
+
+	if _, err := Scan(context.Background(), newProject(t), Options{ProjectLicense: "bogus"}); err == nil {
+		t.Error("expected an error for an unknown project license")
+	}
+}
+
+func TestScan_Cache(t *testing.T) {
+	cache := NewCache(10)
+	project := newProject(t)
+	for range 2 {
+		if _, err := Scan(context.Background(), project, Options{Cache: cache}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The second scan finds the project and both packages unchanged
+	expected := CacheStats{Hits: 3, Misses: 3, Size: 3}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+
+	recorder := httptest.NewRecorder()
+	cache.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	var served CacheStats
+	if err := json.NewDecoder(recorder.Body).Decode(&served); err != nil || served != expected {
+		t.Errorf("expected the metrics endpoint to serve %+v, got %+v (%v)", expected, served, err)
+	}
+}
+
+func TestScan_Errors(t *testing.T) {
+	if _, err := Scan(context.Background(), t.TempDir(), Options{}); err == nil {
+		t.Error("expected an error for a directory without a lock file")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Scan(ctx, newProject(t), Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}